@@ -0,0 +1,157 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/spf13/cobra"
+)
+
+var alertFlags struct {
+	name      string
+	kind      string
+	currency  string
+	base      string
+	direction string
+	threshold string
+	days      int
+	cooldown  int
+}
+
+// alertCmd represents the alert command
+var alertCmd = &cobra.Command{
+	Use:   "alert",
+	Short: "Manages alert rules, which are evaluated after each import",
+}
+
+// alertListCmd represents the alert list command
+var alertListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists alert rules",
+	Run: func(cmd *cobra.Command, args []string) {
+		rs, err := manager.AlertRules(context.Background())
+		if err != nil {
+			log.Fatalf("failed to obtain alert rules: %s", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tKIND\tCOOLDOWN\tLAST_FIRED_AT")
+
+		for _, r := range rs {
+			lastFiredAt := "-"
+			if r.LastFiredAt.Valid {
+				lastFiredAt = r.LastFiredAt.Time.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%dm\t%s\n", r.ID, r.Name, r.Kind, r.CooldownMinutes, lastFiredAt)
+		}
+
+		w.Flush()
+	},
+}
+
+// alertAddCmd represents the alert add command
+var alertAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Adds an alert rule",
+	Example: `  tetest alert add --kind threshold --currency USD --threshold 1.10
+  tetest alert add --kind threshold --currency JPY --base USD --direction above --threshold 110 --cooldown 60
+  tetest alert add --kind movement --currency JPY --threshold 2 --days 1
+  tetest alert add --kind staleness --currency USD --days 2`,
+	Run: func(cmd *cobra.Command, args []string) {
+		r := currency.AlertRule{
+			Name:            alertFlags.name,
+			Kind:            currency.AlertKind(alertFlags.kind),
+			CurrencyID:      alertFlags.currency,
+			Base:            alertFlags.base,
+			Direction:       currency.AlertDirection(alertFlags.direction),
+			Days:            alertFlags.days,
+			CooldownMinutes: alertFlags.cooldown,
+		}
+
+		if alertFlags.threshold != "" {
+			var err error
+
+			if r.Threshold, err = currency.ParseValue(alertFlags.threshold); err != nil {
+				log.Fatalf("invalid --threshold: %s", err)
+			}
+		}
+
+		r, err := manager.CreateAlertRule(context.Background(), r)
+		if err != nil {
+			log.Fatalf("failed to create alert rule: %s", err)
+		}
+
+		fmt.Printf("alert rule #%d created: %s\n", r.ID, r.Name)
+	},
+}
+
+// alertDeleteCmd represents the alert delete command
+var alertDeleteCmd = &cobra.Command{
+	Use:   "delete [id]",
+	Short: "Deletes an alert rule",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("invalid alert rule id: %s", args[0])
+		}
+
+		if err = manager.DeleteAlertRule(context.Background(), id); err != nil {
+			log.Fatalf("failed to delete alert rule: %s", err)
+		}
+	},
+}
+
+// alertCheckCmd represents the alert check command
+var alertCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Evaluates alert rules against the stored values and delivers the hits",
+	Run: func(cmd *cobra.Command, args []string) {
+		as, err := manager.EvaluateAlerts(context.Background())
+		if err != nil {
+			log.Fatalf("failed to evaluate alerts: %s", err)
+		}
+
+		for _, a := range as {
+			fmt.Printf("#%d %s: %s\n", a.RuleID, a.RuleName, a.Message)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(alertCmd)
+	alertCmd.AddCommand(alertListCmd, alertAddCmd, alertDeleteCmd, alertCheckCmd)
+
+	alertAddCmd.Flags().StringVar(&alertFlags.name, "name", "", "rule name, describing the condition by default")
+	alertAddCmd.Flags().StringVar(&alertFlags.kind, "kind", "", "rule kind: threshold, movement or staleness")
+	alertAddCmd.Flags().StringVar(&alertFlags.currency, "currency", "", "currency ID")
+	alertAddCmd.Flags().StringVar(&alertFlags.base, "base", currency.BaseCurrency, "base currency of a threshold")
+	alertAddCmd.Flags().StringVar(&alertFlags.direction, "direction", "", "direction a threshold is crossed in: above, below or cross (default)")
+	alertAddCmd.Flags().StringVar(&alertFlags.threshold, "threshold", "", "threshold level, or percentage of a movement")
+	alertAddCmd.Flags().IntVar(&alertFlags.days, "days", 0, "calendar days of a movement, or business days without new data (1 by default)")
+	alertAddCmd.Flags().IntVar(&alertFlags.cooldown, "cooldown", 0, "minimum minutes between two notifications")
+
+	alertAddCmd.MarkFlagRequired("kind")
+	alertAddCmd.MarkFlagRequired("currency")
+}
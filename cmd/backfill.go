@@ -0,0 +1,99 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var backfillFlags struct {
+	from      string
+	to        string
+	source    string
+	chunkSize int
+}
+
+// backfillCmd represents the backfill command
+var backfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Backfills currency history from ECB history archives",
+	Example: `  tetest backfill --from 1999-01-04 --to 2020-03-19
+  tetest backfill --source ./eurofxref-hist.xml --from 2019-01-01`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := currency.BackfillOptions{
+			ChunkSize: backfillFlags.chunkSize,
+		}
+
+		// parsing the range of dates
+		var err error
+
+		if backfillFlags.from != "" {
+			if opts.From, err = time.Parse("2006-01-02", backfillFlags.from); err != nil {
+				log.Fatalf("invalid --from date: %s", err)
+			}
+		}
+
+		if backfillFlags.to != "" {
+			if opts.To, err = time.Parse("2006-01-02", backfillFlags.to); err != nil {
+				log.Fatalf("invalid --to date: %s", err)
+			}
+		}
+
+		src, err := currency.NewECBHistorySource(backfillFlags.source)
+		if err != nil {
+			log.Fatalf("failed to initialize history source: %s", err)
+		}
+
+		// reporting progress
+		opts.Progress = func(p currency.BackfillProgress) {
+			manager.Logger().Info(
+				"backfill progress",
+				zap.Int("stored", p.Stored),
+				zap.Int("total", p.Total),
+				zap.Float64("percent", float64(p.Stored)*100/float64(p.Total)),
+			)
+		}
+
+		manager.Logger().Info("backfilling currency history", zap.String("source", src.Name()))
+
+		p, err := manager.Backfill(context.Background(), src, opts)
+		if err != nil {
+			log.Fatalf("failed to backfill currency history: %s", err)
+		}
+
+		manager.Logger().Info(
+			"backfill complete",
+			zap.Int("dates", p.Dates),
+			zap.Int("stored", p.Stored),
+			zap.Int("rejected", p.Rejected),
+		)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backfillCmd)
+
+	backfillCmd.Flags().StringVar(&backfillFlags.from, "from", "", "first publication date to backfill (YYYY-MM-DD)")
+	backfillCmd.Flags().StringVar(&backfillFlags.to, "to", "", "last publication date to backfill (YYYY-MM-DD)")
+	backfillCmd.Flags().StringVar(&backfillFlags.source, "source", "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.zip", "path or URL of the ECB history file (.xml, .csv or .zip)")
+	backfillCmd.Flags().IntVar(&backfillFlags.chunkSize, "chunk-size", currency.DefaultBackfillChunkSize, "number of rows stored at once")
+}
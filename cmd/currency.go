@@ -0,0 +1,95 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/spf13/cobra"
+)
+
+var currencyFlags struct {
+	base string
+	asOf string
+}
+
+// currencyCmd represents the currency command
+var currencyCmd = &cobra.Command{
+	Use:   "currency [id]",
+	Short: "Lists the latest currency values, or the history of a given currency",
+	Example: `  tetest currency
+  tetest currency --base USD
+  tetest currency JPY --base USD
+  tetest currency USD --as-of 2020-03-15`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var cs []currency.Currency
+		var err error
+
+		switch {
+		case currencyFlags.asOf != "":
+			if len(args) == 0 {
+				log.Fatalf("currency id is required for an as-of lookup")
+			}
+
+			date, err := time.Parse("2006-01-02", currencyFlags.asOf)
+			if err != nil {
+				log.Fatalf("invalid as-of date (expected YYYY-MM-DD): %s", currencyFlags.asOf)
+			}
+
+			r, err := manager.AsOf(context.Background(), args[0], currencyFlags.base, date)
+			if err != nil {
+				log.Fatalf("failed to obtain currency value: %s", err)
+			}
+
+			cs = []currency.Currency{r.Currency}
+		case len(args) == 0:
+			if cs, err = manager.GetLatest(context.Background(), currencyFlags.base); err != nil {
+				log.Fatalf("failed to obtain latest currency values: %s", err)
+			}
+
+			// ordering by ID for readability
+			sort.Slice(cs, func(i, j int) bool { return cs[i].ID < cs[j].ID })
+		default:
+			if cs, err = manager.GetAllByID(context.Background(), args[0], currencyFlags.base); err != nil {
+				log.Fatalf("failed to obtain currency history: %s", err)
+			}
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tVALUE\tPUB_DATE\tSOURCE")
+
+		for _, c := range cs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.ID, c.Value, c.PubDate.Time.Format("2006-01-02"), c.Source)
+		}
+
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(currencyCmd)
+
+	currencyCmd.Flags().StringVar(&currencyFlags.base, "base", currency.BaseCurrency, "base currency the values are relative to")
+	currencyCmd.Flags().StringVar(&currencyFlags.asOf, "as-of", "", "date (YYYY-MM-DD) to look up the value effective on")
+}
@@ -17,35 +17,138 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"text/tabwriter"
+	"time"
 
+	"github.com/agubarev/tetest/internal/currency"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
 // importCmd represents the import command
 var importCmd = &cobra.Command{
 	Use:   "import",
 	Short: "Imports currency feed",
+	Example: `  tetest import
+  tetest import --file rates.csv --format csv
+  cat rates.json | tetest import --file - --format json`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// importing currencies from a local file or the standard input
+		if importFlags.file != "" {
+			src, err := currency.NewFileSource(importFlags.file, importFlags.format)
+			if err != nil {
+				log.Fatalf("failed to initialize file source: %s", err)
+			}
+
+			manager.Logger().Info("importing currency data", zap.String("source", src.Name()))
+			r, err := manager.ImportFrom(context.Background(), src)
+			if err != nil {
+				log.Fatalf("failed to import currency: %s", err)
+			}
+
+			logImportReport(r)
+
+			return
+		}
+
 		// importing currencies from remote source
+		requireFeedSources()
+
 		manager.Logger().Info("importing currency data")
-		if err := manager.Import(context.Background()); err != nil {
+		r, err := manager.Import(context.Background())
+		if err != nil {
 			log.Fatalf("failed to import currency: %s", err)
 		}
+
+		logImportReport(r)
+	},
+}
+
+// logImportReport logs the summary of an import run
+func logImportReport(r currency.ImportReport) {
+	if r.Skipped {
+		manager.Logger().Info("currency feed is not modified; import skipped", zap.String("source", r.Source))
+		return
+	}
+
+	manager.Logger().Info(
+		"currency import complete",
+		zap.String("source", r.Source),
+		zap.Int("snapshots", r.Snapshots),
+		zap.Int("seen", r.Seen),
+		zap.Int("inserted", r.Inserted),
+		zap.Int("updated", r.Updated),
+		zap.Int("unchanged", r.Unchanged),
+		zap.Int("rejected", r.Rejected),
+		zap.Int("discrepancies", r.Discrepancies),
+		zap.Duration("took", r.FinishedAt.Time.Sub(r.StartedAt.Time)),
+	)
+}
+
+// importHistoryCmd represents the import history command
+var importHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Lists the most recent import runs",
+	Run: func(cmd *cobra.Command, args []string) {
+		rs, err := manager.ImportHistory(context.Background(), importHistoryFlags.limit)
+		if err != nil {
+			log.Fatalf("failed to obtain import history: %s", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTARTED\tDURATION\tSOURCE\tSEEN\tINSERTED\tUPDATED\tUNCHANGED\tREJECTED\tSTATUS")
+
+		for _, r := range rs {
+			status := "ok"
+
+			switch {
+			case r.Error != "":
+				status = "failed: " + r.Error
+			case r.Skipped:
+				status = "skipped"
+			}
+
+			fmt.Fprintf(
+				w,
+				"%d\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+				r.ID,
+				r.StartedAt.Time.Format(time.RFC3339),
+				r.FinishedAt.Time.Sub(r.StartedAt.Time).Round(time.Millisecond),
+				r.Source,
+				r.Seen,
+				r.Inserted,
+				r.Updated,
+				r.Unchanged,
+				r.Rejected,
+				status,
+			)
+		}
+
+		w.Flush()
 	},
 }
 
+var importHistoryFlags struct {
+	limit int
+}
+
+var importFlags struct {
+	file   string
+	format string
+}
+
 func init() {
 	rootCmd.AddCommand(importCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// importCmd.PersistentFlags().String("foo", "", "A help for foo")
+	importCmd.Flags().StringVar(&importFlags.file, "file", "", "import from a local file instead of the feed (\"-\" for stdin)")
+	importCmd.Flags().StringVar(&importFlags.format, "format", currency.FormatCSV, "local file format: csv, json, rss or ecbxml")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// importCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	importCmd.AddCommand(importHistoryCmd)
+	importHistoryCmd.Flags().IntVar(&importHistoryFlags.limit, "limit", 20, "maximum number of runs to list")
 }
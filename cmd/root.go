@@ -16,16 +16,21 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/agubarev/tetest/internal/currency"
+	"github.com/gocql/gocql"
 	"github.com/gocraft/dbr/v2"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
+	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
@@ -35,6 +40,13 @@ var manager *currency.Manager
 
 var cfgFile string
 
+// storeFlags select the backend store, defaulting to STORE, DB_PATH and DATA_DIR
+var storeFlags struct {
+	kind    string
+	dbPath  string
+	dataDir string
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "tetest",
@@ -48,6 +60,21 @@ to quickly create a Cobra application.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	//	Run: func(cmd *cobra.Command, args []string) { },
+
+	// closing the store if necessary, i.e. flushing the log of the memory store
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if manager == nil {
+			return
+		}
+
+		if store, err := manager.Store(); err == nil {
+			if c, ok := store.(io.Closer); ok {
+				if err = c.Close(); err != nil {
+					log.Fatalf("failed to close backend store: %s", err)
+				}
+			}
+		}
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -60,20 +87,30 @@ func Execute() {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	// NOTE: the manager is initialized after parsing flags, which select the store
+	cobra.OnInitialize(initConfig, initManager)
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.tetest.yaml)")
+	rootCmd.PersistentFlags().StringVar(&storeFlags.kind, "store", envOr("STORE", "mysql"), "backend store: mysql, postgres, sqlite, cassandra or memory")
+	rootCmd.PersistentFlags().StringVar(&storeFlags.dbPath, "db-path", envOr("DB_PATH", "rates.db"), "database file of the sqlite store")
+	rootCmd.PersistentFlags().StringVar(&storeFlags.dataDir, "data-dir", envOr("DATA_DIR", ""), "directory persisting the memory store (volatile if empty)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
 
-	// initialzing currency project
-	initManager()
+// envOr returns the value of an environment variable, or a given default if it's empty
+func envOr(key string, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+
+	return def
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -103,11 +140,10 @@ func initConfig() {
 }
 
 func initManager() {
-	// basic validation
+	// NOTE: the feed is optional here, because only commands which fetch it
+	// require it (see requireFeedSources), i.e. a local file is imported without
 	feedURL := strings.TrimSpace(os.Getenv("FEED_URL"))
-	if feedURL == "" {
-		log.Fatal("env `FEED_URL` is not set")
-	}
+	feedSources := strings.TrimSpace(os.Getenv("FEED_SOURCES"))
 
 	// initializing main logger
 	// NOTE: using a preset logger is sufficient for testing purposes
@@ -116,36 +152,104 @@ func initManager() {
 		log.Fatalf("failed to initialize logger: %s", err)
 	}
 
+	store, err := initStore(l)
+	if err != nil {
+		log.Fatalf("failed to initialize backend store: %s", err)
+	}
+
 	//---------------------------------------------------------------------------
-	// initializing mysql store
+	// initialzing new currency manager
 	//---------------------------------------------------------------------------
-	l.Info("initializing database connection")
-
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	)
+	l.Info("initializing currency manager")
 
-	connection, err := dbr.Open("mysql", dsn, nil)
-	if err != nil {
-		log.Fatalf("failed to initialize mysql connection: %s", err)
+	opts := make([]currency.ManagerOption, 0)
+
+	if feedURL != "" || feedSources != "" {
+		sources, err := initFeedSources(feedURL, feedSources)
+		if err != nil {
+			log.Fatalf("failed to initialize feed source: %s", err)
+		}
+
+		opts = append(opts, currency.WithFeedSources(sources...))
+	}
+
+	// NOTE: strict import rejects the whole feed if any item is malformed
+	strict, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("IMPORT_STRICT")))
+
+	// reconciliation of multiple sources
+	policy := currency.DefaultReconcilePolicy
+
+	if strategy := strings.TrimSpace(os.Getenv("RECONCILE_STRATEGY")); strategy != "" {
+		policy.Strategy = currency.ReconcileStrategy(strategy)
+	}
+
+	if tolerance := strings.TrimSpace(os.Getenv("RECONCILE_TOLERANCE")); tolerance != "" {
+		if policy.Tolerance, err = strconv.ParseFloat(tolerance, 64); err != nil {
+			log.Fatalf("invalid reconcile tolerance: %s", tolerance)
+		}
+	}
+
+	// precision and rounding of conversions
+	conversion := currency.DefaultConversionRounding
+
+	if precision := strings.TrimSpace(os.Getenv("CONVERSION_PRECISION")); precision != "" {
+		places, err := strconv.ParseInt(precision, 10, 32)
+		if err != nil {
+			log.Fatalf("invalid conversion precision: %s", precision)
+		}
+
+		conversion.Places = int32(places)
+	}
+
+	if mode := strings.TrimSpace(os.Getenv("CONVERSION_ROUNDING")); mode != "" {
+		conversion.Mode = currency.RoundingMode(mode)
+	}
+
+	// precision and rounding of stored values
+	values := currency.DefaultValueRounding
+
+	if precision := strings.TrimSpace(os.Getenv("VALUE_PRECISION")); precision != "" {
+		places, err := strconv.ParseInt(precision, 10, 32)
+		if err != nil {
+			log.Fatalf("invalid value precision: %s", precision)
+		}
+
+		values.Places = int32(places)
+	}
+
+	if mode := strings.TrimSpace(os.Getenv("VALUE_ROUNDING")); mode != "" {
+		values.Mode = currency.RoundingMode(mode)
+	}
+
+	if err = validateValueRounding(values); err != nil {
+		log.Fatalf("invalid value rounding: %s", err)
+	}
+
+	// maximum number of days an as-of value may lag behind the requested date
+	staleness := currency.DefaultMaxStaleness
+
+	if days := strings.TrimSpace(os.Getenv("MAX_STALENESS_DAYS")); days != "" {
+		if staleness, err = strconv.Atoi(days); err != nil {
+			log.Fatalf("invalid max staleness: %s", days)
+		}
 	}
 
-	l.Info("initializing default MySQL store")
-	mysqlStore, err := currency.NewDefaultMySQLStore(connection)
+	notifiers, err := initNotifiers(l)
 	if err != nil {
-		log.Fatalf("failed to initialize mysql backend store: %s", err)
+		log.Fatalf("failed to initialize alert notifiers: %s", err)
 	}
 
-	//---------------------------------------------------------------------------
-	// initialzing new currency manager
-	//---------------------------------------------------------------------------
-	l.Info("initializing currency manager")
-	manager, err = currency.NewManager(mysqlStore, feedURL)
+	opts = append(
+		opts,
+		currency.WithStrictImport(strict),
+		currency.WithReconcilePolicy(policy),
+		currency.WithValueRounding(values),
+		currency.WithConversionRounding(conversion),
+		currency.WithMaxStaleness(staleness),
+		currency.WithNotifiers(notifiers...),
+	)
+
+	manager, err = currency.NewManager(store, opts...)
 	if err != nil {
 		log.Fatalf("failed to initialize currency manager: %s", err)
 	}
@@ -156,3 +260,252 @@ func initManager() {
 		log.Fatalf("failed to set main logger: %s", err)
 	}
 }
+
+// storedValuePlaces is the precision of value columns of the MySQL
+// and PostgreSQL stores, i.e. decimal(15,4)
+const storedValuePlaces = 4
+
+// validateValueRounding checks the rounding of stored values, which must not
+// exceed the precision of the selected store, otherwise it's rounded twice
+func validateValueRounding(r currency.Rounding) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	switch kind := strings.TrimSpace(storeFlags.kind); kind {
+	case "", "mysql", "postgres":
+		if r.Places > storedValuePlaces {
+			return fmt.Errorf("precision of %d places exceeds %d places of stored values (--store %s)", r.Places, storedValuePlaces, kind)
+		}
+	}
+
+	return nil
+}
+
+// requireFeedSources stops a command which fetches the feed unless it's configured
+func requireFeedSources() {
+	if _, err := manager.FeedSources(); err != nil {
+		log.Fatal("neither env `FEED_URL` nor `FEED_SOURCES` is set")
+	}
+}
+
+// initStore initializes the backend store selected by --store (mysql by default),
+// both mysql and postgres connect to the database of DB_* variables
+func initStore(l *zap.Logger) (currency.Store, error) {
+	switch kind := strings.TrimSpace(storeFlags.kind); kind {
+	case "", "mysql":
+		l.Info("initializing database connection")
+
+		dsn := fmt.Sprintf(
+			"%s:%s@tcp(%s:%s)/%s",
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_NAME"),
+		)
+
+		connection, err := dbr.Open("mysql", dsn, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize mysql connection: %s", err)
+		}
+
+		l.Info("initializing default MySQL store")
+		return currency.NewDefaultMySQLStore(connection)
+	case "postgres":
+		l.Info("initializing database connection")
+
+		sslmode := strings.TrimSpace(os.Getenv("DB_SSLMODE"))
+		if sslmode == "" {
+			sslmode = "disable"
+		}
+
+		dsn := fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_NAME"),
+			sslmode,
+		)
+
+		connection, err := dbr.Open("postgres", dsn, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize postgres connection: %s", err)
+		}
+
+		l.Info("initializing PostgreSQL store")
+		return currency.NewPostgresStore(connection)
+	case "sqlite":
+		l.Info("opening sqlite database", zap.String("path", storeFlags.dbPath))
+
+		connection, err := currency.OpenSQLite(storeFlags.dbPath)
+		if err != nil {
+			return nil, err
+		}
+
+		l.Info("initializing SQLite store")
+		return currency.NewSQLiteStore(connection)
+	case "memory":
+		if storeFlags.dataDir == "" {
+			l.Warn("initializing volatile memory store; nothing is kept after exit")
+			return currency.NewMemoryStore(), nil
+		}
+
+		opts, err := initPersistenceOptions()
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, currency.WithPersistenceLogger(l.Named("[memory]")))
+
+		l.Info("initializing persistent memory store", zap.String("dir", storeFlags.dataDir))
+		return currency.NewPersistentMemoryStore(storeFlags.dataDir, opts...)
+	case "cassandra":
+		l.Info("initializing cassandra session")
+
+		keyspace := strings.TrimSpace(os.Getenv("CASSANDRA_KEYSPACE"))
+		if keyspace == "" {
+			keyspace = "tetest"
+		}
+
+		replication := 1
+		if rf := strings.TrimSpace(os.Getenv("CASSANDRA_REPLICATION")); rf != "" {
+			var err error
+			if replication, err = strconv.Atoi(rf); err != nil {
+				return nil, fmt.Errorf("invalid cassandra replication factor: %s", rf)
+			}
+		}
+
+		cluster := gocql.NewCluster(strings.Split(os.Getenv("CASSANDRA_HOSTS"), ",")...)
+
+		// NOTE: the schema is bootstrapped by a session without a keyspace
+		session, err := cluster.CreateSession()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize cassandra session: %s", err)
+		}
+
+		err = currency.InitCassandraSchema(context.Background(), session, keyspace, replication)
+		session.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		cluster.Keyspace = keyspace
+
+		if session, err = cluster.CreateSession(); err != nil {
+			return nil, fmt.Errorf("failed to initialize cassandra session: %s", err)
+		}
+
+		l.Info("initializing default Cassandra store")
+		return currency.NewDefaultCassandraStore(session)
+	default:
+		return nil, fmt.Errorf("unsupported store: %s", kind)
+	}
+}
+
+// initPersistenceOptions initializes options of the persistent memory store: when its
+// log is flushed (MEMORY_SYNC, MEMORY_SYNC_INTERVAL) and how often it is compacted
+// into a snapshot (MEMORY_SNAPSHOT_EVERY batches)
+func initPersistenceOptions() ([]currency.PersistenceOption, error) {
+	opts := make([]currency.PersistenceOption, 0)
+
+	if policy := strings.TrimSpace(os.Getenv("MEMORY_SYNC")); policy != "" {
+		opts = append(opts, currency.WithSyncPolicy(currency.SyncPolicy(policy)))
+	}
+
+	if interval := strings.TrimSpace(os.Getenv("MEMORY_SYNC_INTERVAL")); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory sync interval: %s", interval)
+		}
+
+		opts = append(opts, currency.WithSyncInterval(d))
+	}
+
+	if every := strings.TrimSpace(os.Getenv("MEMORY_SNAPSHOT_EVERY")); every != "" {
+		n, err := strconv.Atoi(every)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number of batches between snapshots: %s", every)
+		}
+
+		opts = append(opts, currency.WithSnapshotEvery(n))
+	}
+
+	return opts, nil
+}
+
+// initNotifiers initializes alert notifiers: the log is always notified, and
+// optionally a webhook (ALERT_WEBHOOK_URL) and email recipients (ALERT_EMAIL_TO)
+func initNotifiers(l *zap.Logger) ([]currency.Notifier, error) {
+	notifiers := []currency.Notifier{currency.NewLogNotifier(l)}
+
+	if url := strings.TrimSpace(os.Getenv("ALERT_WEBHOOK_URL")); url != "" {
+		n, err := currency.NewWebhookNotifier(url)
+		if err != nil {
+			return nil, err
+		}
+
+		notifiers = append(notifiers, n)
+	}
+
+	if to := strings.TrimSpace(os.Getenv("ALERT_EMAIL_TO")); to != "" {
+		n, err := currency.NewSMTPNotifier(
+			strings.TrimSpace(os.Getenv("SMTP_ADDR")),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			strings.TrimSpace(os.Getenv("ALERT_EMAIL_FROM")),
+			strings.Split(to, ","),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		notifiers = append(notifiers, n)
+	}
+
+	return notifiers, nil
+}
+
+// initFeedSources initializes feed sources either from a comma separated list
+// of "<format>=<location>" pairs in the order of priority (i.e. "csv=/data/override.csv,rss=https://...")
+// or a single feed URL of FEED_FORMAT (rss by default)
+func initFeedSources(feedURL string, feedSources string) ([]currency.FeedSource, error) {
+	if feedSources == "" {
+		format := strings.TrimSpace(os.Getenv("FEED_FORMAT"))
+		if format == "" {
+			format = currency.FormatRSS
+		}
+
+		source, err := currency.NewSource(format, feedURL)
+		if err != nil {
+			return nil, err
+		}
+
+		return []currency.FeedSource{source}, nil
+	}
+
+	sources := make([]currency.FeedSource, 0)
+
+	for _, spec := range strings.Split(feedSources, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+
+		parts := strings.SplitN(spec, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid feed source (expected <format>=<location>): %s", spec)
+		}
+
+		source, err := currency.NewSource(parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
@@ -18,11 +18,14 @@ package cmd
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/scheduler"
 	"github.com/agubarev/tetest/internal/server"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
 // startCmd represents the start command
@@ -34,30 +37,62 @@ var startCmd = &cobra.Command{
 			log.Fatal(currency.ErrNilManager)
 		}
 
+		requireFeedSources()
+
 		// running import before server start (a slight code duplication, but is ok for the test)
+		// NOTE: an unavailable upstream is not fatal, the server keeps serving stored data
 		manager.Logger().Info("importing currency data")
-		if err := manager.Import(context.Background()); err != nil {
-			log.Fatalf("failed to import currency: %s", err)
+		if r, err := manager.Import(context.Background()); err != nil {
+			manager.Logger().Warn("failed to import currency; serving stored data", zap.Error(err))
+		} else {
+			logImportReport(r)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// refreshing currencies in the background
+		if startFlags.schedule != "" {
+			schedule, err := scheduler.Parse(startFlags.schedule, currency.IsBusinessDay)
+			if err != nil {
+				log.Fatalf("failed to parse import schedule: %s", err)
+			}
+
+			job := func(ctx context.Context) error {
+				r, err := manager.Import(ctx)
+				if err != nil {
+					return err
+				}
+
+				logImportReport(r)
+
+				return nil
+			}
+
+			importer, err := scheduler.New("import", scheduler.WithJitter(schedule, startFlags.jitter), job, manager.Logger())
+			if err != nil {
+				log.Fatalf("failed to initialize import scheduler: %s", err)
+			}
+
+			go importer.Run(ctx)
 		}
 
 		// initialzing and starting the server listener
 		manager.Logger().Info("starting server")
-		if err := server.Run(context.Background(), manager, ":8080"); err != nil {
+		if err := server.Run(ctx, manager, ":8080"); err != nil {
 			log.Fatal(errors.Wrap(err, "failed to start the server"))
 		}
 	},
 }
 
+var startFlags struct {
+	schedule string
+	jitter   time.Duration
+}
+
 func init() {
 	rootCmd.AddCommand(startCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// startCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// startCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	startCmd.Flags().StringVar(&startFlags.schedule, "schedule", "@ecb", "background import schedule: cron expression, \"@every <duration>\", \"@hourly\", \"@daily\" or \"@ecb\" (empty to disable)")
+	startCmd.Flags().DurationVar(&startFlags.jitter, "jitter", 5*time.Minute, "maximum random delay added to each scheduled import")
 }
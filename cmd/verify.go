@@ -0,0 +1,104 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var gapsFlags struct {
+	from       string
+	to         string
+	currencies []string
+}
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verifies the integrity of stored currency data",
+}
+
+// verifyGapsCmd represents the verify gaps command
+var verifyGapsCmd = &cobra.Command{
+	Use:   "gaps",
+	Short: "Lists TARGET2 business days missing from the store",
+	Example: `  tetest verify gaps
+  tetest verify gaps --from 2020-01-01 --to 2020-03-19
+  tetest verify gaps --from 2020-01-01 --currency USD,JPY`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var from, to time.Time
+		var err error
+
+		if gapsFlags.from != "" {
+			if from, err = time.Parse("2006-01-02", gapsFlags.from); err != nil {
+				log.Fatalf("invalid --from date: %s", err)
+			}
+		}
+
+		if gapsFlags.to != "" {
+			if to, err = time.Parse("2006-01-02", gapsFlags.to); err != nil {
+				log.Fatalf("invalid --to date: %s", err)
+			}
+		}
+
+		r, err := manager.Gaps(context.Background(), from, to, gapsFlags.currencies...)
+		if err != nil {
+			log.Fatalf("failed to verify gaps: %s", err)
+		}
+
+		fmt.Printf(
+			"%d business days from %s to %s\n",
+			r.BusinessDays,
+			r.From.Time.Format("2006-01-02"),
+			r.To.Time.Format("2006-01-02"),
+		)
+
+		if len(r.MissingDates) > 0 {
+			fmt.Printf("missing for all currencies: %s\n", strings.Join(r.MissingDates, ", "))
+		}
+
+		if len(r.Gaps) == 0 {
+			fmt.Println("no gaps found")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEXPECTED\tMISSING\tDATES")
+
+		for _, g := range r.Gaps {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", g.CurrencyID, g.Expected, len(g.Missing), strings.Join(g.Missing, ", "))
+		}
+
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.AddCommand(verifyGapsCmd)
+
+	verifyGapsCmd.Flags().StringVar(&gapsFlags.from, "from", "", "first date to verify (YYYY-MM-DD), 30 days before --to by default")
+	verifyGapsCmd.Flags().StringVar(&gapsFlags.to, "to", "", "last date to verify (YYYY-MM-DD), yesterday by default")
+	verifyGapsCmd.Flags().StringSliceVar(&gapsFlags.currencies, "currency", nil, "currencies to verify, all stored ones by default")
+}
@@ -0,0 +1,21 @@
+-- --------------------------------------------------------
+-- 0001: conditional fetching of feeds, and the versions of
+-- applied migrations (see "upgrading the MySQL schema" in README.md)
+-- --------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS `schema_migrations` (
+  `version` int unsigned NOT NULL,
+  `applied_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `feed_state` (
+  `source` varchar(255) NOT NULL,
+  `etag` varchar(255) NOT NULL DEFAULT '',
+  `last_modified` varchar(64) NOT NULL DEFAULT '',
+  `content_hash` char(64) NOT NULL DEFAULT '',
+  `checked_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`source`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT INTO `schema_migrations` (`version`) VALUES (1);
@@ -0,0 +1,22 @@
+-- --------------------------------------------------------
+-- 0002: audit log of import runs
+-- --------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS `import_runs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `source` varchar(255) NOT NULL,
+  `started_at` timestamp(3) NOT NULL,
+  `finished_at` timestamp(3) NULL DEFAULT NULL,
+  `skipped` tinyint(1) NOT NULL DEFAULT '0',
+  `snapshots` int unsigned NOT NULL DEFAULT '0',
+  `items_seen` int unsigned NOT NULL DEFAULT '0',
+  `items_inserted` int unsigned NOT NULL DEFAULT '0',
+  `items_updated` int unsigned NOT NULL DEFAULT '0',
+  `items_unchanged` int unsigned NOT NULL DEFAULT '0',
+  `items_rejected` int unsigned NOT NULL DEFAULT '0',
+  `error` text NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `started_at` (`started_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT INTO `schema_migrations` (`version`) VALUES (2);
@@ -0,0 +1,17 @@
+-- --------------------------------------------------------
+-- 0003: quarantine of rejected feed items
+-- --------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS `quarantine` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `source` varchar(255) NOT NULL,
+  `pub_date` date NULL DEFAULT NULL,
+  `payload` text NOT NULL,
+  `reason` text NOT NULL,
+  `created_at` timestamp NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `source` (`source`),
+  KEY `created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT INTO `schema_migrations` (`version`) VALUES (3);
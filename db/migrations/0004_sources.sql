@@ -0,0 +1,27 @@
+-- --------------------------------------------------------
+-- 0004: multiple sources, their reconciliation and discrepancies
+-- NOTE: values stored before are attributed to no source ('')
+-- --------------------------------------------------------
+
+ALTER TABLE `currency`
+  ADD COLUMN `source` varchar(255) NOT NULL DEFAULT '' AFTER `pub_date`;
+
+ALTER TABLE `import_runs`
+  ADD COLUMN `discrepancies` int unsigned NOT NULL DEFAULT '0' AFTER `items_rejected`;
+
+CREATE TABLE IF NOT EXISTS `discrepancies` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `currency_id` varchar(3) NOT NULL,
+  `pub_date` date NOT NULL,
+  `source` varchar(255) NOT NULL,
+  `value` decimal(15,4) NOT NULL,
+  `chosen_source` varchar(255) NOT NULL,
+  `chosen_value` decimal(15,4) NOT NULL,
+  `deviation` double NOT NULL,
+  `created_at` timestamp NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `currency_id` (`currency_id`,`pub_date`),
+  KEY `created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT INTO `schema_migrations` (`version`) VALUES (4);
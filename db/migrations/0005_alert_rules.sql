@@ -0,0 +1,21 @@
+-- --------------------------------------------------------
+-- 0005: alert rules
+-- --------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS `alert_rules` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `kind` varchar(16) NOT NULL,
+  `currency_id` varchar(3) NOT NULL,
+  `base` varchar(3) NOT NULL DEFAULT 'EUR',
+  `direction` varchar(8) NOT NULL DEFAULT '',
+  `threshold` decimal(15,6) NOT NULL DEFAULT '0.000000',
+  `days` int unsigned NOT NULL DEFAULT '0',
+  `cooldown_minutes` int unsigned NOT NULL DEFAULT '0',
+  `last_fired_at` timestamp NULL DEFAULT NULL,
+  `last_fingerprint` varchar(255) NOT NULL DEFAULT '',
+  `created_at` timestamp NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT INTO `schema_migrations` (`version`) VALUES (5);
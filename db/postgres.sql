@@ -0,0 +1,90 @@
+-- --------------------------------------------------------
+-- PostgreSQL schema of tetest, equivalent to baseline.sql
+-- --------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS currency (
+  id varchar(3) NOT NULL,
+  value numeric(15,4) NOT NULL,
+  pub_date date NOT NULL,
+  source varchar(255) NOT NULL DEFAULT '',
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz NULL DEFAULT NULL,
+  PRIMARY KEY (id, pub_date)
+);
+
+CREATE INDEX IF NOT EXISTS currency_pub_date ON currency (pub_date);
+CREATE INDEX IF NOT EXISTS currency_created_at ON currency (created_at);
+CREATE INDEX IF NOT EXISTS currency_updated_at ON currency (updated_at);
+
+CREATE TABLE IF NOT EXISTS feed_state (
+  source varchar(255) NOT NULL,
+  etag varchar(255) NOT NULL DEFAULT '',
+  last_modified varchar(64) NOT NULL DEFAULT '',
+  content_hash char(64) NOT NULL DEFAULT '',
+  checked_at timestamptz NULL DEFAULT NULL,
+  PRIMARY KEY (source)
+);
+
+CREATE TABLE IF NOT EXISTS import_runs (
+  id bigserial NOT NULL,
+  source varchar(255) NOT NULL,
+  started_at timestamptz(3) NOT NULL,
+  finished_at timestamptz(3) NULL DEFAULT NULL,
+  skipped boolean NOT NULL DEFAULT FALSE,
+  snapshots integer NOT NULL DEFAULT 0,
+  items_seen integer NOT NULL DEFAULT 0,
+  items_inserted integer NOT NULL DEFAULT 0,
+  items_updated integer NOT NULL DEFAULT 0,
+  items_unchanged integer NOT NULL DEFAULT 0,
+  items_rejected integer NOT NULL DEFAULT 0,
+  discrepancies integer NOT NULL DEFAULT 0,
+  error text NOT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS import_runs_started_at ON import_runs (started_at);
+
+CREATE TABLE IF NOT EXISTS quarantine (
+  id bigserial NOT NULL,
+  source varchar(255) NOT NULL,
+  pub_date date NULL DEFAULT NULL,
+  payload text NOT NULL,
+  reason text NOT NULL,
+  created_at timestamptz NOT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS quarantine_source ON quarantine (source);
+CREATE INDEX IF NOT EXISTS quarantine_created_at ON quarantine (created_at);
+
+CREATE TABLE IF NOT EXISTS discrepancies (
+  id bigserial NOT NULL,
+  currency_id varchar(3) NOT NULL,
+  pub_date date NOT NULL,
+  source varchar(255) NOT NULL,
+  value numeric(15,4) NOT NULL,
+  chosen_source varchar(255) NOT NULL,
+  chosen_value numeric(15,4) NOT NULL,
+  deviation double precision NOT NULL,
+  created_at timestamptz NOT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS discrepancies_currency_id ON discrepancies (currency_id, pub_date);
+CREATE INDEX IF NOT EXISTS discrepancies_created_at ON discrepancies (created_at);
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+  id bigserial NOT NULL,
+  name varchar(255) NOT NULL,
+  kind varchar(16) NOT NULL,
+  currency_id varchar(3) NOT NULL,
+  base varchar(3) NOT NULL DEFAULT 'EUR',
+  direction varchar(8) NOT NULL DEFAULT '',
+  threshold numeric(15,6) NOT NULL DEFAULT 0,
+  days integer NOT NULL DEFAULT 0,
+  cooldown_minutes integer NOT NULL DEFAULT 0,
+  last_fired_at timestamptz NULL DEFAULT NULL,
+  last_fingerprint varchar(255) NOT NULL DEFAULT '',
+  created_at timestamptz NOT NULL,
+  PRIMARY KEY (id)
+);
@@ -7,16 +7,21 @@ require (
 	github.com/PuerkitoBio/goquery v1.5.1 // indirect
 	github.com/go-chi/chi v4.0.3+incompatible
 	github.com/go-sql-driver/mysql v1.5.0
+	github.com/gocql/gocql v1.7.0
 	github.com/gocraft/dbr/v2 v2.7.0
 	github.com/json-iterator/go v1.1.9
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v2.0.3+incompatible
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mmcdole/gofeed v1.0.0-beta2
 	github.com/mmcdole/goxpp v0.0.0-20181012175147-0068e33feabf // indirect
 	github.com/pkg/errors v0.8.0
 	github.com/r3labs/diff v0.0.0-20191120142937-b4ed99a31f5a
+	github.com/shopspring/decimal v1.4.0
 	github.com/spf13/cobra v0.0.6
 	github.com/spf13/viper v1.6.2
 	github.com/stretchr/testify v1.4.0
 	go.uber.org/zap v1.10.0
 	golang.org/x/text v0.3.2 // indirect
+	gopkg.in/inf.v0 v0.9.1
 )
@@ -0,0 +1,159 @@
+package currency
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Period represents the length of aggregation intervals
+type Period string
+
+// aggregation periods
+const (
+	PeriodWeek    Period = "week"
+	PeriodMonth   Period = "month"
+	PeriodQuarter Period = "quarter"
+	PeriodYear    Period = "year"
+)
+
+// Validate checks whether the period is supported
+func (p Period) Validate() error {
+	switch p {
+	case PeriodWeek, PeriodMonth, PeriodQuarter, PeriodYear:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnsupportedPeriod, "%s", p)
+	}
+}
+
+// Start returns the first day of the period a given date belongs to,
+// where weeks start on Monday (as in ISO 8601)
+func (p Period) Start(date time.Time) time.Time {
+	y, m, d := date.Date()
+
+	switch p {
+	case PeriodWeek:
+		return time.Date(y, m, d-(int(date.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case PeriodMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case PeriodQuarter:
+		return time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Aggregate represents the values of a currency aggregated over a single period,
+// where open and close are the values of the first and the last observed dates
+type Aggregate struct {
+	PeriodStart  dbr.NullTime    `db:"period_start" json:"period_start"`
+	FirstPubDate dbr.NullTime    `db:"first_pub_date" json:"first_pub_date"`
+	LastPubDate  dbr.NullTime    `db:"last_pub_date" json:"last_pub_date"`
+	Open         decimal.Decimal `db:"open" json:"open"`
+	High         decimal.Decimal `db:"high" json:"high"`
+	Low          decimal.Decimal `db:"low" json:"low"`
+	Close        decimal.Decimal `db:"close" json:"close"`
+	Mean         decimal.Decimal `db:"mean" json:"mean"`
+	Count        int             `db:"observations" json:"observations"`
+}
+
+// AggregateStore is implemented by stores which are able
+// to aggregate currency values over periods
+// NOTE: zero dates are unbounded, aggregates are ordered by period
+type AggregateStore interface {
+	Aggregate(ctx context.Context, id string, period Period, from, to time.Time) (as []Aggregate, err error)
+}
+
+// aggregateValues aggregates values of a currency ordered by date over periods,
+// for stores which can't aggregate by themselves
+func aggregateValues(cs []Currency, period Period) (as []Aggregate) {
+	as = make([]Aggregate, 0)
+
+	// values are ordered by date, thus each period is a contiguous run
+	sum := decimal.Zero
+
+	for _, c := range cs {
+		start := period.Start(c.PubDate.Time)
+
+		if len(as) == 0 || !as[len(as)-1].PeriodStart.Time.Equal(start) {
+			as = append(as, Aggregate{
+				PeriodStart:  dbr.NewNullTime(start),
+				FirstPubDate: c.PubDate,
+				Open:         c.Value,
+				High:         c.Value,
+				Low:          c.Value,
+			})
+
+			sum = decimal.Zero
+		}
+
+		a := &as[len(as)-1]
+		a.LastPubDate = c.PubDate
+		a.Close = c.Value
+		a.Count++
+
+		if c.Value.GreaterThan(a.High) {
+			a.High = c.Value
+		}
+
+		if c.Value.LessThan(a.Low) {
+			a.Low = c.Value
+		}
+
+		sum = sum.Add(c.Value)
+		a.Mean = sum.DivRound(decimal.New(int64(a.Count), 0), divisionPlaces)
+	}
+
+	return as
+}
+
+// Aggregate returns the values of a currency aggregated over periods
+// within a given date range, the mean is rounded as conversions are
+// NOTE: zero dates are unbounded
+func (m *Manager) Aggregate(ctx context.Context, id string, period Period, from, to time.Time) (as []Aggregate, err error) {
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	store, err := m.Store()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain currency store")
+	}
+
+	astore, ok := store.(AggregateStore)
+	if !ok {
+		return nil, ErrUnsupportedByStore
+	}
+
+	if id = strings.ToUpper(strings.TrimSpace(id)); id == "" {
+		return nil, ErrEmptyCurrencyID
+	}
+
+	period = Period(strings.ToLower(strings.TrimSpace(string(period))))
+	if err = period.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return nil, errors.Wrap(ErrInvalidHistoryQuery, "from date is after to date")
+	}
+
+	if as, err = astore.Aggregate(ctx, id, period, from, to); err != nil {
+		return nil, errors.Wrapf(err, "failed to aggregate currency values for ID: %s", id)
+	}
+
+	if len(as) == 0 {
+		return nil, errors.Wrapf(ErrCurrencyNotFound, "no %s values within the range", id)
+	}
+
+	for i := range as {
+		as[i].Mean = m.conversion.Round(as[i].Mean)
+	}
+
+	return as, nil
+}
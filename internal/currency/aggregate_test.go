@@ -0,0 +1,95 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriod_Start(t *testing.T) {
+	a := assert.New(t)
+
+	// thursday
+	date := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	a.Equal(time.Date(2020, 3, 16, 0, 0, 0, 0, time.UTC), currency.PeriodWeek.Start(date))
+	a.Equal(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), currency.PeriodMonth.Start(date))
+	a.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), currency.PeriodQuarter.Start(date))
+	a.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), currency.PeriodYear.Start(date))
+
+	// sunday belongs to the week started on the previous monday
+	a.Equal(time.Date(2020, 3, 16, 0, 0, 0, 0, time.UTC), currency.PeriodWeek.Start(time.Date(2020, 3, 22, 0, 0, 0, 0, time.UTC)))
+
+	// the last quarter
+	a.Equal(time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC), currency.PeriodQuarter.Start(time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestManager_Aggregate(t *testing.T) {
+	a := assert.New(t)
+
+	day := func(m time.Month, d int) time.Time {
+		return time.Date(2020, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(day(3, 19), map[string]float64{"USD": 1.0801}),
+			newSnapshot(day(3, 18), map[string]float64{"USD": 1.0934}),
+			newSnapshot(day(3, 17), map[string]float64{"USD": 1.0982}),
+			newSnapshot(day(3, 13), map[string]float64{"USD": 1.1104}),
+			newSnapshot(day(2, 28), map[string]float64{"USD": 1.0977}),
+			newSnapshot(day(2, 27), map[string]float64{"USD": 1.0964}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	as, err := m.Aggregate(context.Background(), "usd", currency.PeriodMonth, time.Time{}, time.Time{})
+	a.NoError(err)
+	a.Len(as, 2)
+
+	a.Equal(day(2, 1), as[0].PeriodStart.Time)
+	a.Equal(2, as[0].Count)
+	a.Equal("1.0964", as[0].Open.String())
+	a.Equal("1.0977", as[0].Close.String())
+
+	a.Equal(day(3, 1), as[1].PeriodStart.Time)
+	a.Equal(day(3, 13), as[1].FirstPubDate.Time)
+	a.Equal(day(3, 19), as[1].LastPubDate.Time)
+	a.Equal("1.1104", as[1].Open.String())
+	a.Equal("1.1104", as[1].High.String())
+	a.Equal("1.0801", as[1].Low.String())
+	a.Equal("1.0801", as[1].Close.String())
+	a.Equal("1.095525", as[1].Mean.String())
+	a.Equal(4, as[1].Count)
+
+	// weeks within a range
+	as, err = m.Aggregate(context.Background(), "USD", currency.PeriodWeek, day(3, 1), day(3, 31))
+	a.NoError(err)
+	a.Len(as, 2)
+	a.Equal(day(3, 9), as[0].PeriodStart.Time)
+	a.Equal(1, as[0].Count)
+	a.Equal(day(3, 16), as[1].PeriodStart.Time)
+	a.Equal(3, as[1].Count)
+
+	as, err = m.Aggregate(context.Background(), "USD", currency.PeriodYear, time.Time{}, time.Time{})
+	a.NoError(err)
+	a.Len(as, 1)
+	a.Equal(6, as[0].Count)
+
+	// invalid period
+	_, err = m.Aggregate(context.Background(), "USD", "fortnight", time.Time{}, time.Time{})
+	a.Equal(currency.ErrUnsupportedPeriod, errors.Cause(err))
+
+	// nothing within the range
+	_, err = m.Aggregate(context.Background(), "USD", currency.PeriodMonth, day(1, 1), day(1, 31))
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+}
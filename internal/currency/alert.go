@@ -0,0 +1,419 @@
+package currency
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// AlertKind represents the condition an alert rule checks
+type AlertKind string
+
+// alert kinds
+const (
+	// AlertThreshold fires when the value crosses a level,
+	// i.e. EUR/USD crosses 1.10
+	AlertThreshold AlertKind = "threshold"
+
+	// AlertMovement fires when the value changes by more than a percentage
+	// over a number of calendar days, i.e. JPY moves more than 2% day-over-day
+	AlertMovement AlertKind = "movement"
+
+	// AlertStaleness fires when there is no new value for a number
+	// of business days, i.e. no new data for 2 business days
+	AlertStaleness AlertKind = "staleness"
+)
+
+// AlertDirection represents the direction a threshold is crossed in
+type AlertDirection string
+
+// alert directions
+const (
+	DirectionAbove AlertDirection = "above"
+	DirectionBelow AlertDirection = "below"
+	DirectionCross AlertDirection = "cross"
+)
+
+// AlertRule represents a condition which is checked after each import
+type AlertRule struct {
+	ID         int64           `db:"id" json:"id"`
+	Name       string          `db:"name" json:"name"`
+	Kind       AlertKind       `db:"kind" json:"kind"`
+	CurrencyID string          `db:"currency_id" json:"currency_id"`
+	Base       string          `db:"base" json:"base,omitempty"`
+	Direction  AlertDirection  `db:"direction" json:"direction,omitempty"`
+	Threshold  decimal.Decimal `db:"threshold" json:"threshold"`
+	Days       int             `db:"days" json:"days,omitempty"`
+
+	// CooldownMinutes is the minimum time between two notifications of this rule
+	CooldownMinutes int `db:"cooldown_minutes" json:"cooldown_minutes"`
+
+	// the last delivered alert, which is never delivered twice
+	LastFiredAt     dbr.NullTime `db:"last_fired_at" json:"last_fired_at"`
+	LastFingerprint string       `db:"last_fingerprint" json:"-"`
+
+	CreatedAt dbr.NullTime `db:"created_at" json:"created_at"`
+}
+
+// Validate checks whether the rule is complete, assigning defaults
+// NOTE: only threshold rules may have a base currency other than EUR
+func (r *AlertRule) Validate() (err error) {
+	r.Kind = AlertKind(strings.ToLower(strings.TrimSpace(string(r.Kind))))
+	r.CurrencyID = strings.ToUpper(strings.TrimSpace(r.CurrencyID))
+	r.Base = normalizeBase(r.Base)
+	r.Direction = AlertDirection(strings.ToLower(strings.TrimSpace(string(r.Direction))))
+
+	if _, ok := LookupCurrency(r.CurrencyID); !ok {
+		return errors.Wrapf(ErrInvalidAlertRule, "unknown currency: %s", r.CurrencyID)
+	}
+
+	if r.CooldownMinutes < 0 {
+		return errors.Wrapf(ErrInvalidAlertRule, "invalid cooldown: %d", r.CooldownMinutes)
+	}
+
+	if r.Kind != AlertThreshold && r.Base != BaseCurrency {
+		return errors.Wrapf(ErrInvalidAlertRule, "%s rules are relative to %s", r.Kind, BaseCurrency)
+	}
+
+	switch r.Kind {
+	case AlertThreshold:
+		if r.Direction == "" {
+			r.Direction = DirectionCross
+		}
+
+		switch r.Direction {
+		case DirectionAbove, DirectionBelow, DirectionCross:
+		default:
+			return errors.Wrapf(ErrInvalidAlertRule, "unsupported direction: %s", r.Direction)
+		}
+
+		if _, ok := LookupCurrency(r.Base); !ok {
+			return errors.Wrapf(ErrInvalidAlertRule, "unknown base currency: %s", r.Base)
+		}
+
+		if !r.Threshold.IsPositive() {
+			return errors.Wrap(ErrInvalidAlertRule, "threshold level must be positive")
+		}
+	case AlertMovement:
+		if r.Days == 0 {
+			r.Days = 1
+		}
+
+		if !r.Threshold.IsPositive() {
+			return errors.Wrap(ErrInvalidAlertRule, "threshold percentage must be positive")
+		}
+	case AlertStaleness:
+		if r.Days == 0 {
+			r.Days = 1
+		}
+	default:
+		return errors.Wrapf(ErrInvalidAlertRule, "unsupported kind: %s", r.Kind)
+	}
+
+	if r.Days < 0 {
+		return errors.Wrapf(ErrInvalidAlertRule, "invalid number of days: %d", r.Days)
+	}
+
+	// NOTE: the name ends up in the email subject, so it must be a single line
+	if strings.ContainsAny(r.Name, "\r\n") {
+		return errors.Wrap(ErrInvalidAlertRule, "name must be a single line")
+	}
+
+	if strings.TrimSpace(r.Name) == "" {
+		r.Name = r.describe()
+	}
+
+	return nil
+}
+
+// describe returns a human readable condition of the rule
+func (r AlertRule) describe() string {
+	switch r.Kind {
+	case AlertThreshold:
+		if r.Direction == DirectionCross {
+			return fmt.Sprintf("%s/%s crosses %s", r.Base, r.CurrencyID, r.Threshold)
+		}
+
+		return fmt.Sprintf("%s/%s crosses %s %s", r.Base, r.CurrencyID, r.Direction, r.Threshold)
+	case AlertMovement:
+		return fmt.Sprintf("%s moves more than %s%% over %d days", r.CurrencyID, r.Threshold, r.Days)
+	default:
+		return fmt.Sprintf("no new %s data for %d business days", r.CurrencyID, r.Days)
+	}
+}
+
+// Alert represents a single hit of an alert rule
+type Alert struct {
+	RuleID     int64           `json:"rule_id"`
+	RuleName   string          `json:"rule_name"`
+	Kind       AlertKind       `json:"kind"`
+	CurrencyID string          `json:"currency_id"`
+	Message    string          `json:"message"`
+	Value      decimal.Decimal `json:"value"`
+	PubDate    dbr.NullTime    `json:"pub_date"`
+	FiredAt    dbr.NullTime    `json:"fired_at"`
+
+	// Fingerprint identifies the condition the alert is fired on,
+	// so the same alert isn't delivered more than once
+	Fingerprint string `json:"fingerprint"`
+}
+
+// AlertStore is implemented by stores which are able to keep alert rules
+type AlertStore interface {
+	CreateAlertRule(ctx context.Context, r AlertRule) (_ AlertRule, err error)
+	AlertRules(ctx context.Context) (rs []AlertRule, err error)
+	DeleteAlertRule(ctx context.Context, id int64) (err error)
+
+	// AlertFired remembers the last delivered alert of a rule
+	AlertFired(ctx context.Context, id int64, firedAt time.Time, fingerprint string) (err error)
+}
+
+// Notifier delivers alerts, i.e. to a log, a webhook or by email
+type Notifier interface {
+	// Name returns a human readable notifier name (mostly for logging)
+	Name() string
+
+	Notify(ctx context.Context, a Alert) (err error)
+}
+
+// WithNotifiers sets the notifiers alerts are delivered to,
+// the alerts are logged if there is none
+func WithNotifiers(ns ...Notifier) ManagerOption {
+	return func(m *Manager) error {
+		for _, n := range ns {
+			if n == nil {
+				return ErrNilNotifier
+			}
+		}
+
+		m.notifiers = ns
+
+		return nil
+	}
+}
+
+// alertStore returns the store as an alert store, if it is one
+func (m *Manager) alertStore() (AlertStore, error) {
+	store, err := m.Store()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain currency store")
+	}
+
+	astore, ok := store.(AlertStore)
+	if !ok {
+		return nil, ErrUnsupportedByStore
+	}
+
+	return astore, nil
+}
+
+// CreateAlertRule validates and stores a new alert rule
+func (m *Manager) CreateAlertRule(ctx context.Context, r AlertRule) (_ AlertRule, err error) {
+	if m == nil {
+		return r, ErrNilManager
+	}
+
+	astore, err := m.alertStore()
+	if err != nil {
+		return r, err
+	}
+
+	if err = r.Validate(); err != nil {
+		return r, err
+	}
+
+	r.CreatedAt = dbr.NewNullTime(time.Now())
+
+	if r, err = astore.CreateAlertRule(ctx, r); err != nil {
+		return r, errors.Wrap(err, "failed to create alert rule")
+	}
+
+	return r, nil
+}
+
+// AlertRules returns every alert rule
+func (m *Manager) AlertRules(ctx context.Context) (rs []AlertRule, err error) {
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	astore, err := m.alertStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return astore.AlertRules(ctx)
+}
+
+// DeleteAlertRule deletes an alert rule by its ID
+func (m *Manager) DeleteAlertRule(ctx context.Context, id int64) (err error) {
+	if m == nil {
+		return ErrNilManager
+	}
+
+	astore, err := m.alertStore()
+	if err != nil {
+		return err
+	}
+
+	return astore.DeleteAlertRule(ctx, id)
+}
+
+// EvaluateAlerts checks every alert rule against the stored values and
+// delivers the hits to the notifiers, returning the delivered alerts
+// NOTE: an alert is not delivered again if it has the same fingerprint
+// as the last one of its rule, or within the cooldown of the rule; it's
+// considered delivered if at least one notifier has succeeded
+func (m *Manager) EvaluateAlerts(ctx context.Context) (as []Alert, err error) {
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	astore, err := m.alertStore()
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := astore.AlertRules(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain alert rules")
+	}
+
+	now := time.Now()
+	as = make([]Alert, 0)
+
+	for _, rule := range rules {
+		a, hit, err := m.evaluateAlertRule(ctx, rule, now)
+		if err != nil {
+			m.Logger().Warn("failed to evaluate alert rule", zap.Int64("rule_id", rule.ID), zap.Error(err))
+			continue
+		}
+
+		if !hit || a.Fingerprint == rule.LastFingerprint {
+			continue
+		}
+
+		cooldown := time.Duration(rule.CooldownMinutes) * time.Minute
+		if rule.LastFiredAt.Valid && now.Sub(rule.LastFiredAt.Time) < cooldown {
+			m.Logger().Debug("alert rule is cooling down", zap.Int64("rule_id", rule.ID))
+			continue
+		}
+
+		if !m.notify(ctx, a) {
+			continue
+		}
+
+		if err = astore.AlertFired(ctx, rule.ID, now, a.Fingerprint); err != nil {
+			return as, errors.Wrapf(err, "failed to save the last alert of rule %d", rule.ID)
+		}
+
+		as = append(as, a)
+	}
+
+	return as, nil
+}
+
+// evaluateAlertRule checks a single rule, returning an alert if it's hit
+func (m *Manager) evaluateAlertRule(ctx context.Context, rule AlertRule, now time.Time) (a Alert, hit bool, err error) {
+	a = Alert{
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		Kind:       rule.Kind,
+		CurrencyID: rule.CurrencyID,
+		FiredAt:    dbr.NewNullTime(now),
+	}
+
+	switch rule.Kind {
+	case AlertThreshold:
+		// the two most recent values of a pair
+		p, err := m.HistoryByID(ctx, rule.CurrencyID, rule.Base, HistoryQuery{Limit: 2})
+		if err != nil {
+			return a, false, err
+		}
+
+		if len(p.Currencies) < 2 {
+			return a, false, nil
+		}
+
+		cur, prev := p.Currencies[0], p.Currencies[1]
+		above := prev.Value.LessThan(rule.Threshold) && cur.Value.GreaterThanOrEqual(rule.Threshold)
+		below := prev.Value.GreaterThan(rule.Threshold) && cur.Value.LessThanOrEqual(rule.Threshold)
+
+		switch {
+		case above && rule.Direction != DirectionBelow:
+			a.Message = fmt.Sprintf("%s/%s has crossed above %s: %s", rule.Base, rule.CurrencyID, rule.Threshold, cur.Value)
+		case below && rule.Direction != DirectionAbove:
+			a.Message = fmt.Sprintf("%s/%s has crossed below %s: %s", rule.Base, rule.CurrencyID, rule.Threshold, cur.Value)
+		default:
+			return a, false, nil
+		}
+
+		a.Value, a.PubDate = cur.Value, cur.PubDate
+	case AlertMovement:
+		ss, err := m.Stats(ctx, []string{rule.CurrencyID}, StatsQuery{Window: 2, ChangeDays: []int{rule.Days}})
+		if err != nil {
+			return a, false, err
+		}
+
+		if len(ss[0].Changes) == 0 || ss[0].Changes[0].Percent.Abs().LessThanOrEqual(rule.Threshold) {
+			return a, false, nil
+		}
+
+		c := ss[0].Changes[0]
+		a.Message = fmt.Sprintf("%s has moved by %s%% over %d days: %s -> %s", rule.CurrencyID, c.Percent, rule.Days, c.Value, ss[0].Value)
+		a.Value, a.PubDate = ss[0].Value, ss[0].PubDate
+	case AlertStaleness:
+		latest, err := m.rateAt(ctx, rule.CurrencyID, now)
+		if err != nil {
+			return a, false, err
+		}
+
+		// NOTE: today's value may be not published yet, thus not counted
+		missed := BusinessDays(latest.PubDate.Time.AddDate(0, 0, 1), now.AddDate(0, 0, -1))
+		if len(missed) < rule.Days {
+			return a, false, nil
+		}
+
+		a.Message = fmt.Sprintf("no new %s data for %d business days since %s", rule.CurrencyID, len(missed), latest.PubDate.Time.Format(dateLayout))
+		a.Value, a.PubDate = latest.Value, latest.PubDate
+	default:
+		return a, false, errors.Wrapf(ErrInvalidAlertRule, "unsupported kind: %s", rule.Kind)
+	}
+
+	// the same condition on the same data is the same alert
+	a.Fingerprint = fmt.Sprintf("%d:%s:%s", rule.ID, rule.Kind, a.PubDate.Time.Format(dateLayout))
+
+	return a, true, nil
+}
+
+// notify delivers an alert to every notifier, returning whether
+// it has been delivered by at least one of them
+func (m *Manager) notify(ctx context.Context, a Alert) (delivered bool) {
+	ns := m.notifiers
+	if len(ns) == 0 {
+		ns = []Notifier{NewLogNotifier(m.Logger())}
+	}
+
+	for _, n := range ns {
+		if err := n.Notify(ctx, a); err != nil {
+			m.Logger().Warn(
+				"failed to deliver alert",
+				zap.String("notifier", n.Name()),
+				zap.Int64("rule_id", a.RuleID),
+				zap.Error(err),
+			)
+
+			continue
+		}
+
+		delivered = true
+	}
+
+	return delivered
+}
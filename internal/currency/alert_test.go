@@ -0,0 +1,286 @@
+package currency_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingNotifier keeps delivered alerts, or fails if it has an error
+type recordingNotifier struct {
+	as  []currency.Alert
+	err error
+}
+
+func (n *recordingNotifier) Name() string {
+	return "recording"
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, a currency.Alert) error {
+	if n.err != nil {
+		return n.err
+	}
+
+	n.as = append(n.as, a)
+
+	return nil
+}
+
+// ruleIDs returns sorted rule IDs of given alerts
+func ruleIDs(as []currency.Alert) []int64 {
+	ids := make([]int64, 0, len(as))
+	for _, a := range as {
+		ids = append(ids, a.RuleID)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+func TestAlertRule_Validate(t *testing.T) {
+	a := assert.New(t)
+
+	r := currency.AlertRule{Kind: "Threshold", CurrencyID: "usd", Threshold: decimal.RequireFromString("1.10")}
+	a.NoError(r.Validate())
+	a.Equal(currency.AlertThreshold, r.Kind)
+	a.Equal("USD", r.CurrencyID)
+	a.Equal("EUR", r.Base)
+	a.Equal(currency.DirectionCross, r.Direction)
+	a.Equal("EUR/USD crosses 1.1", r.Name)
+
+	r = currency.AlertRule{Kind: currency.AlertStaleness, CurrencyID: "USD"}
+	a.NoError(r.Validate())
+	a.Equal(1, r.Days)
+
+	for _, r := range []currency.AlertRule{
+		{Kind: "sideways", CurrencyID: "USD"},
+		{Kind: currency.AlertThreshold, CurrencyID: "XXX", Threshold: decimal.New(1, 0)},
+		{Kind: currency.AlertThreshold, CurrencyID: "USD"},
+		{Kind: currency.AlertThreshold, CurrencyID: "USD", Threshold: decimal.New(1, 0), Direction: "up"},
+		{Kind: currency.AlertMovement, CurrencyID: "USD"},
+		{Kind: currency.AlertMovement, CurrencyID: "USD", Base: "USD", Threshold: decimal.New(1, 0)},
+		{Kind: currency.AlertStaleness, CurrencyID: "USD", Days: -1},
+		{Kind: currency.AlertStaleness, CurrencyID: "USD", CooldownMinutes: -1},
+		{Kind: currency.AlertStaleness, CurrencyID: "USD", Name: "stale\r\nBcc: victim@example.com"},
+	} {
+		a.Equal(currency.ErrInvalidAlertRule, errors.Cause(r.Validate()), r)
+	}
+}
+
+func TestManager_Alerts(t *testing.T) {
+	a := assert.New(t)
+
+	today := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(today, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+			newSnapshot(today.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934, "JPY": 117.67}),
+		},
+	}
+
+	n := &recordingNotifier{}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src), currency.WithNotifiers(n))
+	a.NoError(err)
+
+	ctx := context.Background()
+
+	for _, r := range []currency.AlertRule{
+		// #1 EUR/USD crosses 1.09 (below)
+		{Kind: currency.AlertThreshold, CurrencyID: "USD", Threshold: decimal.RequireFromString("1.09"), CooldownMinutes: 60},
+		// #2 doesn't fire, because it's crossed below
+		{Kind: currency.AlertThreshold, CurrencyID: "USD", Threshold: decimal.RequireFromString("1.09"), Direction: currency.DirectionAbove},
+		// #3 USD/JPY crosses above 108 (107.6184 -> 109.388)
+		{Kind: currency.AlertThreshold, CurrencyID: "JPY", Base: "USD", Threshold: decimal.New(108, 0), Direction: currency.DirectionAbove},
+		// #4 JPY moves more than 0.4% day-over-day (+0.4079%)
+		{Kind: currency.AlertMovement, CurrencyID: "JPY", Threshold: decimal.RequireFromString("0.4")},
+		// #5 doesn't fire, USD has moved by 1.2%
+		{Kind: currency.AlertMovement, CurrencyID: "USD", Threshold: decimal.New(2, 0)},
+		// #6 the values are from 2020, thus stale
+		{Kind: currency.AlertStaleness, CurrencyID: "USD", Days: 2},
+	} {
+		_, err = m.CreateAlertRule(ctx, r)
+		a.NoError(err)
+	}
+
+	// alerts are evaluated after the import
+	_, err = m.Import(ctx)
+	a.NoError(err)
+	a.Equal([]int64{1, 3, 4, 6}, ruleIDs(n.as))
+
+	for _, alert := range n.as {
+		a.NotEmpty(alert.Message)
+		a.Equal(today, alert.PubDate.Time)
+	}
+
+	rs, err := m.AlertRules(ctx)
+	a.NoError(err)
+	a.Len(rs, 6)
+	a.True(rs[0].LastFiredAt.Valid)
+	a.False(rs[1].LastFiredAt.Valid)
+
+	// the same alerts aren't delivered twice
+	as, err := m.EvaluateAlerts(ctx)
+	a.NoError(err)
+	a.Empty(as)
+
+	// USD crosses back above 1.09, but the rule #1 is cooling down,
+	// while the rule #2 fires, and #6 is still stale on the new date
+	n.as = nil
+	src.ss = []currency.Snapshot{newSnapshot(today.AddDate(0, 0, 1), map[string]float64{"USD": 1.0950, "JPY": 118.15})}
+
+	_, err = m.Import(ctx)
+	a.NoError(err)
+	a.Equal([]int64{2, 6}, ruleIDs(n.as))
+
+	// failed deliveries are retried on the next evaluation
+	a.NoError(m.DeleteAlertRule(ctx, 6))
+	a.Equal(currency.ErrAlertRuleNotFound, m.DeleteAlertRule(ctx, 6))
+
+	_, err = m.CreateAlertRule(ctx, currency.AlertRule{Kind: currency.AlertStaleness, CurrencyID: "JPY"})
+	a.NoError(err)
+
+	n.as, n.err = nil, errors.New("unavailable")
+
+	as, err = m.EvaluateAlerts(ctx)
+	a.NoError(err)
+	a.Empty(as)
+
+	n.err = nil
+
+	as, err = m.EvaluateAlerts(ctx)
+	a.NoError(err)
+	a.Equal([]int64{7}, ruleIDs(as))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	a := assert.New(t)
+
+	var received currency.Alert
+	status := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal(http.MethodPost, r.Method)
+		a.Equal("application/json", r.Header.Get("Content-Type"))
+		a.NoError(json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	n, err := currency.NewWebhookNotifier(server.URL)
+	a.NoError(err)
+
+	alert := currency.Alert{RuleID: 1, RuleName: "EUR/USD", Message: "crossed", Value: decimal.RequireFromString("1.0801")}
+
+	a.NoError(n.Notify(context.Background(), alert))
+	a.Equal(int64(1), received.RuleID)
+	a.Equal("1.0801", received.Value.String())
+
+	status = http.StatusInternalServerError
+	a.Error(n.Notify(context.Background(), alert))
+
+	_, err = currency.NewWebhookNotifier("ftp://example.com")
+	a.Error(err)
+
+	_, err = currency.NewSMTPNotifier("localhost:25", "", "", "", []string{"ops@example.com"})
+	a.Error(err)
+}
+
+// serveSMTP accepts a single SMTP session and passes the received message
+// to a given channel
+func serveSMTP(t *testing.T, l net.Listener, messages chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+		case "DATA":
+			reply("354 go ahead")
+
+			var msg strings.Builder
+			for {
+				line, err = r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+
+				msg.WriteString(line)
+			}
+
+			messages <- msg.String()
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	a := assert.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.NoError(err)
+	defer l.Close()
+
+	messages := make(chan string, 1)
+	go serveSMTP(t, l, messages)
+
+	n, err := currency.NewSMTPNotifier(l.Addr().String(), "", "", "alerts@example.com", []string{"ops@example.com"})
+	a.NoError(err)
+
+	// a name which would inject a header unless the subject is encoded
+	alert := currency.Alert{
+		RuleID:   1,
+		RuleName: "EUR/USD ↑\r\nBcc: victim@example.com",
+		Message:  "crossed",
+		FiredAt:  dbr.NewNullTime(time.Now()),
+	}
+
+	a.NoError(n.Notify(context.Background(), alert))
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-messages))
+	a.NoError(err)
+	a.Empty(msg.Header.Get("Bcc"))
+	a.Equal([]string{"ops@example.com"}, msg.Header["To"])
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	a.NoError(err)
+	a.Equal("[tetest] "+alert.RuleName, subject)
+
+	_, err = currency.NewSMTPNotifier("localhost:25", "", "", "alerts@example.com", []string{"ops@example.com\r\nBcc: victim@example.com"})
+	a.Error(err)
+}
@@ -0,0 +1,131 @@
+package currency
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultMaxStaleness is the default number of days an effective value
+// may lag behind the requested date, which is enough to cover weekends
+// and TARGET holidays (i.e. from Maundy Thursday to Easter Monday)
+const DefaultMaxStaleness = 7
+
+// AsOfRate represents the value of a currency effective on a given date,
+// i.e. the most recent value published on or before that date
+// NOTE: the pub_date of the embedded currency is the effective date
+type AsOfRate struct {
+	Currency
+
+	// AsOf is the requested date
+	AsOf dbr.NullTime `json:"as_of"`
+
+	// Staleness is the number of days between the effective and the requested date
+	Staleness int `json:"staleness"`
+
+	// MaxStaleness is the staleness window the value was looked up within
+	MaxStaleness int `json:"max_staleness"`
+}
+
+// AsOf returns the most recent value of a currency published on or before
+// a given date, relative to an optional base currency, as long as it's not
+// older than the maximum staleness window
+func (m *Manager) AsOf(ctx context.Context, id string, base string, date time.Time) (r AsOfRate, err error) {
+	if m == nil {
+		return r, ErrNilManager
+	}
+
+	if id = strings.ToUpper(strings.TrimSpace(id)); id == "" {
+		return r, ErrEmptyCurrencyID
+	}
+
+	base = normalizeBase(base)
+
+	c, err := m.rateAt(ctx, id, date)
+	if err != nil {
+		return r, err
+	}
+
+	if base != BaseCurrency {
+		b, err := m.rateAt(ctx, base, date)
+		if err != nil {
+			if errors.Cause(err) == ErrCurrencyNotFound {
+				return r, errors.Wrapf(ErrCurrencyNotFound, "base currency: %s", base)
+			}
+
+			return r, err
+		}
+
+		c.Value = m.conversion.Round(c.Value.DivRound(b.Value, divisionPlaces))
+
+		// the effective date is the oldest one of both values
+		if !c.PubDate.Valid || (b.PubDate.Valid && b.PubDate.Time.Before(c.PubDate.Time)) {
+			c.PubDate = b.PubDate
+		}
+	}
+
+	// the base currency itself is effective on any date
+	if !c.PubDate.Valid {
+		c.PubDate = dbr.NewNullTime(date)
+	}
+
+	r = AsOfRate{
+		Currency:     c,
+		AsOf:         dbr.NewNullTime(date),
+		Staleness:    daysBetween(c.PubDate.Time, date),
+		MaxStaleness: m.staleness,
+	}
+
+	if r.Staleness > r.MaxStaleness {
+		return r, errors.Wrapf(
+			ErrStaleRate,
+			"latest %s rate on or before %s is published on %s",
+			id,
+			date.Format(dateLayout),
+			c.PubDate.Time.Format(dateLayout),
+		)
+	}
+
+	return r, nil
+}
+
+// rateAt returns the most recent value of a given currency published
+// on or before a given date, the base currency is always 1.0
+// NOTE: zero date stands for the latest known value
+func (m *Manager) rateAt(ctx context.Context, id string, date time.Time) (c Currency, err error) {
+	if id == BaseCurrency {
+		return Currency{ID: id, Value: decimal.New(1, 0)}, nil
+	}
+
+	store, err := m.Store()
+	if err != nil {
+		return c, errors.Wrap(err, "failed to obtain currency store")
+	}
+
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	if c, err = store.AsOf(ctx, id, date); err != nil {
+		if err == ErrCurrencyNotFound {
+			return c, errors.Wrapf(err, "no %s rate on or before %s", id, date.Format(dateLayout))
+		}
+
+		return c, errors.Wrapf(err, "failed to fetch %s rate as of %s", id, date.Format(dateLayout))
+	}
+
+	return c, nil
+}
+
+// daysBetween returns the number of calendar days from one date to another
+// NOTE: comparing dates only, thus ignoring time and location
+func daysBetween(from, to time.Time) int {
+	f := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	t := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(t.Sub(f).Hours() / 24)
+}
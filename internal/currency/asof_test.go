@@ -0,0 +1,78 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_AsOf(t *testing.T) {
+	a := assert.New(t)
+
+	// thursday and the preceding wednesday
+	thursday := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(thursday, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+			newSnapshot(thursday.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934}),
+		},
+	}
+
+	m, err := currency.NewManager(
+		currency.NewMemoryStore(),
+		currency.WithFeedSource(src),
+		currency.WithMaxStaleness(3),
+	)
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	// exact date
+	r, err := m.AsOf(context.Background(), "usd", "", thursday.AddDate(0, 0, -1))
+	a.NoError(err)
+	a.Equal("USD", r.ID)
+	a.Equal("1.0934", r.Value.String())
+	a.Equal(0, r.Staleness)
+	a.Equal(3, r.MaxStaleness)
+
+	// sunday falls back to thursday
+	sunday := thursday.AddDate(0, 0, 3)
+
+	r, err = m.AsOf(context.Background(), "USD", "", sunday)
+	a.NoError(err)
+	a.Equal("1.0801", r.Value.String())
+	a.Equal(thursday, r.PubDate.Time)
+	a.Equal(sunday, r.AsOf.Time)
+	a.Equal(3, r.Staleness)
+
+	// rebased, the effective date is the oldest one of both values
+	r, err = m.AsOf(context.Background(), "USD", "JPY", sunday)
+	a.NoError(err)
+	a.Equal("0.009142", r.Value.String())
+	a.Equal(thursday, r.PubDate.Time)
+
+	// base currency itself
+	r, err = m.AsOf(context.Background(), "EUR", "USD", sunday)
+	a.NoError(err)
+	a.Equal("0.925840", r.Value.StringFixed(6))
+
+	// beyond the staleness window
+	_, err = m.AsOf(context.Background(), "USD", "", sunday.AddDate(0, 0, 1))
+	a.Error(err)
+	a.Equal(currency.ErrStaleRate, errors.Cause(err))
+
+	// no JPY value on or before wednesday
+	_, err = m.AsOf(context.Background(), "USD", "JPY", thursday.AddDate(0, 0, -1))
+	a.Error(err)
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+
+	// invalid staleness window
+	_, err = currency.NewManager(currency.NewMemoryStore(), currency.WithMaxStaleness(-1))
+	a.Error(err)
+}
@@ -0,0 +1,142 @@
+package currency
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// DefaultBackfillChunkSize is the default number of rows stored at once
+const DefaultBackfillChunkSize = 1000
+
+// BackfillOptions configures historical backfill
+type BackfillOptions struct {
+	// From and To limit the range of publication dates (inclusive),
+	// zero value means the range is not limited from that side
+	From time.Time
+	To   time.Time
+
+	// ChunkSize is the number of rows passed to the store at once
+	ChunkSize int
+
+	// Progress is called after each stored chunk (optional)
+	Progress func(p BackfillProgress)
+}
+
+// BackfillProgress represents the state of a running backfill
+type BackfillProgress struct {
+	Dates    int
+	Total    int
+	Stored   int
+	Rejected int
+}
+
+// Backfill loads the whole history from a given source and upserts
+// it through the store in chunks, within a given range of dates
+func (m *Manager) Backfill(ctx context.Context, src FeedSource, opts BackfillOptions) (p BackfillProgress, err error) {
+	if src == nil {
+		return p, ErrNilFeedSource
+	}
+
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultBackfillChunkSize
+	}
+
+	if !opts.From.IsZero() && !opts.To.IsZero() && opts.From.After(opts.To) {
+		return p, errors.Errorf("invalid backfill range: %s > %s", opts.From.Format(dateLayout), opts.To.Format(dateLayout))
+	}
+
+	m.Logger().Info("loading currency history", zap.String("source", src.Name()))
+
+	ss, err := src.Fetch(ctx)
+	if err != nil {
+		return p, errors.Wrapf(err, "failed to fetch history [%s]", src.Name())
+	}
+
+	// NOTE: comparing formatted dates to ignore time and location
+	from, to := opts.From.Format(dateLayout), opts.To.Format(dateLayout)
+
+	// filtering snapshots by the requested range
+	filtered := make([]Snapshot, 0, len(ss))
+	rejected := make([]Rejection, 0)
+	for _, s := range ss {
+		for _, rj := range s.Rejected {
+			rj.Source = src.Name()
+			rejected = append(rejected, rj)
+		}
+
+		// NOTE: snapshots of unknown dates only contain rejections
+		d := s.PubDate.Format(dateLayout)
+		if s.PubDate.IsZero() || (!opts.From.IsZero() && d < from) || (!opts.To.IsZero() && d > to) {
+			continue
+		}
+
+		// NOTE: validating each row first, because a single invalid one
+		// (i.e. a retired currency) would fail the whole chunk otherwise
+		rejected = append(rejected, m.validateSnapshot(&s, src.Name())...)
+
+		filtered = append(filtered, s)
+		p.Total += len(s.Currencies)
+	}
+
+	// NOTE: rejected payloads don't stop the backfill
+	if p.Rejected = len(rejected); p.Rejected > 0 {
+		if err = m.quarantine(ctx, rejected); err != nil {
+			return p, err
+		}
+	}
+
+	// storing the oldest dates first
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].PubDate.Before(filtered[j].PubDate)
+	})
+
+	p.Dates = len(filtered)
+
+	// flattening and storing in chunks
+	chunk := make([]Currency, 0, opts.ChunkSize)
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if _, _, err := m.BulkCreate(ctx, chunk); err != nil {
+			return err
+		}
+
+		p.Stored += len(chunk)
+		chunk = chunk[:0]
+
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+
+		return nil
+	}
+
+	for _, s := range filtered {
+		for _, c := range s.Currencies {
+			chunk = append(chunk, c)
+
+			if len(chunk) == opts.ChunkSize {
+				if err = flush(); err != nil {
+					return p, errors.Wrapf(err, "failed to store history chunk (%d/%d stored)", p.Stored, p.Total)
+				}
+			}
+		}
+	}
+
+	if err = flush(); err != nil {
+		return p, errors.Wrapf(err, "failed to store history chunk (%d/%d stored)", p.Stored, p.Total)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,152 @@
+package currency_test
+
+import (
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestECBHistorySource_Formats(t *testing.T) {
+	a := assert.New(t)
+
+	csvPayload, err := ioutil.ReadFile("testdata/eurofxref-hist.csv")
+	a.NoError(err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// zipping the csv fixture on the fly
+		zw := zip.NewWriter(w)
+		f, err := zw.Create("eurofxref-hist.csv")
+		a.NoError(err)
+		_, err = f.Write(csvPayload)
+		a.NoError(err)
+		a.NoError(zw.Close())
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		location string
+		dates    int
+	}{
+		{"testdata/eurofxref-hist.xml", 4},
+		{"testdata/eurofxref-hist.csv", 5},
+		{ts.URL + "/eurofxref-hist.zip", 5},
+	}
+
+	for _, tt := range tests {
+		src, err := currency.NewECBHistorySource(tt.location)
+		a.NoError(err)
+
+		ss, err := src.Fetch(context.Background())
+		a.NoError(err, tt.location)
+		a.Len(ss, tt.dates, tt.location)
+	}
+
+	// N/A values must be skipped
+	src, err := currency.NewECBHistorySource("testdata/eurofxref-hist.csv")
+	a.NoError(err)
+
+	ss, err := src.Fetch(context.Background())
+	a.NoError(err)
+	a.Len(ss[0].Currencies, 3)
+	a.Len(ss[4].Currencies, 4)
+}
+
+func TestManager_Backfill(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore())
+	a.NoError(err)
+
+	src, err := currency.NewECBHistorySource("testdata/eurofxref-hist.csv")
+	a.NoError(err)
+
+	progress := make([]currency.BackfillProgress, 0)
+
+	p, err := m.Backfill(context.Background(), src, currency.BackfillOptions{
+		From:      time.Date(2020, 3, 16, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2020, 3, 18, 0, 0, 0, 0, time.UTC),
+		ChunkSize: 4,
+		Progress: func(p currency.BackfillProgress) {
+			progress = append(progress, p)
+		},
+	})
+
+	a.NoError(err)
+	a.Equal(3, p.Dates)
+	a.Equal(9, p.Total)
+	a.Equal(9, p.Stored)
+	a.Len(progress, 3)
+	a.Equal(4, progress[0].Stored)
+
+	cs, err := m.GetAllByID(context.Background(), "USD", "")
+	a.NoError(err)
+	a.Len(cs, 3)
+
+	// invalid range
+	_, err = m.Backfill(context.Background(), src, currency.BackfillOptions{
+		From: time.Date(2020, 3, 18, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2020, 3, 16, 0, 0, 0, 0, time.UTC),
+	})
+	a.Error(err)
+
+	// cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = m.Backfill(ctx, src, currency.BackfillOptions{})
+	a.Error(err)
+}
+
+func TestManager_Backfill_Rejected(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	// a retired currency in the middle of the history
+	f, err := ioutil.TempFile("", "eurofxref-hist-*.csv")
+	a.NoError(err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("Date,USD,CYP,GBP,\n" +
+		"2020-03-18,1.0934,N/A,0.91830,\n" +
+		"2020-03-17,1.0982,0.585274,0.90670,\n" +
+		"2007-12-31,1.4721,0.585274,0.73335,\n")
+	a.NoError(err)
+	a.NoError(f.Close())
+
+	store := currency.NewMemoryStore()
+
+	m, err := currency.NewManager(store)
+	a.NoError(err)
+
+	src, err := currency.NewECBHistorySource(f.Name())
+	a.NoError(err)
+
+	p, err := m.Backfill(ctx, src, currency.BackfillOptions{ChunkSize: 2})
+	a.NoError(err)
+	a.Equal(3, p.Dates)
+	a.Equal(7, p.Total)
+	a.Equal(7, p.Stored)
+	a.Equal(1, p.Rejected)
+
+	rs, err := store.(currency.QuarantineStore).Rejections(ctx, 10)
+	a.NoError(err)
+	a.Len(rs, 1)
+	a.Equal("CYP 0.5853", rs[0].Payload)
+	a.Equal(src.Name(), rs[0].Source)
+
+	cs, err := m.GetAllByID(ctx, "CYP", "")
+	a.NoError(err)
+	a.Len(cs, 1)
+
+	cs, err = m.GetAllByID(ctx, "GBP", "")
+	a.NoError(err)
+	a.Len(cs, 3)
+}
@@ -0,0 +1,82 @@
+package currency
+
+import (
+	"time"
+)
+
+// IsTARGETHoliday tells whether TARGET2 is closed on a given weekday,
+// i.e. the ECB doesn't publish reference rates on that date
+// NOTE: since 2002 the closing days are New Year's Day, Good Friday,
+// Easter Monday, Labour Day, Christmas Day and the following day;
+// in 2000-2001 the 31st of December was closed as well, and in 1999
+// only New Year's Day, Christmas Day and the 31st of December
+func IsTARGETHoliday(date time.Time) bool {
+	y, m, d := date.Date()
+
+	switch {
+	case m == time.January && d == 1:
+		return true
+	case m == time.December && d == 25:
+		return true
+	case m == time.December && d == 31 && y <= 2001:
+		return true
+	case y < 2000:
+		return false
+	case m == time.May && d == 1:
+		return true
+	case m == time.December && d == 26:
+		return true
+	}
+
+	// Good Friday and Easter Monday
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	easter := easterSunday(y)
+
+	return day.Equal(easter.AddDate(0, 0, -2)) || day.Equal(easter.AddDate(0, 0, 1))
+}
+
+// IsBusinessDay tells whether a given date is a TARGET2 business day,
+// on which the ECB publishes reference rates
+func IsBusinessDay(date time.Time) bool {
+	switch date.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+
+	return !IsTARGETHoliday(date)
+}
+
+// BusinessDays returns the TARGET2 business days within a given range
+// of dates (inclusive), as UTC dates ignoring time and location
+func BusinessDays(from, to time.Time) []time.Time {
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	days := make([]time.Time, 0)
+
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if IsBusinessDay(day) {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
+
+// easterSunday returns the date of the Western Easter Sunday of a given
+// year, computed by the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
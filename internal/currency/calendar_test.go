@@ -0,0 +1,56 @@
+package currency_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTARGETCalendar(t *testing.T) {
+	a := assert.New(t)
+
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	// closing days
+	for _, d := range []time.Time{
+		date(2020, 1, 1),
+		date(2020, 4, 10), // Good Friday
+		date(2020, 4, 13), // Easter Monday
+		date(2020, 5, 1),
+		date(2020, 12, 25),
+		date(2019, 4, 19), // Good Friday
+		date(2019, 4, 22), // Easter Monday
+		date(2019, 12, 26),
+		date(2001, 12, 31),
+		date(1999, 12, 31),
+	} {
+		a.True(currency.IsTARGETHoliday(d), d.Format("2006-01-02"))
+		a.False(currency.IsBusinessDay(d), d.Format("2006-01-02"))
+	}
+
+	// business days
+	for _, d := range []time.Time{
+		date(2020, 3, 19),
+		date(2020, 4, 9),   // Maundy Thursday
+		date(2020, 12, 24), // Christmas Eve
+		date(2020, 12, 31),
+		date(1999, 4, 2), // Good Friday, before 2000
+		date(1999, 5, 3),
+	} {
+		a.True(currency.IsBusinessDay(d), d.Format("2006-01-02"))
+	}
+
+	// weekends
+	a.False(currency.IsBusinessDay(date(2020, 3, 21)))
+	a.False(currency.IsBusinessDay(date(2020, 3, 22)))
+
+	// 262 weekdays of 2020, 5 of which are closing days
+	a.Len(currency.BusinessDays(date(2020, 1, 1), date(2020, 12, 31)), 257)
+
+	days := currency.BusinessDays(date(2020, 4, 9), time.Date(2020, 4, 14, 15, 0, 0, 0, time.Local))
+	a.Equal([]time.Time{date(2020, 4, 9), date(2020, 4, 14)}, days)
+}
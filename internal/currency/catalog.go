@@ -0,0 +1,299 @@
+package currency
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+)
+
+// CurrencyInfo represents ISO 4217 metadata of a currency
+type CurrencyInfo struct {
+	Code       string `json:"code"`
+	Numeric    string `json:"numeric"`
+	Name       string `json:"name"`
+	MinorUnits int    `json:"minor_units"`
+
+	// ActiveFrom and ActiveTo limit the period the currency has been
+	// in use, zero date means that the limit is unknown or irrelevant
+	ActiveFrom dbr.NullTime `json:"active_from"`
+	ActiveTo   dbr.NullTime `json:"active_to"`
+}
+
+// Historic tells whether the currency has been withdrawn
+func (ci CurrencyInfo) Historic() bool {
+	return ci.ActiveTo.Valid
+}
+
+// ActiveOn tells whether the currency has been in use on a given date
+// NOTE: comparing formatted dates to ignore time and location
+func (ci CurrencyInfo) ActiveOn(date time.Time) bool {
+	day := date.Format(dateLayout)
+
+	if ci.ActiveFrom.Valid && day < ci.ActiveFrom.Time.Format(dateLayout) {
+		return false
+	}
+
+	if ci.ActiveTo.Valid && day > ci.ActiveTo.Time.Format(dateLayout) {
+		return false
+	}
+
+	return true
+}
+
+// LookupCurrency returns ISO 4217 metadata of a given currency code
+func LookupCurrency(code string) (ci CurrencyInfo, ok bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	i := sort.Search(len(catalog), func(i int) bool {
+		return catalog[i].Code >= code
+	})
+
+	if i < len(catalog) && catalog[i].Code == code {
+		return catalog[i], true
+	}
+
+	return ci, false
+}
+
+// Catalog returns ISO 4217 metadata of all known currencies, ordered by code
+func Catalog() []CurrencyInfo {
+	cis := make([]CurrencyInfo, len(catalog))
+	copy(cis, catalog)
+
+	return cis
+}
+
+// Observation represents the range of publication dates of a stored currency
+type Observation struct {
+	ID           string       `db:"id" json:"-"`
+	FirstPubDate dbr.NullTime `db:"first_pub_date" json:"first_pub_date"`
+	LastPubDate  dbr.NullTime `db:"last_pub_date" json:"last_pub_date"`
+	Count        int          `db:"observations" json:"observations"`
+}
+
+// ObservationStore is implemented by stores which are able to
+// summarize stored publication dates of each currency
+type ObservationStore interface {
+	Observations(ctx context.Context) (obs []Observation, err error)
+}
+
+// KnownCurrency represents ISO 4217 metadata of a currency,
+// along with the range of its stored publication dates
+type KnownCurrency struct {
+	CurrencyInfo
+	Observation
+}
+
+// Currencies returns metadata of the stored currencies (ordered by code),
+// or of every currency in the catalog if requested
+func (m *Manager) Currencies(ctx context.Context, all bool) (kcs []KnownCurrency, err error) {
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	ostore, ok := m.store.(ObservationStore)
+	if !ok {
+		return nil, errors.Wrap(ErrUnsupportedByStore, "currency observations")
+	}
+
+	obs, err := ostore.Observations(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch currency observations from the store")
+	}
+
+	observed := make(map[string]Observation, len(obs))
+	for _, o := range obs {
+		observed[o.ID] = o
+	}
+
+	kcs = make([]KnownCurrency, 0, len(obs))
+
+	for _, ci := range catalog {
+		o, ok := observed[ci.Code]
+		if !ok && !all {
+			continue
+		}
+
+		kcs = append(kcs, KnownCurrency{CurrencyInfo: ci, Observation: o})
+	}
+
+	return kcs, nil
+}
+
+// date is a shorthand for a catalog date
+func date(year int, month time.Month, day int) dbr.NullTime {
+	return dbr.NewNullTime(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// catalog contains ISO 4217 currencies (ordered by code), including
+// historic ones which have been published by the ECB
+var catalog = []CurrencyInfo{
+	{Code: "AED", Numeric: "784", Name: "UAE Dirham", MinorUnits: 2},
+	{Code: "AFN", Numeric: "971", Name: "Afghani", MinorUnits: 2},
+	{Code: "ALL", Numeric: "008", Name: "Lek", MinorUnits: 2},
+	{Code: "AMD", Numeric: "051", Name: "Armenian Dram", MinorUnits: 2},
+	{Code: "ANG", Numeric: "532", Name: "Netherlands Antillean Guilder", MinorUnits: 2},
+	{Code: "AOA", Numeric: "973", Name: "Kwanza", MinorUnits: 2},
+	{Code: "ARS", Numeric: "032", Name: "Argentine Peso", MinorUnits: 2},
+	{Code: "AUD", Numeric: "036", Name: "Australian Dollar", MinorUnits: 2},
+	{Code: "AWG", Numeric: "533", Name: "Aruban Florin", MinorUnits: 2},
+	{Code: "AZN", Numeric: "944", Name: "Azerbaijan Manat", MinorUnits: 2, ActiveFrom: date(2006, 1, 1)},
+	{Code: "BAM", Numeric: "977", Name: "Convertible Mark", MinorUnits: 2},
+	{Code: "BBD", Numeric: "052", Name: "Barbados Dollar", MinorUnits: 2},
+	{Code: "BDT", Numeric: "050", Name: "Taka", MinorUnits: 2},
+	{Code: "BGN", Numeric: "975", Name: "Bulgarian Lev", MinorUnits: 2, ActiveFrom: date(1999, 7, 5), ActiveTo: date(2025, 12, 31)},
+	{Code: "BHD", Numeric: "048", Name: "Bahraini Dinar", MinorUnits: 3},
+	{Code: "BIF", Numeric: "108", Name: "Burundi Franc", MinorUnits: 0},
+	{Code: "BMD", Numeric: "060", Name: "Bermudian Dollar", MinorUnits: 2},
+	{Code: "BND", Numeric: "096", Name: "Brunei Dollar", MinorUnits: 2},
+	{Code: "BOB", Numeric: "068", Name: "Boliviano", MinorUnits: 2},
+	{Code: "BRL", Numeric: "986", Name: "Brazilian Real", MinorUnits: 2},
+	{Code: "BSD", Numeric: "044", Name: "Bahamian Dollar", MinorUnits: 2},
+	{Code: "BTN", Numeric: "064", Name: "Ngultrum", MinorUnits: 2},
+	{Code: "BWP", Numeric: "072", Name: "Pula", MinorUnits: 2},
+	{Code: "BYN", Numeric: "933", Name: "Belarusian Ruble", MinorUnits: 2, ActiveFrom: date(2016, 7, 1)},
+	{Code: "BZD", Numeric: "084", Name: "Belize Dollar", MinorUnits: 2},
+	{Code: "CAD", Numeric: "124", Name: "Canadian Dollar", MinorUnits: 2},
+	{Code: "CDF", Numeric: "976", Name: "Congolese Franc", MinorUnits: 2},
+	{Code: "CHF", Numeric: "756", Name: "Swiss Franc", MinorUnits: 2},
+	{Code: "CLP", Numeric: "152", Name: "Chilean Peso", MinorUnits: 0},
+	{Code: "CNY", Numeric: "156", Name: "Yuan Renminbi", MinorUnits: 2},
+	{Code: "COP", Numeric: "170", Name: "Colombian Peso", MinorUnits: 2},
+	{Code: "CRC", Numeric: "188", Name: "Costa Rican Colon", MinorUnits: 2},
+	{Code: "CUP", Numeric: "192", Name: "Cuban Peso", MinorUnits: 2},
+	{Code: "CVE", Numeric: "132", Name: "Cabo Verde Escudo", MinorUnits: 2},
+	{Code: "CYP", Numeric: "196", Name: "Cyprus Pound", MinorUnits: 2, ActiveTo: date(2007, 12, 31)},
+	{Code: "CZK", Numeric: "203", Name: "Czech Koruna", MinorUnits: 2},
+	{Code: "DJF", Numeric: "262", Name: "Djibouti Franc", MinorUnits: 0},
+	{Code: "DKK", Numeric: "208", Name: "Danish Krone", MinorUnits: 2},
+	{Code: "DOP", Numeric: "214", Name: "Dominican Peso", MinorUnits: 2},
+	{Code: "DZD", Numeric: "012", Name: "Algerian Dinar", MinorUnits: 2},
+	{Code: "EEK", Numeric: "233", Name: "Kroon", MinorUnits: 2, ActiveTo: date(2010, 12, 31)},
+	{Code: "EGP", Numeric: "818", Name: "Egyptian Pound", MinorUnits: 2},
+	{Code: "ERN", Numeric: "232", Name: "Nakfa", MinorUnits: 2},
+	{Code: "ETB", Numeric: "230", Name: "Ethiopian Birr", MinorUnits: 2},
+	{Code: "EUR", Numeric: "978", Name: "Euro", MinorUnits: 2, ActiveFrom: date(1999, 1, 1)},
+	{Code: "FJD", Numeric: "242", Name: "Fiji Dollar", MinorUnits: 2},
+	{Code: "FKP", Numeric: "238", Name: "Falkland Islands Pound", MinorUnits: 2},
+	{Code: "GBP", Numeric: "826", Name: "Pound Sterling", MinorUnits: 2},
+	{Code: "GEL", Numeric: "981", Name: "Lari", MinorUnits: 2},
+	{Code: "GHS", Numeric: "936", Name: "Ghana Cedi", MinorUnits: 2, ActiveFrom: date(2007, 7, 1)},
+	{Code: "GIP", Numeric: "292", Name: "Gibraltar Pound", MinorUnits: 2},
+	{Code: "GMD", Numeric: "270", Name: "Dalasi", MinorUnits: 2},
+	{Code: "GNF", Numeric: "324", Name: "Guinean Franc", MinorUnits: 0},
+	{Code: "GTQ", Numeric: "320", Name: "Quetzal", MinorUnits: 2},
+	{Code: "GYD", Numeric: "328", Name: "Guyana Dollar", MinorUnits: 2},
+	{Code: "HKD", Numeric: "344", Name: "Hong Kong Dollar", MinorUnits: 2},
+	{Code: "HNL", Numeric: "340", Name: "Lempira", MinorUnits: 2},
+	{Code: "HRK", Numeric: "191", Name: "Kuna", MinorUnits: 2, ActiveTo: date(2022, 12, 31)},
+	{Code: "HTG", Numeric: "332", Name: "Gourde", MinorUnits: 2},
+	{Code: "HUF", Numeric: "348", Name: "Forint", MinorUnits: 2},
+	{Code: "IDR", Numeric: "360", Name: "Rupiah", MinorUnits: 2},
+	{Code: "ILS", Numeric: "376", Name: "New Israeli Sheqel", MinorUnits: 2},
+	{Code: "INR", Numeric: "356", Name: "Indian Rupee", MinorUnits: 2},
+	{Code: "IQD", Numeric: "368", Name: "Iraqi Dinar", MinorUnits: 3},
+	{Code: "IRR", Numeric: "364", Name: "Iranian Rial", MinorUnits: 2},
+	{Code: "ISK", Numeric: "352", Name: "Iceland Krona", MinorUnits: 0},
+	{Code: "JMD", Numeric: "388", Name: "Jamaican Dollar", MinorUnits: 2},
+	{Code: "JOD", Numeric: "400", Name: "Jordanian Dinar", MinorUnits: 3},
+	{Code: "JPY", Numeric: "392", Name: "Yen", MinorUnits: 0},
+	{Code: "KES", Numeric: "404", Name: "Kenyan Shilling", MinorUnits: 2},
+	{Code: "KGS", Numeric: "417", Name: "Som", MinorUnits: 2},
+	{Code: "KHR", Numeric: "116", Name: "Riel", MinorUnits: 2},
+	{Code: "KMF", Numeric: "174", Name: "Comorian Franc", MinorUnits: 0},
+	{Code: "KPW", Numeric: "408", Name: "North Korean Won", MinorUnits: 2},
+	{Code: "KRW", Numeric: "410", Name: "Won", MinorUnits: 0},
+	{Code: "KWD", Numeric: "414", Name: "Kuwaiti Dinar", MinorUnits: 3},
+	{Code: "KYD", Numeric: "136", Name: "Cayman Islands Dollar", MinorUnits: 2},
+	{Code: "KZT", Numeric: "398", Name: "Tenge", MinorUnits: 2},
+	{Code: "LAK", Numeric: "418", Name: "Lao Kip", MinorUnits: 2},
+	{Code: "LBP", Numeric: "422", Name: "Lebanese Pound", MinorUnits: 2},
+	{Code: "LKR", Numeric: "144", Name: "Sri Lanka Rupee", MinorUnits: 2},
+	{Code: "LRD", Numeric: "430", Name: "Liberian Dollar", MinorUnits: 2},
+	{Code: "LSL", Numeric: "426", Name: "Loti", MinorUnits: 2},
+	{Code: "LTL", Numeric: "440", Name: "Lithuanian Litas", MinorUnits: 2, ActiveTo: date(2014, 12, 31)},
+	{Code: "LVL", Numeric: "428", Name: "Latvian Lats", MinorUnits: 2, ActiveTo: date(2013, 12, 31)},
+	{Code: "LYD", Numeric: "434", Name: "Libyan Dinar", MinorUnits: 3},
+	{Code: "MAD", Numeric: "504", Name: "Moroccan Dirham", MinorUnits: 2},
+	{Code: "MDL", Numeric: "498", Name: "Moldovan Leu", MinorUnits: 2},
+	{Code: "MGA", Numeric: "969", Name: "Malagasy Ariary", MinorUnits: 2},
+	{Code: "MKD", Numeric: "807", Name: "Denar", MinorUnits: 2},
+	{Code: "MMK", Numeric: "104", Name: "Kyat", MinorUnits: 2},
+	{Code: "MNT", Numeric: "496", Name: "Tugrik", MinorUnits: 2},
+	{Code: "MOP", Numeric: "446", Name: "Pataca", MinorUnits: 2},
+	{Code: "MRU", Numeric: "929", Name: "Ouguiya", MinorUnits: 2, ActiveFrom: date(2018, 1, 1)},
+	{Code: "MTL", Numeric: "470", Name: "Maltese Lira", MinorUnits: 2, ActiveTo: date(2007, 12, 31)},
+	{Code: "MUR", Numeric: "480", Name: "Mauritius Rupee", MinorUnits: 2},
+	{Code: "MVR", Numeric: "462", Name: "Rufiyaa", MinorUnits: 2},
+	{Code: "MWK", Numeric: "454", Name: "Malawi Kwacha", MinorUnits: 2},
+	{Code: "MXN", Numeric: "484", Name: "Mexican Peso", MinorUnits: 2},
+	{Code: "MYR", Numeric: "458", Name: "Malaysian Ringgit", MinorUnits: 2},
+	{Code: "MZN", Numeric: "943", Name: "Mozambique Metical", MinorUnits: 2, ActiveFrom: date(2006, 7, 1)},
+	{Code: "NAD", Numeric: "516", Name: "Namibia Dollar", MinorUnits: 2},
+	{Code: "NGN", Numeric: "566", Name: "Naira", MinorUnits: 2},
+	{Code: "NIO", Numeric: "558", Name: "Cordoba Oro", MinorUnits: 2},
+	{Code: "NOK", Numeric: "578", Name: "Norwegian Krone", MinorUnits: 2},
+	{Code: "NPR", Numeric: "524", Name: "Nepalese Rupee", MinorUnits: 2},
+	{Code: "NZD", Numeric: "554", Name: "New Zealand Dollar", MinorUnits: 2},
+	{Code: "OMR", Numeric: "512", Name: "Rial Omani", MinorUnits: 3},
+	{Code: "PAB", Numeric: "590", Name: "Balboa", MinorUnits: 2},
+	{Code: "PEN", Numeric: "604", Name: "Sol", MinorUnits: 2},
+	{Code: "PGK", Numeric: "598", Name: "Kina", MinorUnits: 2},
+	{Code: "PHP", Numeric: "608", Name: "Philippine Peso", MinorUnits: 2},
+	{Code: "PKR", Numeric: "586", Name: "Pakistan Rupee", MinorUnits: 2},
+	{Code: "PLN", Numeric: "985", Name: "Zloty", MinorUnits: 2},
+	{Code: "PYG", Numeric: "600", Name: "Guarani", MinorUnits: 0},
+	{Code: "QAR", Numeric: "634", Name: "Qatari Rial", MinorUnits: 2},
+	{Code: "ROL", Numeric: "642", Name: "Romanian Leu (old)", MinorUnits: 2, ActiveTo: date(2005, 6, 30)},
+	{Code: "RON", Numeric: "946", Name: "Romanian Leu", MinorUnits: 2, ActiveFrom: date(2005, 7, 1)},
+	{Code: "RSD", Numeric: "941", Name: "Serbian Dinar", MinorUnits: 2, ActiveFrom: date(2006, 10, 25)},
+	{Code: "RUB", Numeric: "643", Name: "Russian Ruble", MinorUnits: 2},
+	{Code: "RWF", Numeric: "646", Name: "Rwanda Franc", MinorUnits: 0},
+	{Code: "SAR", Numeric: "682", Name: "Saudi Riyal", MinorUnits: 2},
+	{Code: "SBD", Numeric: "090", Name: "Solomon Islands Dollar", MinorUnits: 2},
+	{Code: "SCR", Numeric: "690", Name: "Seychelles Rupee", MinorUnits: 2},
+	{Code: "SDG", Numeric: "938", Name: "Sudanese Pound", MinorUnits: 2, ActiveFrom: date(2007, 1, 10)},
+	{Code: "SEK", Numeric: "752", Name: "Swedish Krona", MinorUnits: 2},
+	{Code: "SGD", Numeric: "702", Name: "Singapore Dollar", MinorUnits: 2},
+	{Code: "SHP", Numeric: "654", Name: "Saint Helena Pound", MinorUnits: 2},
+	{Code: "SIT", Numeric: "705", Name: "Tolar", MinorUnits: 2, ActiveTo: date(2006, 12, 31)},
+	{Code: "SKK", Numeric: "703", Name: "Slovak Koruna", MinorUnits: 2, ActiveTo: date(2008, 12, 31)},
+	{Code: "SLE", Numeric: "925", Name: "Leone", MinorUnits: 2, ActiveFrom: date(2022, 7, 1)},
+	{Code: "SOS", Numeric: "706", Name: "Somali Shilling", MinorUnits: 2},
+	{Code: "SRD", Numeric: "968", Name: "Surinam Dollar", MinorUnits: 2, ActiveFrom: date(2004, 1, 1)},
+	{Code: "SSP", Numeric: "728", Name: "South Sudanese Pound", MinorUnits: 2, ActiveFrom: date(2011, 7, 18)},
+	{Code: "STN", Numeric: "930", Name: "Dobra", MinorUnits: 2, ActiveFrom: date(2018, 1, 1)},
+	{Code: "SVC", Numeric: "222", Name: "El Salvador Colon", MinorUnits: 2},
+	{Code: "SYP", Numeric: "760", Name: "Syrian Pound", MinorUnits: 2},
+	{Code: "SZL", Numeric: "748", Name: "Lilangeni", MinorUnits: 2},
+	{Code: "THB", Numeric: "764", Name: "Baht", MinorUnits: 2},
+	{Code: "TJS", Numeric: "972", Name: "Somoni", MinorUnits: 2},
+	{Code: "TMT", Numeric: "934", Name: "Turkmenistan New Manat", MinorUnits: 2, ActiveFrom: date(2009, 1, 1)},
+	{Code: "TND", Numeric: "788", Name: "Tunisian Dinar", MinorUnits: 3},
+	{Code: "TOP", Numeric: "776", Name: "Pa'anga", MinorUnits: 2},
+	{Code: "TRL", Numeric: "792", Name: "Turkish Lira (old)", MinorUnits: 0, ActiveTo: date(2004, 12, 31)},
+	{Code: "TRY", Numeric: "949", Name: "Turkish Lira", MinorUnits: 2, ActiveFrom: date(2005, 1, 1)},
+	{Code: "TTD", Numeric: "780", Name: "Trinidad and Tobago Dollar", MinorUnits: 2},
+	{Code: "TWD", Numeric: "901", Name: "New Taiwan Dollar", MinorUnits: 2},
+	{Code: "TZS", Numeric: "834", Name: "Tanzanian Shilling", MinorUnits: 2},
+	{Code: "UAH", Numeric: "980", Name: "Hryvnia", MinorUnits: 2},
+	{Code: "UGX", Numeric: "800", Name: "Uganda Shilling", MinorUnits: 0},
+	{Code: "USD", Numeric: "840", Name: "US Dollar", MinorUnits: 2},
+	{Code: "UYU", Numeric: "858", Name: "Peso Uruguayo", MinorUnits: 2},
+	{Code: "UZS", Numeric: "860", Name: "Uzbekistan Sum", MinorUnits: 2},
+	{Code: "VES", Numeric: "928", Name: "Bolivar Soberano", MinorUnits: 2, ActiveFrom: date(2018, 8, 20)},
+	{Code: "VND", Numeric: "704", Name: "Dong", MinorUnits: 0},
+	{Code: "VUV", Numeric: "548", Name: "Vatu", MinorUnits: 0},
+	{Code: "WST", Numeric: "882", Name: "Tala", MinorUnits: 2},
+	{Code: "XAF", Numeric: "950", Name: "CFA Franc BEAC", MinorUnits: 0},
+	{Code: "XCD", Numeric: "951", Name: "East Caribbean Dollar", MinorUnits: 2},
+	{Code: "XOF", Numeric: "952", Name: "CFA Franc BCEAO", MinorUnits: 0},
+	{Code: "XPF", Numeric: "953", Name: "CFP Franc", MinorUnits: 0},
+	{Code: "YER", Numeric: "886", Name: "Yemeni Rial", MinorUnits: 2},
+	{Code: "ZAR", Numeric: "710", Name: "Rand", MinorUnits: 2},
+	{Code: "ZMW", Numeric: "967", Name: "Zambian Kwacha", MinorUnits: 2, ActiveFrom: date(2013, 1, 1)},
+}
@@ -0,0 +1,84 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/gocraft/dbr/v2"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	a := assert.New(t)
+
+	ci, ok := currency.LookupCurrency(" usd")
+	a.True(ok)
+	a.Equal("USD", ci.Code)
+	a.Equal("840", ci.Numeric)
+	a.Equal(2, ci.MinorUnits)
+	a.False(ci.Historic())
+
+	ci, ok = currency.LookupCurrency("LVL")
+	a.True(ok)
+	a.True(ci.Historic())
+	a.True(ci.ActiveOn(time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)))
+	a.False(ci.ActiveOn(time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	_, ok = currency.LookupCurrency("XYZ")
+	a.False(ok)
+
+	// catalog must be ordered for the lookup to work
+	cis := currency.Catalog()
+	for i := 1; i < len(cis); i++ {
+		a.True(cis[i-1].Code < cis[i].Code, cis[i].Code)
+	}
+}
+
+func TestCurrency_Validate(t *testing.T) {
+	a := assert.New(t)
+
+	newCurrency := func(id string, pubDate time.Time) currency.Currency {
+		return currency.Currency{ID: id, Value: decimal.New(1, 0), PubDate: dbr.NewNullTime(pubDate)}
+	}
+
+	a.NoError(newCurrency("USD", time.Now()).Validate())
+	a.NoError(newCurrency("LVL", time.Date(2013, 5, 2, 0, 0, 0, 0, time.UTC)).Validate())
+	a.Equal(currency.ErrInactiveCurrency, newCurrency("LVL", time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)).Validate())
+	a.Equal(currency.ErrInactiveCurrency, newCurrency("RON", time.Date(2005, 6, 30, 0, 0, 0, 0, time.UTC)).Validate())
+	a.Equal(currency.ErrUnknownCurrency, newCurrency("QQQ", time.Now()).Validate())
+	a.Equal(currency.ErrEmptyCurrencyID, newCurrency(" ", time.Now()).Validate())
+}
+
+func TestManager_Currencies(t *testing.T) {
+	a := assert.New(t)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(pubDate, map[string]float64{"USD": 1.0801, "LVL": 0.7028, "QQQ": 1.5}),
+			newSnapshot(pubDate.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+
+	// retired and unknown currencies are rejected
+	r, err := m.Import(context.Background())
+	a.NoError(err)
+	a.Equal(2, r.Inserted)
+	a.Equal(2, r.Rejected)
+
+	kcs, err := m.Currencies(context.Background(), false)
+	a.NoError(err)
+	a.Len(kcs, 1)
+	a.Equal("USD", kcs[0].Code)
+	a.Equal("US Dollar", kcs[0].Name)
+	a.Equal(2, kcs[0].Count)
+	a.Equal(pubDate.AddDate(0, 0, -1), kcs[0].FirstPubDate.Time)
+	a.Equal(pubDate, kcs[0].LastPubDate.Time)
+}
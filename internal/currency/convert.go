@@ -0,0 +1,71 @@
+package currency
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/shopspring/decimal"
+)
+
+// BaseCurrency is the currency every stored value is relative to
+const BaseCurrency = "EUR"
+
+// Conversion represents an amount converted from one currency to another
+type Conversion struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Amount decimal.Decimal `json:"amount"`
+	Rate   decimal.Decimal `json:"rate"`
+	Result decimal.Decimal `json:"result"`
+
+	// PubDate is the publication date of the rates used,
+	// if they differ, then it's the oldest one
+	PubDate dbr.NullTime `json:"pub_date"`
+}
+
+// Convert converts an amount between two currencies by triangulating through
+// the base currency, using the most recent rates published on or before
+// a given date (zero date stands for the latest known rates)
+// NOTE: both the rate and the result are rounded as configured by
+// the conversion rounding, the result is computed from the exact rate
+func (m *Manager) Convert(ctx context.Context, from string, to string, amount decimal.Decimal, date time.Time) (c Conversion, err error) {
+	if m == nil {
+		return c, ErrNilManager
+	}
+
+	c = Conversion{
+		From:   strings.ToUpper(strings.TrimSpace(from)),
+		To:     strings.ToUpper(strings.TrimSpace(to)),
+		Amount: amount,
+	}
+
+	if c.From == "" || c.To == "" {
+		return c, ErrEmptyCurrencyID
+	}
+
+	fromRate, err := m.rateAt(ctx, c.From, date)
+	if err != nil {
+		return c, err
+	}
+
+	toRate, err := m.rateAt(ctx, c.To, date)
+	if err != nil {
+		return c, err
+	}
+
+	// base -> from, base -> to, hence from -> to
+	rate := toRate.Value.DivRound(fromRate.Value, divisionPlaces)
+
+	c.Rate = m.conversion.Round(rate)
+	c.Result = m.conversion.Round(amount.Mul(rate))
+
+	// reporting the oldest publication date of both rates
+	c.PubDate = fromRate.PubDate
+	if !c.PubDate.Valid || (toRate.PubDate.Valid && toRate.PubDate.Time.Before(c.PubDate.Time)) {
+		c.PubDate = toRate.PubDate
+	}
+
+	return c, nil
+}
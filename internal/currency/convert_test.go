@@ -0,0 +1,70 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Convert(t *testing.T) {
+	a := assert.New(t)
+
+	today := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(today, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+			newSnapshot(today.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934, "JPY": 117.67}),
+			newSnapshot(today.AddDate(0, 0, -2), map[string]float64{"USD": 1.0982}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	// cross rate, latest
+	c, err := m.Convert(context.Background(), "usd", "JPY", decimal.New(100, 0), time.Time{})
+	a.NoError(err)
+	a.Equal("USD", c.From)
+	a.Equal("JPY", c.To)
+	a.Equal("109.38802", c.Rate.String())
+	a.Equal("10938.801963", c.Result.String())
+	a.Equal(today, c.PubDate.Time)
+
+	// base currency has an implicit rate of 1.0
+	c, err = m.Convert(context.Background(), "EUR", "USD", decimal.New(10, 0), today.AddDate(0, 0, -1))
+	a.NoError(err)
+	a.Equal("1.0934", c.Rate.String())
+	a.Equal("10.934", c.Result.String())
+	a.Equal(today.AddDate(0, 0, -1), c.PubDate.Time)
+
+	// exact inverse conversion
+	c, err = m.Convert(context.Background(), "USD", "EUR", decimal.RequireFromString("1.0982"), today.AddDate(0, 0, -2))
+	a.NoError(err)
+	a.Equal("1", c.Result.String())
+
+	// there is no JPY rate on or before the date
+	_, err = m.Convert(context.Background(), "USD", "JPY", decimal.New(1, 0), today.AddDate(0, 0, -2))
+	a.Error(err)
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+
+	// the most recent rates on or before the date
+	c, err = m.Convert(context.Background(), "USD", "JPY", decimal.New(1, 0), today.AddDate(0, 0, 3))
+	a.NoError(err)
+	a.Equal(today, c.PubDate.Time)
+
+	// unknown and empty currencies
+	_, err = m.Convert(context.Background(), "USD", "XXX", decimal.New(1, 0), time.Time{})
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+
+	_, err = m.Convert(context.Background(), "", "USD", decimal.New(1, 0), time.Time{})
+	a.Equal(currency.ErrEmptyCurrencyID, err)
+}
@@ -0,0 +1,15 @@
+package currency
+
+// exported for the tests of the Cassandra statements, which don't need a cluster
+var (
+	CassandraUpsert       = cassandraUpsert
+	CassandraLatestInsert = cassandraLatestInsert
+	CassandraLatestUpdate = cassandraLatestUpdate
+	CassandraRange        = cassandraRange
+)
+
+// BreakMemoryLog closes the log of a persistent memory store behind its back,
+// so that the following writes fail
+func BreakMemoryLog(s Store) error {
+	return s.(*persistentMemoryStore).log.Close()
+}
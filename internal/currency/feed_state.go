@@ -0,0 +1,34 @@
+package currency
+
+import (
+	"context"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+// FeedState represents the last known state of a feed source, which
+// is used to avoid re-importing the feed if it hasn't changed
+type FeedState struct {
+	Source       string       `db:"source" json:"source"`
+	ETag         string       `db:"etag" json:"etag"`
+	LastModified string       `db:"last_modified" json:"last_modified"`
+	ContentHash  string       `db:"content_hash" json:"content_hash"`
+	CheckedAt    dbr.NullTime `db:"checked_at" json:"checked_at"`
+}
+
+// FeedStateStore is implemented by stores which are able to keep
+// feed states; conditional fetching is disabled for other stores
+type FeedStateStore interface {
+	FeedState(ctx context.Context, source string) (s FeedState, err error)
+	SaveFeedState(ctx context.Context, s FeedState) (err error)
+}
+
+// ConditionalFeedSource is implemented by sources which support
+// conditional fetching based on the previous state of the feed
+type ConditionalFeedSource interface {
+	FeedSource
+
+	// FetchConditional returns ErrNotModified along with the next
+	// state if the feed hasn't changed since a given previous state
+	FetchConditional(ctx context.Context, prev FeedState) (ss []Snapshot, next FeedState, err error)
+}
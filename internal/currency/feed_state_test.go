@@ -0,0 +1,105 @@
+package currency_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_ImportConditionalETag(t *testing.T) {
+	a := assert.New(t)
+
+	var hits, full int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		atomic.AddInt32(&full, 1)
+
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeFile(w, r, "testdata/ecb_rss.xml")
+	}))
+	defer ts.Close()
+
+	src, err := currency.NewRSSSource(ts.URL)
+	a.NoError(err)
+
+	store := currency.NewMemoryStore()
+
+	m, err := currency.NewManager(store, currency.WithFeedSource(src))
+	a.NoError(err)
+
+	// first import is never skipped
+	r, err := m.Import(context.Background())
+	a.NoError(err)
+	a.False(r.Skipped)
+	a.Equal(3, r.Snapshots)
+	a.Equal(18, r.Seen)
+
+	state, err := store.(currency.FeedStateStore).FeedState(context.Background(), src.Name())
+	a.NoError(err)
+	a.Equal(`"v1"`, state.ETag)
+	a.NotEmpty(state.ContentHash)
+
+	// second import is skipped due to 304
+	r, err = m.Import(context.Background())
+	a.NoError(err)
+	a.True(r.Skipped)
+	a.Zero(r.Seen)
+
+	a.EqualValues(2, atomic.LoadInt32(&hits))
+	a.EqualValues(1, atomic.LoadInt32(&full))
+}
+
+func TestManager_ImportConditionalContentHash(t *testing.T) {
+	a := assert.New(t)
+
+	payload, err := ioutil.ReadFile("testdata/ecb_rss.xml")
+	a.NoError(err)
+
+	var changed int32
+
+	// server without any validators
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+
+		if atomic.LoadInt32(&changed) == 1 {
+			w.Write([]byte("\n"))
+		}
+	}))
+	defer ts.Close()
+
+	src, err := currency.NewRSSSource(ts.URL)
+	a.NoError(err)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+
+	r, err := m.Import(context.Background())
+	a.NoError(err)
+	a.False(r.Skipped)
+
+	// same content
+	r, err = m.Import(context.Background())
+	a.NoError(err)
+	a.True(r.Skipped)
+
+	// changed content
+	atomic.StoreInt32(&changed, 1)
+
+	r, err = m.Import(context.Background())
+	a.NoError(err)
+	a.False(r.Skipped)
+	a.Equal(18, r.Seen)
+}
@@ -0,0 +1,156 @@
+package currency
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+)
+
+// DefaultGapDays is the default length of the checked range, ending yesterday
+const DefaultGapDays = 30
+
+// GapReport represents the business days missing from the store within
+// a range of dates, per currency and for all of them at once
+// NOTE: dates are formatted as YYYY-MM-DD, so they can be passed
+// to the backfill as they are
+type GapReport struct {
+	From         dbr.NullTime `json:"from"`
+	To           dbr.NullTime `json:"to"`
+	BusinessDays int          `json:"business_days"`
+
+	// MissingDates are business days without a value of any checked currency,
+	// which usually means that the import of that date has failed
+	MissingDates []string `json:"missing_dates"`
+
+	// Gaps are the currencies missing at least one business day
+	Gaps []Gap `json:"gaps"`
+}
+
+// Gap represents business days missing for a single currency
+type Gap struct {
+	CurrencyID string   `json:"currency_id"`
+	Expected   int      `json:"expected"`
+	Missing    []string `json:"missing"`
+}
+
+// Gaps reports the business days missing from the store within a given range
+// of dates (the last 30 days until yesterday by default), for given currencies
+// or for every currency known to the store
+// NOTE: a currency is expected on the business days since its first stored
+// value and while it's in use, thus the dates before the beginning of its
+// history aren't reported as missing
+func (m *Manager) Gaps(ctx context.Context, from, to time.Time, ids ...string) (r GapReport, err error) {
+	if m == nil {
+		return r, ErrNilManager
+	}
+
+	store, err := m.Store()
+	if err != nil {
+		return r, errors.Wrap(err, "failed to obtain currency store")
+	}
+
+	ostore, ok := store.(ObservationStore)
+	if !ok {
+		return r, ErrUnsupportedByStore
+	}
+
+	// NOTE: today's values may be not published yet, thus not expected
+	if to.IsZero() {
+		to = time.Now().AddDate(0, 0, -1)
+	}
+
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -DefaultGapDays)
+	}
+
+	if from.After(to) {
+		return r, errors.Wrap(ErrInvalidHistoryQuery, "from date is after to date")
+	}
+
+	obs, err := ostore.Observations(ctx)
+	if err != nil {
+		return r, errors.Wrap(err, "failed to obtain observed currencies")
+	}
+
+	observed := make(map[string]Observation, len(obs))
+	for _, o := range obs {
+		observed[o.ID] = o
+	}
+
+	// checking every observed currency unless specific ones are requested
+	if len(ids) == 0 {
+		for _, o := range obs {
+			ids = append(ids, o.ID)
+		}
+	}
+
+	days := BusinessDays(from, to)
+
+	r = GapReport{
+		From:         dbr.NewNullTime(from),
+		To:           dbr.NewNullTime(to),
+		BusinessDays: len(days),
+		MissingDates: make([]string, 0),
+		Gaps:         make([]Gap, 0),
+	}
+
+	// number of currencies expected and missing on each date
+	expected := make(map[string]int, len(days))
+	missing := make(map[string]int, len(days))
+
+	for _, id := range ids {
+		id = strings.ToUpper(strings.TrimSpace(id))
+
+		o, ok := observed[id]
+		if !ok {
+			return r, errors.Wrapf(ErrCurrencyNotFound, "no %s values", id)
+		}
+
+		cs, err := store.RangeByID(ctx, id, Range{From: from, To: to, Order: OrderAsc})
+		if err != nil {
+			return r, errors.Wrapf(err, "failed to fetch currency history for ID: %s", id)
+		}
+
+		stored := make(map[string]bool, len(cs))
+		for _, c := range cs {
+			stored[c.PubDate.Time.Format(dateLayout)] = true
+		}
+
+		ci, _ := LookupCurrency(id)
+		first := o.FirstPubDate.Time.Format(dateLayout)
+		g := Gap{CurrencyID: id, Missing: make([]string, 0)}
+
+		for _, day := range days {
+			date := day.Format(dateLayout)
+
+			if date < first || (ci.Code != "" && !ci.ActiveOn(day)) {
+				continue
+			}
+
+			g.Expected++
+			expected[date]++
+
+			if !stored[date] {
+				g.Missing = append(g.Missing, date)
+				missing[date]++
+			}
+		}
+
+		if len(g.Missing) > 0 {
+			r.Gaps = append(r.Gaps, g)
+		}
+	}
+
+	for _, day := range days {
+		date := day.Format(dateLayout)
+
+		if expected[date] > 0 && missing[date] == expected[date] {
+			r.MissingDates = append(r.MissingDates, date)
+		}
+	}
+
+	return r, nil
+}
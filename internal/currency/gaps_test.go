@@ -0,0 +1,81 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Gaps(t *testing.T) {
+	a := assert.New(t)
+
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(2020, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	// from Thursday before Easter to the following Thursday, where
+	// Tuesday is missing entirely and JPY is missing on Wednesday
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(date(4, 9), map[string]float64{"USD": 1.0867, "JPY": 118.30}),
+			newSnapshot(date(4, 15), map[string]float64{"USD": 1.0916}),
+			newSnapshot(date(4, 16), map[string]float64{"USD": 1.0867, "JPY": 117.08}),
+			newSnapshot(date(4, 14), map[string]float64{"GBP": 0.87}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	r, err := m.Gaps(context.Background(), date(4, 9), date(4, 16), "USD", "jpy")
+	a.NoError(err)
+	a.Equal(4, r.BusinessDays)
+	a.Equal([]string{"2020-04-14"}, r.MissingDates)
+	a.Len(r.Gaps, 2)
+
+	a.Equal("USD", r.Gaps[0].CurrencyID)
+	a.Equal(4, r.Gaps[0].Expected)
+	a.Equal([]string{"2020-04-14"}, r.Gaps[0].Missing)
+
+	a.Equal("JPY", r.Gaps[1].CurrencyID)
+	a.Equal([]string{"2020-04-14", "2020-04-15"}, r.Gaps[1].Missing)
+
+	// all stored currencies, GBP isn't expected before its first value
+	r, err = m.Gaps(context.Background(), date(4, 9), date(4, 16))
+	a.NoError(err)
+	a.Len(r.Gaps, 3)
+	a.Equal("GBP", r.Gaps[0].CurrencyID)
+	a.Equal(3, r.Gaps[0].Expected)
+	a.Equal([]string{"2020-04-15", "2020-04-16"}, r.Gaps[0].Missing)
+	a.Empty(r.MissingDates)
+
+	// no gaps
+	r, err = m.Gaps(context.Background(), date(4, 10), date(4, 13), "USD")
+	a.NoError(err)
+	a.Zero(r.BusinessDays)
+	a.Empty(r.Gaps)
+
+	// unknown currency and invalid range
+	_, err = m.Gaps(context.Background(), date(4, 9), date(4, 16), "CHF")
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+
+	_, err = m.Gaps(context.Background(), date(4, 16), date(4, 9))
+	a.Equal(currency.ErrInvalidHistoryQuery, errors.Cause(err))
+
+	// today isn't expected by default, since it may be not published yet
+	today := time.Now()
+
+	r, err = m.Gaps(context.Background(), time.Time{}, time.Time{}, "USD")
+	a.NoError(err)
+	a.Equal(today.AddDate(0, 0, -1).Format("2006-01-02"), r.To.Time.Format("2006-01-02"))
+	a.Equal(today.AddDate(0, 0, -31).Format("2006-01-02"), r.From.Time.Format("2006-01-02"))
+	a.NotContains(r.MissingDates, today.Format("2006-01-02"))
+	a.Equal(len(currency.BusinessDays(r.From.Time, r.To.Time)), r.BusinessDays)
+}
@@ -0,0 +1,217 @@
+package currency
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// history page limits
+const (
+	DefaultHistoryLimit = 100
+	MaxHistoryLimit     = 1000
+)
+
+// SortOrder represents the order of publication dates
+type SortOrder string
+
+// sort orders
+const (
+	OrderAsc  SortOrder = "asc"
+	OrderDesc SortOrder = "desc"
+)
+
+// Range represents a window of publication dates of a single currency,
+// as it's queried from the store
+// NOTE: zero dates are unbounded, After is an exclusive position in
+// the given order (i.e. the last date of the previous page), and zero
+// limit stands for no limit at all
+type Range struct {
+	From  time.Time
+	To    time.Time
+	After time.Time
+	Order SortOrder
+	Limit int
+}
+
+// HistoryQuery represents a request of a single page of currency history
+// NOTE: zero dates are unbounded, an empty cursor stands for the first page
+type HistoryQuery struct {
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Cursor string
+	Order  SortOrder
+}
+
+// HistoryPage represents a single page of currency history, the cursor
+// of the next page is empty if this page is the last one
+type HistoryPage struct {
+	Currencies []Currency `json:"currencies"`
+	Limit      int        `json:"limit"`
+	Order      SortOrder  `json:"order"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// HasMore tells whether there is a next page
+func (p HistoryPage) HasMore() bool {
+	return p.NextCursor != ""
+}
+
+// HistoryByID returns a single page of currency history within a given date
+// range, relative to an optional base currency
+// NOTE: rebased pages omit the dates where the base value is missing, thus
+// such pages may contain less items than the limit, even if there are more
+func (m *Manager) HistoryByID(ctx context.Context, id string, base string, q HistoryQuery) (p HistoryPage, err error) {
+	if m == nil {
+		return p, ErrNilManager
+	}
+
+	store, err := m.Store()
+	if err != nil {
+		return p, errors.Wrap(err, "failed to obtain currency store")
+	}
+
+	if id = strings.ToUpper(strings.TrimSpace(id)); id == "" {
+		return p, ErrEmptyCurrencyID
+	}
+
+	r, err := q.rangeOf()
+	if err != nil {
+		return p, err
+	}
+
+	p = HistoryPage{Limit: r.Limit, Order: r.Order}
+
+	// the base currency itself isn't stored, thus paging through its values
+	// within the base history and deriving them afterwards
+	base = normalizeBase(base)
+	pivot := id
+
+	if id == BaseCurrency && base != BaseCurrency {
+		pivot = base
+	}
+
+	// requesting one more item to find out whether there is a next page
+	r.Limit++
+
+	cs, err := store.RangeByID(ctx, pivot, r)
+	if err != nil {
+		return p, errors.Wrapf(err, "failed to fetch currency history for ID: %s", pivot)
+	}
+
+	if len(cs) == 0 {
+		if q.Cursor == "" {
+			return p, errors.Wrapf(ErrCurrencyNotFound, "no %s values within the range", id)
+		}
+
+		p.Currencies = cs
+
+		return p, nil
+	}
+
+	if len(cs) > p.Limit {
+		cs = cs[:p.Limit]
+		p.NextCursor = encodeCursor(cs[len(cs)-1].PubDate.Time)
+	}
+
+	if base == BaseCurrency {
+		p.Currencies = cs
+		return p, nil
+	}
+
+	// obtaining base values within the dates of this page
+	bs := cs
+
+	if pivot != base {
+		first, last := cs[0].PubDate.Time, cs[len(cs)-1].PubDate.Time
+		if first.After(last) {
+			first, last = last, first
+		}
+
+		if bs, err = store.RangeByID(ctx, base, Range{From: first, To: last, Order: OrderAsc}); err != nil {
+			return p, errors.Wrapf(err, "failed to fetch currency history for ID: %s", base)
+		}
+	}
+
+	rates := make(map[string]decimal.Decimal, len(bs))
+	for _, b := range bs {
+		rates[b.PubDate.Time.Format(dateLayout)] = b.Value
+	}
+
+	if pivot != id {
+		for i := range cs {
+			cs[i] = Currency{ID: BaseCurrency, Value: decimal.New(1, 0), PubDate: cs[i].PubDate}
+		}
+	}
+
+	p.Currencies = rebase(cs, m.conversion, func(c Currency) (decimal.Decimal, bool) {
+		rate, ok := rates[c.PubDate.Time.Format(dateLayout)]
+		return rate, ok
+	})
+
+	return p, nil
+}
+
+// rangeOf validates the query and transforms it into a store range
+func (q HistoryQuery) rangeOf() (r Range, err error) {
+	r = Range{
+		From:  q.From,
+		To:    q.To,
+		Order: SortOrder(strings.ToLower(strings.TrimSpace(string(q.Order)))),
+		Limit: q.Limit,
+	}
+
+	switch r.Order {
+	case "":
+		r.Order = OrderDesc
+	case OrderAsc, OrderDesc:
+	default:
+		return r, errors.Wrapf(ErrInvalidHistoryQuery, "unsupported order: %s", q.Order)
+	}
+
+	switch {
+	case r.Limit < 0:
+		return r, errors.Wrapf(ErrInvalidHistoryQuery, "invalid limit: %d", q.Limit)
+	case r.Limit == 0:
+		r.Limit = DefaultHistoryLimit
+	case r.Limit > MaxHistoryLimit:
+		r.Limit = MaxHistoryLimit
+	}
+
+	if !r.From.IsZero() && !r.To.IsZero() && r.From.After(r.To) {
+		return r, errors.Wrapf(ErrInvalidHistoryQuery, "from date is after to date")
+	}
+
+	if q.Cursor != "" {
+		if r.After, err = decodeCursor(q.Cursor); err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+// encodeCursor returns an opaque cursor pointing after a given date
+// NOTE: publication date is unique within the history of a currency
+func encodeCursor(date time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(date.Format(dateLayout)))
+}
+
+// decodeCursor returns the date a given cursor points after
+func decodeCursor(cursor string) (date time.Time, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return date, errors.Wrapf(ErrInvalidHistoryQuery, "invalid cursor: %s", cursor)
+	}
+
+	if date, err = time.Parse(dateLayout, string(b)); err != nil {
+		return date, errors.Wrapf(ErrInvalidHistoryQuery, "invalid cursor: %s", cursor)
+	}
+
+	return date, nil
+}
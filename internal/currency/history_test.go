@@ -0,0 +1,90 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_HistoryByID(t *testing.T) {
+	a := assert.New(t)
+
+	today := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(today, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+			newSnapshot(today.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934, "JPY": 117.67}),
+			newSnapshot(today.AddDate(0, 0, -2), map[string]float64{"USD": 1.0982}),
+			newSnapshot(today.AddDate(0, 0, -5), map[string]float64{"USD": 1.1053, "JPY": 119.08}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	// latest first by default
+	p, err := m.HistoryByID(context.Background(), "usd", "", currency.HistoryQuery{Limit: 3})
+	a.NoError(err)
+	a.Equal(3, p.Limit)
+	a.Equal(currency.OrderDesc, p.Order)
+	a.True(p.HasMore())
+	a.Len(p.Currencies, 3)
+	a.Equal(today, p.Currencies[0].PubDate.Time)
+	a.Equal(today.AddDate(0, 0, -2), p.Currencies[2].PubDate.Time)
+
+	// next page
+	p, err = m.HistoryByID(context.Background(), "USD", "", currency.HistoryQuery{Limit: 3, Cursor: p.NextCursor})
+	a.NoError(err)
+	a.False(p.HasMore())
+	a.Len(p.Currencies, 1)
+	a.Equal(today.AddDate(0, 0, -5), p.Currencies[0].PubDate.Time)
+
+	// ascending within a date range
+	p, err = m.HistoryByID(context.Background(), "USD", "", currency.HistoryQuery{
+		From:  today.AddDate(0, 0, -4),
+		To:    today.AddDate(0, 0, -1),
+		Order: currency.OrderAsc,
+	})
+	a.NoError(err)
+	a.Equal(currency.DefaultHistoryLimit, p.Limit)
+	a.False(p.HasMore())
+	a.Len(p.Currencies, 2)
+	a.Equal("1.0982", p.Currencies[0].Value.String())
+	a.Equal("1.0934", p.Currencies[1].Value.String())
+
+	// rebased, omitting the date without the base value
+	p, err = m.HistoryByID(context.Background(), "EUR", "JPY", currency.HistoryQuery{Limit: 2})
+	a.NoError(err)
+	a.True(p.HasMore())
+	a.Len(p.Currencies, 2)
+	a.Equal("EUR", p.Currencies[0].ID)
+	a.Equal("0.008464", p.Currencies[0].Value.String())
+
+	p, err = m.HistoryByID(context.Background(), "USD", "JPY", currency.HistoryQuery{From: today.AddDate(0, 0, -2)})
+	a.NoError(err)
+	a.Len(p.Currencies, 2)
+	a.Equal("0.009142", p.Currencies[0].Value.String())
+
+	// nothing within the range
+	_, err = m.HistoryByID(context.Background(), "USD", "", currency.HistoryQuery{To: today.AddDate(0, 0, -10)})
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+
+	// invalid queries
+	for _, q := range []currency.HistoryQuery{
+		{Limit: -1},
+		{Order: "sideways"},
+		{Cursor: "not a cursor"},
+		{From: today, To: today.AddDate(0, 0, -1)},
+	} {
+		_, err = m.HistoryByID(context.Background(), "USD", "", q)
+		a.Equal(currency.ErrInvalidHistoryQuery, errors.Cause(err))
+	}
+}
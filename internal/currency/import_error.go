@@ -0,0 +1,86 @@
+package currency
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ImportErrorKind classifies import failures
+type ImportErrorKind int
+
+// import error kinds
+const (
+	ImportErrorUnknown ImportErrorKind = iota
+	ImportErrorNetwork
+	ImportErrorHTTPStatus
+	ImportErrorParse
+	ImportErrorValidation
+)
+
+func (k ImportErrorKind) String() string {
+	switch k {
+	case ImportErrorNetwork:
+		return "network"
+	case ImportErrorHTTPStatus:
+		return "http status"
+	case ImportErrorParse:
+		return "parse"
+	case ImportErrorValidation:
+		return "validation"
+	default:
+		return "unknown"
+	}
+}
+
+// ImportError represents a typed failure of a feed import
+// NOTE: it intentionally doesn't implement Cause(), so that
+// errors.Cause() stops at this error and its kind is preserved
+type ImportError struct {
+	Kind       ImportErrorKind
+	Source     string
+	StatusCode int
+	Err        error
+}
+
+func newImportError(kind ImportErrorKind, source string, err error) *ImportError {
+	return &ImportError{
+		Kind:   kind,
+		Source: source,
+		Err:    err,
+	}
+}
+
+func (e *ImportError) Error() string {
+	if e.Kind == ImportErrorHTTPStatus {
+		return fmt.Sprintf("%s error [%s]: unexpected status %d", e.Kind, e.Source, e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s error [%s]: %s", e.Kind, e.Source, e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Transient returns true if the failure is likely to go away on retry,
+// which is the case for network failures, server side errors and throttling
+func (e *ImportError) Transient() bool {
+	switch e.Kind {
+	case ImportErrorNetwork:
+		return true
+	case ImportErrorHTTPStatus:
+		return e.StatusCode >= http.StatusInternalServerError ||
+			e.StatusCode == http.StatusTooManyRequests ||
+			e.StatusCode == http.StatusRequestTimeout
+	default:
+		return false
+	}
+}
+
+// AsImportError extracts typed import error, if there is one
+func AsImportError(err error) (*ImportError, bool) {
+	ierr, ok := errors.Cause(err).(*ImportError)
+	return ierr, ok
+}
@@ -0,0 +1,151 @@
+package currency_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryPolicy = currency.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+	AttemptTimeout: 50 * time.Millisecond,
+}
+
+func newTestManager(t *testing.T, h http.HandlerFunc) (*currency.Manager, func()) {
+	ts := httptest.NewServer(h)
+
+	src, err := currency.NewRSSSource(ts.URL)
+	assert.NoError(t, err)
+
+	m, err := currency.NewManager(
+		currency.NewMemoryStore(),
+		currency.WithFeedSource(src),
+		currency.WithRetryPolicy(testRetryPolicy),
+	)
+
+	assert.NoError(t, err)
+
+	return m, ts.Close
+}
+
+func TestManager_ImportRetriesTransientFailures(t *testing.T) {
+	a := assert.New(t)
+
+	var hits int32
+
+	m, done := newTestManager(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		http.ServeFile(w, r, "testdata/ecb_rss.xml")
+	})
+	defer done()
+
+	_, err := m.Import(context.Background())
+	a.NoError(err)
+	a.EqualValues(3, atomic.LoadInt32(&hits))
+
+	cs, err := m.GetLatest(context.Background(), "")
+	a.NoError(err)
+	a.Len(cs, 6)
+}
+
+func TestManager_ImportErrorKinds(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		kind    currency.ImportErrorKind
+		hits    int32
+	}{
+		{
+			name: "persistent server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "unavailable", http.StatusBadGateway)
+			},
+			kind: currency.ImportErrorHTTPStatus,
+			hits: 3,
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			kind: currency.ImportErrorHTTPStatus,
+			hits: 1,
+		},
+		{
+			name: "malformed payload",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("not a feed"))
+			},
+			kind: currency.ImportErrorParse,
+			hits: 1,
+		},
+		{
+			name: "attempt timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(100 * time.Millisecond)
+			},
+			kind: currency.ImportErrorNetwork,
+			hits: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		var hits int32
+
+		m, done := newTestManager(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			tt.handler(w, r)
+		})
+
+		_, err := m.Import(context.Background())
+		a.Error(err, tt.name)
+
+		ierr, ok := currency.AsImportError(err)
+		a.True(ok, tt.name)
+		a.Equal(tt.kind, ierr.Kind, tt.name)
+		a.Equal(tt.hits, atomic.LoadInt32(&hits), tt.name)
+
+		done()
+	}
+}
+
+func TestManager_ImportValidationError(t *testing.T) {
+	a := assert.New(t)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(pubDate, map[string]float64{"USD": 1.0801, "JPY": 0}),
+		},
+	}
+
+	store := currency.NewMemoryStore()
+
+	m, err := currency.NewManager(store, currency.WithFeedSource(src), currency.WithStrictImport(true))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	ierr, ok := currency.AsImportError(err)
+	a.True(ok)
+	a.Equal(currency.ImportErrorValidation, ierr.Kind)
+
+	// nothing must be stored
+	cs, err := store.AllLatest(context.Background())
+	a.NoError(err)
+	a.Empty(cs)
+}
@@ -0,0 +1,31 @@
+package currency
+
+import (
+	"github.com/gocraft/dbr/v2"
+)
+
+// ImportReport summarizes a single import run, and
+// is also stored as a record of the import audit log
+type ImportReport struct {
+	ID         int64        `db:"id" json:"id"`
+	Source     string       `db:"source" json:"source"`
+	StartedAt  dbr.NullTime `db:"started_at" json:"started_at"`
+	FinishedAt dbr.NullTime `db:"finished_at" json:"finished_at"`
+
+	// Skipped is set when the feed hasn't changed since the previous
+	// import, thus nothing has been stored
+	Skipped bool `db:"skipped" json:"skipped"`
+
+	Snapshots int `db:"snapshots" json:"snapshots"`
+	Seen      int `db:"items_seen" json:"items_seen"`
+	Inserted  int `db:"items_inserted" json:"items_inserted"`
+	Updated   int `db:"items_updated" json:"items_updated"`
+	Unchanged int `db:"items_unchanged" json:"items_unchanged"`
+	Rejected  int `db:"items_rejected" json:"items_rejected"`
+
+	// Discrepancies is the number of values on which sources have disagreed
+	Discrepancies int `db:"discrepancies" json:"discrepancies"`
+
+	// Error is the failure message of an unsuccessful import
+	Error string `db:"error" json:"error,omitempty"`
+}
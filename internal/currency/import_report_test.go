@@ -0,0 +1,75 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_ImportHistory(t *testing.T) {
+	a := assert.New(t)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(pubDate, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src), currency.WithStrictImport(true))
+	a.NoError(err)
+
+	// first run inserts everything
+	r, err := m.Import(context.Background())
+	a.NoError(err)
+	a.Equal(1, r.Snapshots)
+	a.Equal(2, r.Seen)
+	a.Equal(2, r.Inserted)
+	a.False(r.FinishedAt.Time.Before(r.StartedAt.Time))
+
+	// second run updates one value
+	src.ss = []currency.Snapshot{
+		newSnapshot(pubDate, map[string]float64{"USD": 1.0802, "JPY": 118.15}),
+	}
+
+	r, err = m.Import(context.Background())
+	a.NoError(err)
+	a.Equal(1, r.Updated)
+	a.Equal(1, r.Unchanged)
+
+	// third run is rejected (strict mode)
+	src.ss = []currency.Snapshot{
+		newSnapshot(pubDate, map[string]float64{"USD": 0, "JPY": 0}),
+	}
+
+	_, err = m.Import(context.Background())
+	a.Error(err)
+
+	// fourth run fails to fetch
+	src.err = errors.New("source is down")
+
+	_, err = m.Import(context.Background())
+	a.Error(err)
+
+	// all runs must be recorded, latest first
+	rs, err := m.ImportHistory(context.Background(), 0)
+	a.NoError(err)
+	a.Len(rs, 4)
+	a.EqualValues(4, rs[0].ID)
+	a.Contains(rs[0].Error, "source is down")
+	a.Equal(2, rs[1].Rejected)
+	a.NotEmpty(rs[1].Error)
+	a.Equal(1, rs[2].Updated)
+	a.Empty(rs[3].Error)
+	a.Equal(2, rs[3].Inserted)
+
+	rs, err = m.ImportHistory(context.Background(), 2)
+	a.NoError(err)
+	a.Len(rs, 2)
+	a.EqualValues(3, rs[1].ID)
+}
@@ -4,25 +4,42 @@ import (
 	"strings"
 
 	"github.com/gocraft/dbr/v2"
+	"github.com/shopspring/decimal"
 )
 
-// Currency represents a single currency item
+// dateLayout is the layout of publication dates (i.e. as in ECB feeds)
+const dateLayout = "2006-01-02"
+
+// Currency represents a single currency item, its value is an exact
+// decimal which is encoded into JSON as a string
 type Currency struct {
-	ID        string       `db:"id" json:"id"`
-	Value     float64      `db:"value" json:"value"`
-	PubDate   dbr.NullTime `db:"pub_date" json:"pub_date"`
-	CreatedAt dbr.NullTime `db:"created_at" json:"created_at"`
-	UpdatedAt dbr.NullTime `db:"updated_at" json:"updated_at"`
+	ID        string          `db:"id" json:"id"`
+	Value     decimal.Decimal `db:"value" json:"value"`
+	PubDate   dbr.NullTime    `db:"pub_date" json:"pub_date"`
+	Source    string          `db:"source" json:"source"`
+	CreatedAt dbr.NullTime    `db:"created_at" json:"created_at"`
+	UpdatedAt dbr.NullTime    `db:"updated_at" json:"updated_at"`
 }
 
+// Validate checks whether the currency is fit to be stored, its ID
+// must be a known ISO 4217 code in use on the publication date
 func (c Currency) Validate() (err error) {
 	if strings.TrimSpace(c.ID) == "" {
-		err = ErrEmptyCurrencyID
+		return ErrEmptyCurrencyID
+	}
+
+	ci, ok := LookupCurrency(c.ID)
+	if !ok {
+		return ErrUnknownCurrency
+	}
+
+	if c.PubDate.Valid && !ci.ActiveOn(c.PubDate.Time) {
+		return ErrInactiveCurrency
 	}
 
 	// NOTE: technically this could be zero, but very unlikely
-	if c.Value == 0 {
-		err = ErrInvalidCurrencyValue
+	if c.Value.IsZero() {
+		return ErrInvalidCurrencyValue
 	}
 
 	return nil
@@ -2,14 +2,13 @@ package currency
 
 import (
 	"context"
-	"log"
-	"strconv"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gocraft/dbr/v2"
-	"github.com/mmcdole/gofeed"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -21,32 +20,194 @@ var (
 	ErrCurrencyNotFound     = errors.New("currency not found")
 	ErrNoData               = errors.New("no data")
 	ErrEmptyFeedURL         = errors.New("invalid feed url")
+	ErrNilFeedSource        = errors.New("feed source is nil")
+	ErrUnsupportedFormat    = errors.New("unsupported payload format")
+	ErrNotModified          = errors.New("feed not modified")
+	ErrFeedStateNotFound    = errors.New("feed state not found")
+	ErrUnsupportedByStore   = errors.New("operation is not supported by the store")
 	ErrInvalidPayloadFormat = errors.New("invalid payload format")
 	ErrEmptyCurrencyID      = errors.New("invalid currency id")
+	ErrUnknownCurrency      = errors.New("unknown currency code")
+	ErrInactiveCurrency     = errors.New("currency is not in use on the publication date")
 	ErrInvalidCurrencyValue = errors.New("invalid currency value")
+	ErrStaleRate            = errors.New("currency rate is too stale")
+	ErrInvalidHistoryQuery  = errors.New("invalid history query")
+	ErrUnsupportedPeriod    = errors.New("unsupported aggregation period")
+	ErrInvalidStatsQuery    = errors.New("invalid stats query")
+	ErrInvalidAlertRule     = errors.New("invalid alert rule")
+	ErrAlertRuleNotFound    = errors.New("alert rule not found")
+	ErrNilNotifier          = errors.New("notifier is nil")
+	ErrClosedStore          = errors.New("store is closed")
+	ErrCorruptLog           = errors.New("store log is corrupt")
 )
 
 // Manager handles business logic of its underlying objects
 type Manager struct {
-	feedAddr string
-	store    Store
-	logger   *zap.Logger
+	sources    []FeedSource
+	reconcile  ReconcilePolicy
+	retry      RetryPolicy
+	strict     bool
+	rounding   Rounding
+	conversion Rounding
+	staleness  int
+	notifiers  []Notifier
+	store      Store
+	logger     *zap.Logger
+}
+
+// ManagerOption configures the manager during its initialization
+type ManagerOption func(m *Manager) error
+
+// WithFeedSource sets the feed source to import currencies from
+func WithFeedSource(src FeedSource) ManagerOption {
+	return func(m *Manager) error {
+		if src == nil {
+			return ErrNilFeedSource
+		}
+
+		m.sources = []FeedSource{src}
+
+		return nil
+	}
+}
+
+// WithFeedSources sets multiple feed sources to import currencies from,
+// given in the order of priority (the first one is the most trusted)
+func WithFeedSources(srcs ...FeedSource) ManagerOption {
+	return func(m *Manager) error {
+		if len(srcs) == 0 {
+			return ErrNilFeedSource
+		}
+
+		for _, src := range srcs {
+			if src == nil {
+				return ErrNilFeedSource
+			}
+		}
+
+		m.sources = srcs
+
+		return nil
+	}
+}
+
+// WithFeedURL sets the default bank.lv RSS feed source
+func WithFeedURL(feedURL string) ManagerOption {
+	return func(m *Manager) error {
+		src, err := NewRSSSource(feedURL)
+		if err != nil {
+			return err
+		}
+
+		m.sources = []FeedSource{src}
+
+		return nil
+	}
+}
+
+// WithReconcilePolicy sets the policy which decides the published
+// value when multiple sources disagree
+func WithReconcilePolicy(p ReconcilePolicy) ManagerOption {
+	return func(m *Manager) error {
+		switch p.Strategy {
+		case ReconcilePriority, ReconcileMedian:
+		default:
+			return errors.Errorf("unsupported reconcile strategy: %s", p.Strategy)
+		}
+
+		if p.Tolerance < 0 {
+			return errors.Errorf("invalid reconcile tolerance: %f", p.Tolerance)
+		}
+
+		m.reconcile = p
+
+		return nil
+	}
+}
+
+// WithRetryPolicy sets the retry policy of feed imports
+func WithRetryPolicy(p RetryPolicy) ManagerOption {
+	return func(m *Manager) error {
+		m.retry = p
+
+		return nil
+	}
+}
+
+// WithStrictImport enables the all-or-nothing import mode, where a single
+// rejected item fails the whole import; by default rejected items are
+// put into quarantine, while the rest is stored
+func WithStrictImport(strict bool) ManagerOption {
+	return func(m *Manager) error {
+		m.strict = strict
+
+		return nil
+	}
+}
+
+// WithValueRounding sets the precision of stored values, and the way
+// imported values are rounded to it before being stored
+func WithValueRounding(r Rounding) ManagerOption {
+	return func(m *Manager) error {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+
+		m.rounding = r
+
+		return nil
+	}
+}
+
+// WithConversionRounding sets the precision of conversion results and
+// rebased values, and the way they're rounded to it
+func WithConversionRounding(r Rounding) ManagerOption {
+	return func(m *Manager) error {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+
+		m.conversion = r
+
+		return nil
+	}
+}
+
+// WithMaxStaleness sets the maximum number of days an as-of value
+// may lag behind the requested date
+func WithMaxStaleness(days int) ManagerOption {
+	return func(m *Manager) error {
+		if days < 0 {
+			return errors.Errorf("invalid max staleness: %d", days)
+		}
+
+		m.staleness = days
+
+		return nil
+	}
 }
 
 // NewCurrencyManager initializes a new manager
-func NewManager(s Store, feedURL string) (*Manager, error) {
+// NOTE: a manager without a feed source is still usable, but cannot import
+func NewManager(s Store, opts ...ManagerOption) (*Manager, error) {
 	if s == nil {
 		return nil, errors.Wrap(ErrNilCurrencyStore, "failed to initialize currency manager")
 	}
 
-	feedURL = strings.TrimSpace(feedURL)
-	if feedURL == "" {
-		return nil, ErrEmptyFeedURL
+	m := &Manager{
+		store:      s,
+		retry:      DefaultRetryPolicy,
+		reconcile:  DefaultReconcilePolicy,
+		rounding:   DefaultValueRounding,
+		conversion: DefaultConversionRounding,
+		staleness:  DefaultMaxStaleness,
 	}
 
-	m := &Manager{
-		store:    s,
-		feedAddr: feedURL,
+	// applying options
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, errors.Wrap(err, "failed to initialize currency manager")
+		}
 	}
 
 	return m, nil
@@ -61,6 +222,24 @@ func (m *Manager) Store() (Store, error) {
 	return m.store, nil
 }
 
+// FeedSource returns the primary (most trusted) feed source if set
+func (m *Manager) FeedSource() (FeedSource, error) {
+	if len(m.sources) == 0 {
+		return nil, ErrNilFeedSource
+	}
+
+	return m.sources[0], nil
+}
+
+// FeedSources returns all feed sources in the order of priority
+func (m *Manager) FeedSources() ([]FeedSource, error) {
+	if len(m.sources) == 0 {
+		return nil, ErrNilFeedSource
+	}
+
+	return m.sources, nil
+}
+
 // SetLogger assigns a primary logger for the manager
 func (m *Manager) SetLogger(logger *zap.Logger) error {
 	// if logger is set, then giving it a name
@@ -91,88 +270,441 @@ func (m *Manager) Logger() *zap.Logger {
 	return m.logger
 }
 
-// Import imports external feed and returns as localized currency items
-func (m *Manager) Import(ctx context.Context) (err error) {
-	// initializing and parsing the feed
-	f, err := gofeed.NewParser().ParseURL(m.feedAddr)
+// Import imports external feeds and stores them as localized currency items,
+// evaluating alert rules afterwards if the store supports them
+func (m *Manager) Import(ctx context.Context) (r ImportReport, err error) {
+	srcs, err := m.FeedSources()
 	if err != nil {
-		log.Fatalf("failed to parse feed [%s]: %s", m.feedAddr, err)
+		return r, err
+	}
+
+	r, err = m.ImportFrom(ctx, srcs...)
+
+	// NOTE: alerts are evaluated even if the import has failed (i.e. for
+	// stale data), and failing to evaluate them doesn't fail the import
+	if _, aerr := m.EvaluateAlerts(ctx); aerr != nil && aerr != ErrUnsupportedByStore {
+		m.Logger().Warn("failed to evaluate alerts", zap.Error(aerr))
 	}
 
-	//---------------------------------------------------------------------------
-	// this function transforms raw currency payload into an intermediate map
-	//---------------------------------------------------------------------------
-	fn := func(s []string) (cs map[string]float64, err error) {
-		slen := len(s)
+	return r, err
+}
+
+// ImportFrom imports currencies from given sources (in the order of priority),
+// regardless of the ones the manager is configured with (i.e. a local file);
+// each run is recorded in the import audit log if the store supports it
+// NOTE: failures of the source are returned as *ImportError
+func (m *Manager) ImportFrom(ctx context.Context, srcs ...FeedSource) (r ImportReport, err error) {
+	if len(srcs) == 0 {
+		return r, ErrNilFeedSource
+	}
 
-		// slice must not be empty or contain an odd number of items
-		if slen == 0 || slen%2 != 0 {
-			return nil, ErrInvalidPayloadFormat
+	names := make([]string, 0, len(srcs))
+	for _, src := range srcs {
+		if src == nil {
+			return r, ErrNilFeedSource
 		}
 
-		// initializing result map
-		cs = make(map[string]float64, len(s)/2)
+		names = append(names, src.Name())
+	}
+
+	r.Source = strings.Join(names, ", ")
+	r.StartedAt = dbr.NewNullTime(time.Now())
+
+	err = m.importFrom(ctx, srcs, &r)
+
+	r.FinishedAt = dbr.NewNullTime(time.Now())
+	if err != nil {
+		r.Error = err.Error()
+	}
 
-		// pairing values: key -> value
-		// NOTE: returning if some value fails to be parsed
-		for i := 0; i < slen; i += 2 {
-			k, v := s[i], s[i+1]
+	// NOTE: failing to record the run doesn't fail the import itself
+	if rerr := m.recordImportRun(ctx, &r); rerr != nil {
+		m.Logger().Warn("failed to record import run", zap.String("source", r.Source), zap.Error(rerr))
+	}
 
-			fval, err := strconv.ParseFloat(v, 64)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to parse value: %s", v)
+	return r, err
+}
+
+func (m *Manager) importFrom(ctx context.Context, srcs []FeedSource, r *ImportReport) (err error) {
+	// NOTE: conditional fetching is only used for a single source, because
+	// reconciliation requires the values of every source
+	conditional := len(srcs) == 1
+
+	batches := make([][]Snapshot, 0, len(srcs))
+	states := make([]FeedState, 0, len(srcs))
+	rejected := make([]Rejection, 0)
+	parseFailures := 0
+
+	// the last failure of a source, if any
+	var failure error
+
+	for _, src := range srcs {
+		// fetching and parsing the feed
+		m.Logger().Debug("fetching currency feed", zap.String("source", src.Name()))
+
+		ss, state, err := m.fetch(ctx, src, conditional)
+		switch err {
+		case nil:
+		case ErrNotModified:
+			m.Logger().Debug("currency feed is not modified; skipping", zap.String("source", src.Name()))
+			r.Skipped = true
+
+			return m.saveFeedState(ctx, state)
+		default:
+			// a failed source doesn't stop the others, unless it's the only one
+			if len(srcs) == 1 || m.strict {
+				return err
 			}
 
-			cs[k] = fval
+			m.Logger().Warn("currency feed has failed; continuing with other sources", zap.String("source", src.Name()), zap.Error(err))
+			r.Error = err.Error()
+			failure = err
+
+			continue
 		}
 
-		return cs, nil
+		// collecting rejected payloads and validating the feed before storing anything
+		for i := range ss {
+			snapshot := &ss[i]
+
+			r.Seen += len(snapshot.Currencies) + len(snapshot.Rejected)
+			parseFailures += len(snapshot.Rejected)
+
+			for _, rj := range snapshot.Rejected {
+				rj.Source = src.Name()
+				rejected = append(rejected, rj)
+			}
+
+			// rejecting invalid items
+			rejected = append(rejected, m.validateSnapshot(snapshot, src.Name())...)
+		}
+
+		batches = append(batches, ss)
+		states = append(states, state)
 	}
 
-	//---------------------------------------------------------------------------
-	// transforming raw payload into local currency
-	//---------------------------------------------------------------------------
-	m.Logger().Debug("parsing raw currency feed")
+	// every source has failed
+	if len(batches) == 0 {
+		return failure
+	}
 
-	for _, v := range f.Items {
-		parsedMap, err := fn(strings.Split(strings.TrimSpace(v.Description), " "))
-		if err != nil {
-			return errors.Wrap(err, "failed to parse raw currency payload")
+	r.Rejected = len(rejected)
+
+	if len(rejected) > 0 {
+		// strict mode keeps the all-or-nothing behaviour
+		if m.strict {
+			kind := ImportErrorValidation
+			if parseFailures > 0 {
+				kind = ImportErrorParse
+			}
+
+			return newImportError(kind, r.Source, errors.Errorf("%d item(s) rejected, first: %s (%s)", len(rejected), rejected[0].Payload, rejected[0].Reason))
 		}
 
-		// initialzing currency slice
-		cs := make([]Currency, 0, len(parsedMap))
+		if err = m.quarantine(ctx, rejected); err != nil {
+			return err
+		}
+	}
+
+	// choosing a single value per currency and date
+	ss, ds := reconcile(m.reconcile, batches)
 
-		// initializing currency objects
-		for id, value := range parsedMap {
-			cs = append(cs, Currency{
-				ID:      strings.ToUpper(id),
-				Value:   value,
-				PubDate: dbr.NewNullTime(v.PublishedParsed.Local()),
-			})
+	if r.Discrepancies = len(ds); r.Discrepancies > 0 {
+		if err = m.recordDiscrepancies(ctx, ds); err != nil {
+			return err
+		}
+	}
+
+	for _, snapshot := range ss {
+		if len(snapshot.Currencies) == 0 {
+			continue
 		}
 
 		// creating objects in bulk
-		if _, err = m.BulkCreate(ctx, cs); err != nil {
-			return errors.Wrapf(err, "failed to import currency for date: %s", v.PublishedParsed.Local().Format("02012006"))
+		_, stats, err := m.BulkCreate(ctx, snapshot.Currencies)
+		if err != nil {
+			return errors.Wrapf(err, "failed to import currency for date: %s", snapshot.PubDate.Format("02012006"))
+		}
+
+		r.Snapshots++
+		r.Inserted += stats.Inserted
+		r.Updated += stats.Updated
+		r.Unchanged += stats.Unchanged
+	}
+
+	// NOTE: the state is saved only after everything is stored,
+	// otherwise a failed import would be skipped next time
+	for _, state := range states {
+		if err = m.saveFeedState(ctx, state); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// validateSnapshot keeps only valid currencies of a snapshot, rounded and
+// attributed to a given source, and returns rejections of the others
+func (m *Manager) validateSnapshot(s *Snapshot, source string) (rejected []Rejection) {
+	valid := make([]Currency, 0, len(s.Currencies))
+
+	for _, c := range s.Currencies {
+		// NOTE: rounding before validation, so that reconciliation
+		// compares values the same way they're going to be stored
+		c.Value = m.rounding.Round(c.Value)
+
+		if err := c.Validate(); err != nil {
+			rj := newRejection(s.PubDate, fmt.Sprintf("%s %s", c.ID, c.Value), err)
+			rj.Source = source
+			rejected = append(rejected, rj)
+
+			continue
+		}
+
+		c.ID = strings.ToUpper(strings.TrimSpace(c.ID))
+		c.Source = source
+		valid = append(valid, c)
+	}
+
+	s.Currencies = valid
+
+	return rejected
+}
+
+// quarantine stores rejected payloads if the store supports it,
+// otherwise rejections are only logged
+// NOTE: each rejection is expected to have its source assigned
+func (m *Manager) quarantine(ctx context.Context, rs []Rejection) (err error) {
+	for i := range rs {
+		rs[i].CreatedAt = dbr.NewNullTime(time.Now())
+
+		m.Logger().Warn(
+			"currency payload rejected",
+			zap.String("source", rs[i].Source),
+			zap.String("payload", rs[i].Payload),
+			zap.String("reason", rs[i].Reason),
+		)
+	}
+
+	qs, ok := m.store.(QuarantineStore)
+	if !ok {
+		return nil
+	}
+
+	if err = qs.Quarantine(ctx, rs); err != nil {
+		return errors.Wrap(err, "failed to quarantine rejected payloads")
+	}
+
+	return nil
+}
+
+// recordDiscrepancies stores disagreements between sources if the store
+// supports it, otherwise discrepancies are only logged
+func (m *Manager) recordDiscrepancies(ctx context.Context, ds []Discrepancy) (err error) {
+	for i := range ds {
+		ds[i].CreatedAt = dbr.NewNullTime(time.Now())
+
+		m.Logger().Warn(
+			"currency sources disagree",
+			zap.String("currency", ds[i].CurrencyID),
+			zap.String("date", ds[i].PubDate.Time.Format(dateLayout)),
+			zap.String("source", ds[i].Source),
+			zap.Stringer("value", ds[i].Value),
+			zap.String("chosen_source", ds[i].ChosenSource),
+			zap.Stringer("chosen_value", ds[i].ChosenValue),
+		)
+	}
+
+	dstore, ok := m.store.(DiscrepancyStore)
+	if !ok {
+		return nil
+	}
+
+	if err = dstore.CreateDiscrepancies(ctx, ds); err != nil {
+		return errors.Wrap(err, "failed to record discrepancies")
+	}
+
+	return nil
+}
+
+// Discrepancies returns the most recent disagreements between sources, latest first
+func (m *Manager) Discrepancies(ctx context.Context, limit int) (ds []Discrepancy, err error) {
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	dstore, ok := m.store.(DiscrepancyStore)
+	if !ok {
+		return nil, errors.Wrap(ErrUnsupportedByStore, "discrepancy log")
+	}
+
+	if ds, err = dstore.Discrepancies(ctx, limit); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch discrepancies from the store")
+	}
+
+	return ds, nil
+}
+
+// recordImportRun stores the import report in the audit log, if supported
+func (m *Manager) recordImportRun(ctx context.Context, r *ImportReport) (err error) {
+	irs, ok := m.store.(ImportRunStore)
+	if !ok {
+		return nil
+	}
+
+	created, err := irs.CreateImportRun(ctx, *r)
+	if err != nil {
+		return err
+	}
+
+	r.ID = created.ID
+
+	return nil
+}
+
+// ImportHistory returns the most recent import runs, latest first
+func (m *Manager) ImportHistory(ctx context.Context, limit int) (rs []ImportReport, err error) {
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	irs, ok := m.store.(ImportRunStore)
+	if !ok {
+		return nil, errors.Wrap(ErrUnsupportedByStore, "import audit log")
+	}
+
+	if rs, err = irs.ImportRuns(ctx, limit); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch import runs from the store")
+	}
+
+	return rs, nil
+}
+
+// fetch fetches the source, retrying transient failures with exponential
+// backoff; conditional fetching is used if requested and both source and store support it
+func (m *Manager) fetch(ctx context.Context, src FeedSource, conditional bool) (ss []Snapshot, state FeedState, err error) {
+	policy := m.retry
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+
+	// obtaining the previous state of the feed
+	if state, err = m.feedState(ctx, src.Name()); err != nil {
+		return nil, state, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		ss, state, err = m.fetchAttempt(ctx, src, state, conditional, policy.AttemptTimeout)
+		if err == nil || err == ErrNotModified {
+			return ss, state, err
+		}
+
+		ierr, ok := AsImportError(err)
+		if !ok {
+			ierr = newImportError(ImportErrorUnknown, src.Name(), err)
+		}
+
+		if !ierr.Transient() || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return nil, state, ierr
+		}
+
+		delay := policy.backoff(attempt)
+
+		m.Logger().Warn(
+			"feed fetch attempt has failed; retrying",
+			zap.String("source", src.Name()),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", delay),
+			zap.Error(ierr),
+		)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, state, ierr
+		case <-timer.C:
+		}
+	}
+}
+
+// fetchAttempt fetches the source once, within a given timeout
+func (m *Manager) fetchAttempt(ctx context.Context, src FeedSource, prev FeedState, conditional bool, timeout time.Duration) (ss []Snapshot, next FeedState, err error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if csrc, ok := src.(ConditionalFeedSource); ok && conditional && m.feedStateStore() != nil {
+		ss, next, err = csrc.FetchConditional(ctx, prev)
+	} else {
+		ss, err = src.Fetch(ctx)
+	}
+
+	// the attempt has timed out, which is a transient network failure
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, prev, newImportError(ImportErrorNetwork, src.Name(), errors.Wrap(err, "attempt timed out"))
+	}
+
+	return ss, next, err
+}
+
+// feedStateStore returns the store as FeedStateStore if it's supported
+func (m *Manager) feedStateStore() FeedStateStore {
+	fss, _ := m.store.(FeedStateStore)
+	return fss
+}
+
+// feedState returns the previous state of a given source, or
+// an empty state if there is none (or it's not supported)
+func (m *Manager) feedState(ctx context.Context, source string) (s FeedState, err error) {
+	fss := m.feedStateStore()
+	if fss == nil {
+		return s, nil
+	}
+
+	switch s, err = fss.FeedState(ctx, source); err {
+	case nil:
+		return s, nil
+	case ErrFeedStateNotFound:
+		return FeedState{Source: source}, nil
+	default:
+		return s, errors.Wrapf(err, "failed to obtain feed state [%s]", source)
+	}
+}
+
+// saveFeedState stores the state of a given source, if supported
+func (m *Manager) saveFeedState(ctx context.Context, s FeedState) (err error) {
+	fss := m.feedStateStore()
+	if fss == nil || s.Source == "" {
+		return nil
+	}
+
+	if err = fss.SaveFeedState(ctx, s); err != nil {
+		return errors.Wrapf(err, "failed to save feed state [%s]", s.Source)
+	}
+
+	return nil
+}
+
 // BulkCreate creates currency values grouped by its publication date
-func (m *Manager) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, err error) {
+func (m *Manager) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, stats UpsertStats, err error) {
 	// obtaining store
 	store, err := m.Store()
 	if err != nil {
-		return nil, err
+		return nil, stats, err
 	}
 
 	// validating and initializing new records
-	for _, c := range cs {
+	for i := range cs {
+		c := &cs[i]
+		c.Value = m.rounding.Round(c.Value)
+
 		if err = c.Validate(); err != nil {
-			return nil, err
+			return nil, stats, errors.Wrapf(err, "invalid currency [%s] for date: %s", c.ID, c.PubDate.Time.Format(dateLayout))
 		}
 
 		c.ID = strings.ToUpper(strings.TrimSpace(c.ID))
@@ -180,15 +712,17 @@ func (m *Manager) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency,
 	}
 
 	// storing items
-	cs, err = store.BulkCreate(ctx, cs)
+	cs, stats, err = store.BulkCreate(ctx, cs)
 	if err != nil {
-		return cs, err
+		return cs, stats, err
 	}
 
-	return cs, nil
+	return cs, stats, nil
 }
 
-func (m *Manager) GetLatest(ctx context.Context) (cs []Currency, err error) {
+// GetLatest returns the latest known currency values relative
+// to a given base currency (empty base stands for EUR)
+func (m *Manager) GetLatest(ctx context.Context, base string) (cs []Currency, err error) {
 	if m == nil {
 		return nil, ErrNilManager
 	}
@@ -207,10 +741,33 @@ func (m *Manager) GetLatest(ctx context.Context) (cs []Currency, err error) {
 
 	// NOTE: not caching anything
 
-	return cs, nil
+	if base = normalizeBase(base); base == BaseCurrency {
+		return cs, nil
+	}
+
+	// base value must be published on the same date
+	var rate Currency
+	for _, c := range cs {
+		if c.ID == base {
+			rate = c
+			break
+		}
+	}
+
+	if rate.ID == "" {
+		return nil, errors.Wrapf(ErrCurrencyNotFound, "base currency: %s", base)
+	}
+
+	// NOTE: EUR itself is not stored, thus adding it explicitly
+	cs = append(cs, Currency{ID: BaseCurrency, Value: decimal.New(1, 0), PubDate: rate.PubDate})
+
+	return rebase(cs, m.conversion, func(Currency) (decimal.Decimal, bool) { return rate.Value, true }), nil
 }
 
-func (m *Manager) GetAllByID(ctx context.Context, id string) (cs []Currency, err error) {
+// GetAllByID returns the history of a given currency relative to a given
+// base currency (empty base stands for EUR), dates on which the base
+// currency is unknown are omitted
+func (m *Manager) GetAllByID(ctx context.Context, id string, base string) (cs []Currency, err error) {
 	if m == nil {
 		return nil, ErrNilManager
 	}
@@ -223,13 +780,51 @@ func (m *Manager) GetAllByID(ctx context.Context, id string) (cs []Currency, err
 	// preparing id value
 	id = strings.ToUpper(strings.TrimSpace(id))
 
-	cs, err = store.AllByID(ctx, id)
+	if base = normalizeBase(base); base == BaseCurrency {
+		cs, err = store.AllByID(ctx, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch currency history for ID: %s", id)
+		}
+
+		// handling 404 case, even though this function
+		// returns multiple values
+		if len(cs) == 0 {
+			return nil, ErrCurrencyNotFound
+		}
+
+		return cs, nil
+	}
+
+	// obtaining the history of the base currency
+	bs, err := store.AllByID(ctx, base)
 	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch currency history for ID: %s", base)
+	}
+
+	if len(bs) == 0 {
+		return nil, errors.Wrapf(ErrCurrencyNotFound, "base currency: %s", base)
+	}
+
+	rates := make(map[string]decimal.Decimal, len(bs))
+	for _, b := range bs {
+		rates[b.PubDate.Time.Format(dateLayout)] = b.Value
+	}
+
+	// NOTE: EUR itself is not stored, thus its history is derived from the base one
+	if id == BaseCurrency {
+		cs = make([]Currency, 0, len(bs))
+		for _, b := range bs {
+			cs = append(cs, Currency{ID: BaseCurrency, Value: decimal.New(1, 0), PubDate: b.PubDate})
+		}
+	} else if cs, err = store.AllByID(ctx, id); err != nil {
 		return nil, errors.Wrapf(err, "failed to fetch currency history for ID: %s", id)
 	}
 
-	// handling 404 case, even though this function
-	// returns multiple values
+	cs = rebase(cs, m.conversion, func(c Currency) (decimal.Decimal, bool) {
+		rate, ok := rates[c.PubDate.Time.Format(dateLayout)]
+		return rate, ok
+	})
+
 	if len(cs) == 0 {
 		return nil, ErrCurrencyNotFound
 	}
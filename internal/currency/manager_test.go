@@ -0,0 +1,142 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticSource is a feed source which yields predefined snapshots
+type staticSource struct {
+	ss  []currency.Snapshot
+	err error
+}
+
+func (s *staticSource) Name() string {
+	return "static"
+}
+
+func (s *staticSource) Fetch(ctx context.Context) ([]currency.Snapshot, error) {
+	return s.ss, s.err
+}
+
+func newSnapshot(pubDate time.Time, values map[string]float64) currency.Snapshot {
+	s := currency.Snapshot{PubDate: pubDate}
+
+	for id, v := range values {
+		s.Currencies = append(s.Currencies, currency.Currency{
+			ID:      id,
+			Value:   decimal.NewFromFloat(v),
+			PubDate: dbr.NewNullTime(pubDate),
+		})
+	}
+
+	return s
+}
+
+func TestManager_Import(t *testing.T) {
+	a := assert.New(t)
+
+	today := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(today, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+			newSnapshot(today.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934, "JPY": 117.67}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+	a.NotNil(m)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	cs, err := m.GetLatest(context.Background(), "")
+	a.NoError(err)
+	a.Len(cs, 2)
+
+	cs, err = m.GetAllByID(context.Background(), "usd", "")
+	a.NoError(err)
+	a.Len(cs, 2)
+
+	// failing source
+	src.err = errors.New("source is down")
+	_, err = m.Import(context.Background())
+	a.Error(err)
+}
+
+func TestManager_ImportWithoutSource(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore())
+	a.NoError(err)
+	a.NotNil(m)
+
+	_, err = m.Import(context.Background())
+	a.Equal(currency.ErrNilFeedSource, err)
+
+	// invalid options
+	m, err = currency.NewManager(currency.NewMemoryStore(), currency.WithFeedURL(""))
+	a.Error(err)
+	a.Nil(m)
+
+	m, err = currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(nil))
+	a.Error(err)
+	a.Nil(m)
+}
+
+func TestManager_ImportQuarantine(t *testing.T) {
+	a := assert.New(t)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	snapshot := newSnapshot(pubDate, map[string]float64{"USD": 1.0801, "JPY": 0})
+	snapshot.Rejected = []currency.Rejection{
+		{PubDate: dbr.NewNullTime(pubDate), Payload: "GBP abc", Reason: "failed to parse value: abc"},
+	}
+
+	src := &staticSource{ss: []currency.Snapshot{snapshot}}
+	store := currency.NewMemoryStore()
+
+	m, err := currency.NewManager(store, currency.WithFeedSource(src))
+	a.NoError(err)
+
+	// good rows are stored regardless of rejected ones
+	r, err := m.Import(context.Background())
+	a.NoError(err)
+	a.Equal(3, r.Seen)
+	a.Equal(1, r.Inserted)
+	a.Equal(2, r.Rejected)
+
+	cs, err := store.AllLatest(context.Background())
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal("USD", cs[0].ID)
+
+	rs, err := store.(currency.QuarantineStore).Rejections(context.Background(), 0)
+	a.NoError(err)
+	a.Len(rs, 2)
+
+	for _, rj := range rs {
+		a.Equal("static", rj.Source)
+		a.NotEmpty(rj.Reason)
+		a.True(rj.CreatedAt.Valid)
+	}
+
+	// strict mode rejects the whole feed
+	m, err = currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src), currency.WithStrictImport(true))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	ierr, ok := currency.AsImportError(err)
+	a.True(ok)
+	a.Equal(currency.ImportErrorParse, ierr.Kind)
+}
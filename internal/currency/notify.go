@@ -0,0 +1,160 @@
+package currency
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// DefaultWebhookTimeout is the default timeout of webhook deliveries
+const DefaultWebhookTimeout = 10 * time.Second
+
+// logNotifier writes alerts to the log
+type logNotifier struct {
+	logger *zap.Logger
+}
+
+// NewLogNotifier initializes a notifier which writes alerts to a given log
+func NewLogNotifier(logger *zap.Logger) Notifier {
+	return &logNotifier{logger: logger}
+}
+
+func (n *logNotifier) Name() string {
+	return "log"
+}
+
+func (n *logNotifier) Notify(ctx context.Context, a Alert) (err error) {
+	n.logger.Warn(
+		"currency alert",
+		zap.Int64("rule_id", a.RuleID),
+		zap.String("rule", a.RuleName),
+		zap.String("currency", a.CurrencyID),
+		zap.String("message", a.Message),
+	)
+
+	return nil
+}
+
+// webhookNotifier posts alerts as JSON to a URL
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier initializes a notifier which posts alerts as JSON
+// to a given URL, any response other than 2xx is a failure
+func NewWebhookNotifier(url string) (Notifier, error) {
+	url = strings.TrimSpace(url)
+	if !isRemote(url) {
+		return nil, errors.Errorf("invalid webhook url: %s", url)
+	}
+
+	n := &webhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: DefaultWebhookTimeout},
+	}
+
+	return n, nil
+}
+
+func (n *webhookNotifier) Name() string {
+	return n.url
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, a Alert) (err error) {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal alert")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize webhook request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to deliver webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
+
+// smtpNotifier sends alerts by email
+type smtpNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewSMTPNotifier initializes a notifier which sends alerts by email through
+// an SMTP server at a given address (host:port), authenticating with PLAIN
+// auth if the username is set
+func NewSMTPNotifier(addr, username, password, from string, to []string) (Notifier, error) {
+	host := strings.Split(addr, ":")[0]
+	if host == "" {
+		return nil, errors.Errorf("invalid smtp address: %s", addr)
+	}
+
+	if from == "" || len(to) == 0 {
+		return nil, errors.New("smtp sender and recipients must be set")
+	}
+
+	for _, email := range append([]string{from}, to...) {
+		if strings.ContainsAny(email, "\r\n") {
+			return nil, errors.Errorf("invalid email address: %q", email)
+		}
+	}
+
+	n := &smtpNotifier{
+		addr: addr,
+		from: from,
+		to:   to,
+	}
+
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return n, nil
+}
+
+func (n *smtpNotifier) Name() string {
+	return "smtp://" + n.addr
+}
+
+func (n *smtpNotifier) Notify(ctx context.Context, a Alert) (err error) {
+	msg := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n\r\nrule: %s (#%d)\r\nfired at: %s\r\n",
+		n.from,
+		strings.Join(n.to, ", "),
+		mime.QEncoding.Encode("utf-8", "[tetest] "+a.RuleName),
+		a.Message,
+		a.RuleName,
+		a.RuleID,
+		a.FiredAt.Time.Format(time.RFC3339),
+	)
+
+	// NOTE: net/smtp doesn't support contexts
+	if err = smtp.SendMail(n.addr, n.auth, n.from, n.to, []byte(msg)); err != nil {
+		return errors.Wrap(err, "failed to send alert email")
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package currency
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+// Rejection represents a raw payload which has failed to be parsed or
+// validated during the import, and thus is put into quarantine
+type Rejection struct {
+	ID        int64        `db:"id" json:"id"`
+	Source    string       `db:"source" json:"source"`
+	PubDate   dbr.NullTime `db:"pub_date" json:"pub_date"`
+	Payload   string       `db:"payload" json:"payload"`
+	Reason    string       `db:"reason" json:"reason"`
+	CreatedAt dbr.NullTime `db:"created_at" json:"created_at"`
+}
+
+// newRejection initializes a rejection of a raw payload,
+// zero publication date means that it is unknown
+func newRejection(pubDate time.Time, payload string, reason error) Rejection {
+	r := Rejection{
+		Payload: payload,
+		Reason:  reason.Error(),
+	}
+
+	if !pubDate.IsZero() {
+		r.PubDate = dbr.NewNullTime(pubDate)
+	}
+
+	return r
+}
+
+// QuarantineStore is implemented by stores which are able to keep
+// rejected payloads; otherwise rejections are only logged
+type QuarantineStore interface {
+	Quarantine(ctx context.Context, rs []Rejection) (err error)
+	Rejections(ctx context.Context, limit int) (rs []Rejection, err error)
+}
@@ -0,0 +1,37 @@
+package currency
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// normalizeBase returns the ID of a given base currency,
+// where empty base stands for the default one (EUR)
+func normalizeBase(base string) string {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	if base == "" {
+		return BaseCurrency
+	}
+
+	return base
+}
+
+// rebase recomputes EUR based values against another base currency, where
+// rateOf returns the value of the new base currency for a given item;
+// items without a known base value are omitted
+func rebase(cs []Currency, r Rounding, rateOf func(c Currency) (decimal.Decimal, bool)) []Currency {
+	rebased := make([]Currency, 0, len(cs))
+
+	for _, c := range cs {
+		rate, ok := rateOf(c)
+		if !ok || rate.IsZero() {
+			continue
+		}
+
+		c.Value = r.Round(c.Value.DivRound(rate, divisionPlaces))
+		rebased = append(rebased, c)
+	}
+
+	return rebased
+}
@@ -0,0 +1,84 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Rebase(t *testing.T) {
+	a := assert.New(t)
+
+	today := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(today, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+			newSnapshot(today.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934, "JPY": 117.67}),
+			newSnapshot(today.AddDate(0, 0, -2), map[string]float64{"JPY": 116.80}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	// latest values, EUR is explicitly included
+	cs, err := m.GetLatest(context.Background(), "usd")
+	a.NoError(err)
+	a.Len(cs, 3)
+
+	for _, c := range cs {
+		a.Equal(today, c.PubDate.Time)
+
+		switch c.ID {
+		case "USD":
+			a.Equal("1", c.Value.String())
+		case "EUR":
+			a.Equal("0.92584", c.Value.String())
+		case "JPY":
+			a.Equal("109.38802", c.Value.String())
+		}
+	}
+
+	// default and explicit EUR base are the same as stored
+	cs, err = m.GetLatest(context.Background(), "EUR")
+	a.NoError(err)
+	a.Len(cs, 2)
+
+	// history, the date without the base value is omitted
+	cs, err = m.GetAllByID(context.Background(), "JPY", "USD")
+	a.NoError(err)
+	a.Len(cs, 2)
+
+	for _, c := range cs {
+		a.True(c.PubDate.Time.After(today.AddDate(0, 0, -2)))
+	}
+
+	// history of EUR itself
+	cs, err = m.GetAllByID(context.Background(), "EUR", "JPY")
+	a.NoError(err)
+	a.Len(cs, 3)
+
+	// history of the base currency itself
+	cs, err = m.GetAllByID(context.Background(), "USD", "USD")
+	a.NoError(err)
+	a.Len(cs, 2)
+
+	for _, c := range cs {
+		a.Equal("1", c.Value.String())
+	}
+
+	// unknown base currency
+	_, err = m.GetLatest(context.Background(), "XXX")
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+
+	_, err = m.GetAllByID(context.Background(), "JPY", "XXX")
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+}
@@ -0,0 +1,186 @@
+package currency
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultReconcileTolerance is the relative difference between values
+// of the same currency, beyond which sources are considered to disagree
+const DefaultReconcileTolerance = 0.0005
+
+// ReconcileStrategy decides which value is published when sources disagree
+type ReconcileStrategy string
+
+// reconciliation strategies
+const (
+	// ReconcilePriority always publishes the value of the source
+	// with the highest priority (i.e. the first configured one)
+	ReconcilePriority ReconcileStrategy = "priority"
+
+	// ReconcileMedian publishes the median value when sources disagree,
+	// otherwise the value of the source with the highest priority
+	// NOTE: for an even number of values the one of the higher priority
+	// source is chosen among two middle values
+	ReconcileMedian ReconcileStrategy = "median"
+)
+
+// ReconcilePolicy represents the rules of reconciliation of values
+// obtained from multiple sources, where the source priority is defined
+// by the order of sources (the first one is the most trusted)
+type ReconcilePolicy struct {
+	Strategy ReconcileStrategy
+
+	// Tolerance is the maximum relative difference between values
+	// which is not considered a discrepancy (i.e. 0.001 = 0.1%)
+	Tolerance float64
+}
+
+// DefaultReconcilePolicy prefers sources by priority
+var DefaultReconcilePolicy = ReconcilePolicy{
+	Strategy:  ReconcilePriority,
+	Tolerance: DefaultReconcileTolerance,
+}
+
+// Discrepancy represents a disagreement between the published value
+// and the value of some other source, beyond the tolerance
+type Discrepancy struct {
+	ID           int64           `db:"id" json:"id"`
+	CurrencyID   string          `db:"currency_id" json:"currency_id"`
+	PubDate      dbr.NullTime    `db:"pub_date" json:"pub_date"`
+	Source       string          `db:"source" json:"source"`
+	Value        decimal.Decimal `db:"value" json:"value"`
+	ChosenSource string          `db:"chosen_source" json:"chosen_source"`
+	ChosenValue  decimal.Decimal `db:"chosen_value" json:"chosen_value"`
+	Deviation    float64         `db:"deviation" json:"deviation"`
+	CreatedAt    dbr.NullTime    `db:"created_at" json:"created_at"`
+}
+
+// DiscrepancyStore is implemented by stores which are able
+// to keep the log of disagreements between sources
+type DiscrepancyStore interface {
+	CreateDiscrepancies(ctx context.Context, ds []Discrepancy) (err error)
+	Discrepancies(ctx context.Context, limit int) (ds []Discrepancy, err error)
+}
+
+// deviation returns the relative difference of a value from the reference one
+// NOTE: it's only a metric, thus returned as a float
+func deviation(value, reference decimal.Decimal) float64 {
+	if reference.IsZero() {
+		return math.Inf(1)
+	}
+
+	return value.Sub(reference).Abs().DivRound(reference.Abs(), divisionPlaces).InexactFloat64()
+}
+
+// reconcile merges snapshots of multiple sources (given in the order
+// of priority) into a single set of snapshots, choosing one value per
+// currency and date, and reporting disagreements beyond the tolerance
+// NOTE: each currency is expected to have its source assigned
+func reconcile(policy ReconcilePolicy, batches [][]Snapshot) (ss []Snapshot, ds []Discrepancy) {
+	type key struct {
+		date string
+		id   string
+	}
+
+	// grouping candidates by date and currency, preserving the priority order
+	candidates := make(map[key][]Currency)
+	dates := make(map[string]Snapshot)
+	keys := make([]key, 0)
+
+	for _, batch := range batches {
+		for _, s := range batch {
+			for _, c := range s.Currencies {
+				k := key{date: c.PubDate.Time.Format(dateLayout), id: c.ID}
+
+				if _, ok := candidates[k]; !ok {
+					keys = append(keys, k)
+				}
+
+				if _, ok := dates[k.date]; !ok {
+					dates[k.date] = Snapshot{PubDate: s.PubDate}
+				}
+
+				candidates[k] = append(candidates[k], c)
+			}
+		}
+	}
+
+	ds = make([]Discrepancy, 0)
+
+	for _, k := range keys {
+		cs := candidates[k]
+		chosen := cs[0]
+
+		// checking whether any source disagrees with the most trusted one
+		disagree := false
+		for _, c := range cs[1:] {
+			if deviation(c.Value, chosen.Value) > policy.Tolerance {
+				disagree = true
+				break
+			}
+		}
+
+		if disagree && policy.Strategy == ReconcileMedian {
+			chosen = median(cs)
+		}
+
+		for _, c := range cs {
+			if c.Source == chosen.Source && c.Value.Equal(chosen.Value) {
+				continue
+			}
+
+			if dev := deviation(c.Value, chosen.Value); dev > policy.Tolerance {
+				ds = append(ds, Discrepancy{
+					CurrencyID:   chosen.ID,
+					PubDate:      chosen.PubDate,
+					Source:       c.Source,
+					Value:        c.Value,
+					ChosenSource: chosen.Source,
+					ChosenValue:  chosen.Value,
+					Deviation:    dev,
+				})
+			}
+		}
+
+		s := dates[k.date]
+		s.Currencies = append(s.Currencies, chosen)
+		dates[k.date] = s
+	}
+
+	// ordering snapshots by date
+	ss = make([]Snapshot, 0, len(dates))
+	for _, s := range dates {
+		ss = append(ss, s)
+	}
+
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].PubDate.Before(ss[j].PubDate)
+	})
+
+	return ss, ds
+}
+
+// median returns the candidate with the median value, candidates
+// are expected to be given in the order of priority
+func median(cs []Currency) Currency {
+	sorted := make([]int, len(cs))
+	for i := range sorted {
+		sorted[i] = i
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return cs[sorted[i]].Value.LessThan(cs[sorted[j]].Value)
+	})
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 && sorted[mid-1] < sorted[mid] {
+		mid--
+	}
+
+	return cs[sorted[mid]]
+}
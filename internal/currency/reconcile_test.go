@@ -0,0 +1,136 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// namedSource is a static source with a custom name
+type namedSource struct {
+	staticSource
+	name string
+}
+
+func (s *namedSource) Name() string {
+	return s.name
+}
+
+func TestManager_ImportReconcile(t *testing.T) {
+	a := assert.New(t)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	override := &namedSource{name: "override", staticSource: staticSource{ss: []currency.Snapshot{
+		newSnapshot(pubDate, map[string]float64{"JPY": 118.00}),
+	}}}
+
+	primary := &namedSource{name: "primary", staticSource: staticSource{ss: []currency.Snapshot{
+		newSnapshot(pubDate, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+	}}}
+
+	secondary := &namedSource{name: "secondary", staticSource: staticSource{ss: []currency.Snapshot{
+		newSnapshot(pubDate, map[string]float64{"USD": 1.0802, "JPY": 118.15, "GBP": 0.9241}),
+		newSnapshot(pubDate.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934}),
+	}}}
+
+	store := currency.NewMemoryStore()
+
+	m, err := currency.NewManager(
+		store,
+		currency.WithFeedSources(override, primary, secondary),
+		currency.WithReconcilePolicy(currency.ReconcilePolicy{Strategy: currency.ReconcilePriority, Tolerance: 0.001}),
+	)
+	a.NoError(err)
+
+	r, err := m.Import(context.Background())
+	a.NoError(err)
+	a.Equal("override, primary, secondary", r.Source)
+	a.Equal(7, r.Seen)
+	a.Equal(4, r.Inserted)
+	a.Equal(2, r.Snapshots)
+	a.Equal(2, r.Discrepancies)
+
+	// values are published by the highest priority
+	latest := make(map[string]currency.Currency)
+
+	cs, err := store.AllLatest(context.Background())
+	a.NoError(err)
+
+	for _, c := range cs {
+		latest[c.ID] = c
+	}
+
+	a.Len(latest, 3)
+	a.Equal("118", latest["JPY"].Value.String())
+	a.Equal("override", latest["JPY"].Source)
+	a.Equal("1.0801", latest["USD"].Value.String())
+	a.Equal("primary", latest["USD"].Source)
+	a.Equal("secondary", latest["GBP"].Source)
+
+	// USD is within the tolerance, JPY of both other sources is not
+	ds, err := m.Discrepancies(context.Background(), 0)
+	a.NoError(err)
+	a.Len(ds, 2)
+
+	for _, d := range ds {
+		a.Equal("JPY", d.CurrencyID)
+		a.Equal("override", d.ChosenSource)
+		a.Equal("118", d.ChosenValue.String())
+		a.Equal("118.15", d.Value.String())
+		a.True(d.CreatedAt.Valid)
+	}
+
+	// a failing source doesn't stop the others
+	secondary.err = errors.New("source is down")
+
+	r, err = m.Import(context.Background())
+	a.NoError(err)
+	a.Equal(2, r.Unchanged)
+	a.Contains(r.Error, "source is down")
+
+	// unless all of them fail
+	override.err, primary.err = errors.New("source is down"), errors.New("source is down")
+
+	_, err = m.Import(context.Background())
+	a.Error(err)
+}
+
+func TestManager_ImportReconcileMedian(t *testing.T) {
+	a := assert.New(t)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	srcs := []currency.FeedSource{
+		&namedSource{name: "a", staticSource: staticSource{ss: []currency.Snapshot{newSnapshot(pubDate, map[string]float64{"USD": 1.20})}}},
+		&namedSource{name: "b", staticSource: staticSource{ss: []currency.Snapshot{newSnapshot(pubDate, map[string]float64{"USD": 1.08})}}},
+		&namedSource{name: "c", staticSource: staticSource{ss: []currency.Snapshot{newSnapshot(pubDate, map[string]float64{"USD": 1.07})}}},
+	}
+
+	store := currency.NewMemoryStore()
+
+	m, err := currency.NewManager(
+		store,
+		currency.WithFeedSources(srcs...),
+		currency.WithReconcilePolicy(currency.ReconcilePolicy{Strategy: currency.ReconcileMedian, Tolerance: 0.01}),
+	)
+	a.NoError(err)
+
+	r, err := m.Import(context.Background())
+	a.NoError(err)
+	a.Equal(1, r.Discrepancies)
+
+	cs, err := store.AllLatest(context.Background())
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal("1.08", cs[0].Value.String())
+	a.Equal("b", cs[0].Source)
+
+	// invalid policy
+	_, err = currency.NewManager(store, currency.WithReconcilePolicy(currency.ReconcilePolicy{Strategy: "average"}))
+	a.Error(err)
+}
@@ -0,0 +1,43 @@
+package currency
+
+import (
+	"time"
+)
+
+// RetryPolicy configures retries of transient import failures
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts (including the first one)
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry, which
+	// is doubled on each subsequent retry up until MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// AttemptTimeout limits each attempt, within the deadline
+	// of the context passed to the import (zero means no limit)
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy is used unless the manager is configured otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 1 * time.Second,
+	MaxBackoff:     30 * time.Second,
+	AttemptTimeout: 30 * time.Second,
+}
+
+// backoff returns the delay before a given retry (starting from 1)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+
+	for i := 1; i < retry; i++ {
+		d *= 2
+
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,156 @@
+package currency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+)
+
+// Snapshot represents a set of currency values published on a single date,
+// along with the raw payloads which have failed to be parsed
+// NOTE: publication date is zero if it's unknown (i.e. failed to be parsed)
+type Snapshot struct {
+	PubDate    time.Time
+	Currencies []Currency
+	Rejected   []Rejection
+}
+
+// FeedSource represents a provider of currency snapshots, i.e. a remote
+// feed or any other external source the manager imports from
+type FeedSource interface {
+	// Name returns a human readable source name (mostly for logging)
+	Name() string
+
+	// Fetch obtains and parses the latest payload of this source
+	Fetch(ctx context.Context) (ss []Snapshot, err error)
+}
+
+// NewSource initializes a source of a given format at a given location,
+// which is either a remote feed (for rss and ecbxml formats) or a local file
+func NewSource(format string, location string) (FeedSource, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	location = strings.TrimSpace(location)
+
+	if isRemote(location) {
+		switch format {
+		case FormatRSS:
+			return NewRSSSource(location)
+		case FormatECBXML:
+			return NewECBSource(location)
+		default:
+			return nil, errors.Wrapf(ErrUnsupportedFormat, "remote format: %s", format)
+		}
+	}
+
+	return NewFileSource(location, format)
+}
+
+// isRemote tells whether a location is a remote (http/https) resource
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// fetchURL performs a GET request and returns the response body
+// NOTE: the caller is responsible for closing the returned body
+func fetchURL(ctx context.Context, client *http.Client, addr string) (io.ReadCloser, error) {
+	resp, err := doRequest(ctx, client, addr, FeedState{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// fetchConditional performs a conditional GET request based on a previous
+// feed state and returns ErrNotModified if either the server responds
+// with 304, or the payload has the same content hash as before
+func fetchConditional(ctx context.Context, client *http.Client, addr string, prev FeedState) (payload []byte, next FeedState, err error) {
+	next = prev
+	next.Source = addr
+	next.CheckedAt = dbr.NewNullTime(time.Now())
+
+	resp, err := doRequest(ctx, client, addr, prev)
+	if err != nil {
+		return nil, next, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, next, ErrNotModified
+	}
+
+	if payload, err = ioutil.ReadAll(resp.Body); err != nil {
+		return nil, next, newImportError(ImportErrorNetwork, addr, errors.Wrap(err, "failed to read response body"))
+	}
+
+	// remembering validators for the next request
+	next.ETag = resp.Header.Get("ETag")
+	next.LastModified = resp.Header.Get("Last-Modified")
+
+	// comparing content hash, because not every server supports validators
+	hash := sha256.Sum256(payload)
+	next.ContentHash = hex.EncodeToString(hash[:])
+
+	if prev.ContentHash != "" && prev.ContentHash == next.ContentHash {
+		return nil, next, ErrNotModified
+	}
+
+	return payload, next, nil
+}
+
+// doRequest performs a GET request, adding conditional headers if
+// a given state has validators, both 200 and 304 are considered successful
+func doRequest(ctx context.Context, client *http.Client, addr string, state FeedState) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize feed request")
+	}
+
+	if state.ETag != "" {
+		req.Header.Set("If-None-Match", state.ETag)
+	}
+
+	if state.LastModified != "" {
+		req.Header.Set("If-Modified-Since", state.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, newImportError(ImportErrorNetwork, addr, err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		resp.Body.Close()
+
+		ierr := newImportError(ImportErrorHTTPStatus, addr, errors.New(resp.Status))
+		ierr.StatusCode = resp.StatusCode
+
+		return nil, ierr
+	}
+
+	return resp, nil
+}
+
+// openLocation opens either a remote (http/https) or a local resource
+// NOTE: the caller is responsible for closing the returned reader
+func openLocation(ctx context.Context, client *http.Client, location string) (io.ReadCloser, error) {
+	if isRemote(location) {
+		return fetchURL(ctx, client, location)
+	}
+
+	f, err := os.Open(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file [%s]", location)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,128 @@
+package currency
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+)
+
+// ecbSource reads the native ECB eurofxref XML format, which is used
+// by both daily and historical files, i.e.:
+// https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml
+// https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.xml
+type ecbSource struct {
+	feedURL string
+	client  *http.Client
+}
+
+// ecbEnvelope represents the eurofxref document structure:
+// <Cube><Cube time="..."><Cube currency="USD" rate="..."/></Cube></Cube>
+// NOTE: element namespaces are ignored on purpose
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// NewECBSource initializes a new ECB eurofxref XML feed source
+func NewECBSource(feedURL string) (FeedSource, error) {
+	feedURL = strings.TrimSpace(feedURL)
+	if feedURL == "" {
+		return nil, ErrEmptyFeedURL
+	}
+
+	s := &ecbSource{
+		feedURL: feedURL,
+		client:  http.DefaultClient,
+	}
+
+	return s, nil
+}
+
+func (s *ecbSource) Name() string {
+	return s.feedURL
+}
+
+func (s *ecbSource) Fetch(ctx context.Context) (ss []Snapshot, err error) {
+	ss, _, err = s.FetchConditional(ctx, FeedState{})
+
+	return ss, err
+}
+
+func (s *ecbSource) FetchConditional(ctx context.Context, prev FeedState) (ss []Snapshot, next FeedState, err error) {
+	payload, next, err := fetchConditional(ctx, s.client, s.feedURL, prev)
+	if err != nil {
+		return nil, next, err
+	}
+
+	if ss, err = parseECBXML(bytes.NewReader(payload)); err != nil {
+		return nil, next, newImportError(ImportErrorParse, s.Name(), err)
+	}
+
+	return ss, next, nil
+}
+
+// parseECBXML transforms raw eurofxref XML payload into currency snapshots
+// NOTE: days with malformed dates and malformed rates are rejected individually
+func parseECBXML(r io.Reader) (ss []Snapshot, err error) {
+	envelope := ecbEnvelope{}
+
+	if err = xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, errors.Wrap(err, "failed to parse eurofxref payload")
+	}
+
+	ss = make([]Snapshot, 0, len(envelope.Cube.Days))
+
+	for _, day := range envelope.Cube.Days {
+		pubDate, err := time.Parse(dateLayout, strings.TrimSpace(day.Time))
+		if err != nil {
+			ss = append(ss, Snapshot{
+				Rejected: []Rejection{
+					newRejection(time.Time{}, fmt.Sprintf("time=%q", day.Time), errors.Wrapf(ErrInvalidPayloadFormat, "invalid publication date: %s", day.Time)),
+				},
+			})
+
+			continue
+		}
+
+		// initialzing currency slice
+		cs := make([]Currency, 0, len(day.Rates))
+		rejected := make([]Rejection, 0)
+
+		for _, rate := range day.Rates {
+			value, err := ParseValue(rate.Rate)
+			if err != nil {
+				rejected = append(rejected, newRejection(pubDate, fmt.Sprintf("currency=%q rate=%q", rate.Currency, rate.Rate), err))
+				continue
+			}
+
+			cs = append(cs, Currency{
+				ID:      strings.ToUpper(strings.TrimSpace(rate.Currency)),
+				Value:   value,
+				PubDate: dbr.NewNullTime(pubDate),
+			})
+		}
+
+		ss = append(ss, Snapshot{
+			PubDate:    pubDate,
+			Currencies: cs,
+			Rejected:   rejected,
+		})
+	}
+
+	return ss, nil
+}
@@ -0,0 +1,183 @@
+package currency
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+)
+
+// ecbHistorySource reads full ECB history archives from a local
+// path or a URL, the format is determined by the file extension:
+//
+//	*.zip -- zipped CSV (i.e. eurofxref-hist.zip)
+//	*.csv -- plain CSV (i.e. eurofxref-hist.csv)
+//	other -- eurofxref XML (i.e. eurofxref-hist.xml)
+type ecbHistorySource struct {
+	location string
+	client   *http.Client
+}
+
+// NewECBHistorySource initializes a new ECB history archive source
+func NewECBHistorySource(location string) (FeedSource, error) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return nil, ErrEmptyFeedURL
+	}
+
+	s := &ecbHistorySource{
+		location: location,
+		client:   http.DefaultClient,
+	}
+
+	return s, nil
+}
+
+func (s *ecbHistorySource) Name() string {
+	return s.location
+}
+
+func (s *ecbHistorySource) Fetch(ctx context.Context) (ss []Snapshot, err error) {
+	r, err := openLocation(ctx, s.client, s.location)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	// NOTE: query string is stripped off the URL to obtain the extension
+	switch strings.ToLower(path.Ext(strings.SplitN(s.location, "?", 2)[0])) {
+	case ".zip":
+		ss, err = parseECBZip(r)
+	case ".csv":
+		ss, err = parseECBCSV(r)
+	default:
+		ss, err = parseECBXML(r)
+	}
+
+	if err != nil {
+		return nil, newImportError(ImportErrorParse, s.Name(), err)
+	}
+
+	return ss, nil
+}
+
+// parseECBZip reads the first CSV file from a zip archive
+// NOTE: the whole archive is read into memory, which is fine
+// considering the size of the ECB history (a few megabytes)
+func parseECBZip(r io.Reader) (ss []Snapshot, err error) {
+	payload, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read zip archive")
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(payload), int64(len(payload)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open zip archive")
+	}
+
+	for _, f := range zr.File {
+		if strings.ToLower(path.Ext(f.Name)) != ".csv" {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open archived file [%s]", f.Name)
+		}
+
+		ss, err = parseECBCSV(rc)
+		rc.Close()
+
+		return ss, err
+	}
+
+	return nil, errors.Wrap(ErrInvalidPayloadFormat, "zip archive contains no csv file")
+}
+
+// parseECBCSV transforms ECB CSV history into currency snapshots, i.e.:
+//
+//	Date,USD,JPY,CYP,
+//	2020-03-19,1.0801,118.15,N/A,
+//
+// NOTE: "N/A" and empty values are skipped (retired or not yet introduced currencies),
+// rows with malformed dates and malformed values are rejected individually
+func parseECBCSV(r io.Reader) (ss []Snapshot, err error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read csv header")
+	}
+
+	if len(header) < 2 || !strings.EqualFold(strings.TrimSpace(header[0]), "date") {
+		return nil, errors.Wrap(ErrInvalidPayloadFormat, "unexpected csv header")
+	}
+
+	ss = make([]Snapshot, 0)
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read csv record")
+		}
+
+		pubDate, err := time.Parse(dateLayout, strings.TrimSpace(record[0]))
+		if err != nil {
+			ss = append(ss, Snapshot{
+				Rejected: []Rejection{
+					newRejection(time.Time{}, strings.Join(record, ","), errors.Wrapf(ErrInvalidPayloadFormat, "invalid publication date: %s", record[0])),
+				},
+			})
+
+			continue
+		}
+
+		// initialzing currency slice
+		cs := make([]Currency, 0, len(record)-1)
+		rejected := make([]Rejection, 0)
+
+		for i := 1; i < len(record) && i < len(header); i++ {
+			id := strings.ToUpper(strings.TrimSpace(header[i]))
+			v := strings.TrimSpace(record[i])
+
+			if id == "" || v == "" || v == "N/A" {
+				continue
+			}
+
+			value, err := ParseValue(v)
+			if err != nil {
+				rejected = append(rejected, newRejection(pubDate, id+" "+v, err))
+				continue
+			}
+
+			cs = append(cs, Currency{
+				ID:      id,
+				Value:   value,
+				PubDate: dbr.NewNullTime(pubDate),
+			})
+		}
+
+		ss = append(ss, Snapshot{
+			PubDate:    pubDate,
+			Currencies: cs,
+			Rejected:   rejected,
+		})
+	}
+
+	return ss, nil
+}
@@ -0,0 +1,85 @@
+package currency_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestECBSource_FetchDaily(t *testing.T) {
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/eurofxref-daily.xml")
+	}))
+	defer ts.Close()
+
+	src, err := currency.NewECBSource(ts.URL)
+	a.NoError(err)
+	a.NotNil(src)
+
+	ss, err := src.Fetch(context.Background())
+	a.NoError(err)
+	a.Len(ss, 1)
+	a.Equal(time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC), ss[0].PubDate)
+	a.Len(ss[0].Currencies, 6)
+	a.Equal("USD", ss[0].Currencies[0].ID)
+	a.Equal("1.0801", ss[0].Currencies[0].Value.String())
+}
+
+func TestECBSource_ImportHistory(t *testing.T) {
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/eurofxref-hist.xml")
+	}))
+	defer ts.Close()
+
+	src, err := currency.NewECBSource(ts.URL)
+	a.NoError(err)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+	a.NotNil(m)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	cs, err := m.GetAllByID(context.Background(), "GBP", "")
+	a.NoError(err)
+	a.Len(cs, 4)
+
+	cs, err = m.GetLatest(context.Background(), "")
+	a.NoError(err)
+	a.Len(cs, 3)
+
+	for _, c := range cs {
+		a.Equal(time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC), c.PubDate.Time)
+	}
+}
+
+func TestECBSource_FetchMalformed(t *testing.T) {
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<Envelope><Cube><Cube time="2020-03-19"><Cube currency="USD" rate="abc"/></Cube></Cube></Envelope>`))
+	}))
+	defer ts.Close()
+
+	src, err := currency.NewECBSource(ts.URL)
+	a.NoError(err)
+
+	// malformed rate is rejected individually
+	ss, err := src.Fetch(context.Background())
+	a.NoError(err)
+	a.Len(ss, 1)
+	a.Empty(ss[0].Currencies)
+	a.Len(ss[0].Rejected, 1)
+	a.Equal(`currency="USD" rate="abc"`, ss[0].Rejected[0].Payload)
+	a.NotEmpty(ss[0].Rejected[0].Reason)
+}
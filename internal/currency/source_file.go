@@ -0,0 +1,257 @@
+package currency
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// supported payload formats
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatRSS    = "rss"
+	FormatECBXML = "ecbxml"
+)
+
+// parsers maps payload formats to their respective parsers
+var parsers = map[string]func(r io.Reader) ([]Snapshot, error){
+	FormatCSV:    parseCSV,
+	FormatJSON:   parseJSON,
+	FormatRSS:    parseRSS,
+	FormatECBXML: parseECBXML,
+}
+
+// fileSource reads currencies from a local file or the standard input
+type fileSource struct {
+	path  string
+	parse func(r io.Reader) ([]Snapshot, error)
+}
+
+// NewFileSource initializes a new local file source of a given format,
+// path "-" stands for the standard input
+func NewFileSource(path string, format string) (FeedSource, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, ErrEmptyFeedURL
+	}
+
+	parse, ok := parsers[strings.ToLower(strings.TrimSpace(format))]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupportedFormat, "format: %s", format)
+	}
+
+	s := &fileSource{
+		path:  path,
+		parse: parse,
+	}
+
+	return s, nil
+}
+
+func (s *fileSource) Name() string {
+	if s.path == "-" {
+		return "stdin"
+	}
+
+	return s.path
+}
+
+func (s *fileSource) Fetch(ctx context.Context) (ss []Snapshot, err error) {
+	var r io.ReadCloser = ioutil.NopCloser(os.Stdin)
+
+	if s.path != "-" {
+		if r, err = os.Open(s.path); err != nil {
+			return nil, errors.Wrapf(err, "failed to open file [%s]", s.path)
+		}
+	}
+	defer r.Close()
+
+	if ss, err = s.parse(r); err != nil {
+		return nil, newImportError(ImportErrorParse, s.Name(), err)
+	}
+
+	return ss, nil
+}
+
+// parsePubDate parses publication date either as a plain date or RFC3339
+// timestamp (i.e. as returned by the API)
+func parsePubDate(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+
+	if t, err := time.Parse(dateLayout, v); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return t, errors.Wrapf(ErrInvalidPayloadFormat, "invalid publication date: %s", v)
+	}
+
+	return t, nil
+}
+
+// groupSnapshots groups currencies by their publication dates,
+// rejections are grouped separately regardless of their dates
+func groupSnapshots(cs []Currency, rejected []Rejection) []Snapshot {
+	byDate := make(map[string]*Snapshot)
+
+	for _, c := range cs {
+		k := c.PubDate.Time.Format(dateLayout)
+
+		if byDate[k] == nil {
+			byDate[k] = &Snapshot{PubDate: c.PubDate.Time}
+		}
+
+		byDate[k].Currencies = append(byDate[k].Currencies, c)
+	}
+
+	ss := make([]Snapshot, 0, len(byDate)+1)
+	for _, s := range byDate {
+		ss = append(ss, *s)
+	}
+
+	// latest dates first, as in the feeds
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].PubDate.After(ss[j].PubDate)
+	})
+
+	if len(rejected) > 0 {
+		ss = append(ss, Snapshot{Rejected: rejected})
+	}
+
+	return ss
+}
+
+// parseCSV transforms a plain CSV with a header into currency snapshots,
+// columns are named after currency fields and may go in any order, i.e.:
+//
+//	pub_date,id,value
+//	2020-03-19,USD,1.0801
+//
+// NOTE: malformed rows are rejected individually
+func parseCSV(r io.Reader) (ss []Snapshot, err error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	// NOTE: records of a wrong length are rejected individually below,
+	// rather than by the reader, which would fail the whole file
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read csv header")
+	}
+
+	// mapping column names to their positions
+	columns := map[string]int{"id": -1, "value": -1, "pub_date": -1}
+	for i, name := range header {
+		if _, ok := columns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+	}
+
+	for name, i := range columns {
+		if i == -1 {
+			return nil, errors.Wrapf(ErrInvalidPayloadFormat, "csv column is missing: %s", name)
+		}
+	}
+
+	cs := make([]Currency, 0)
+	rejected := make([]Rejection, 0)
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read csv record")
+		}
+
+		if len(record) != len(header) {
+			rejected = append(rejected, newRejection(time.Time{}, strings.Join(record, ","), errors.Wrapf(ErrInvalidPayloadFormat, "csv record has %d fields, expected %d", len(record), len(header))))
+			continue
+		}
+
+		pubDate, err := parsePubDate(record[columns["pub_date"]])
+		if err != nil {
+			rejected = append(rejected, newRejection(time.Time{}, strings.Join(record, ","), err))
+			continue
+		}
+
+		v := strings.TrimSpace(record[columns["value"]])
+
+		value, err := ParseValue(v)
+		if err != nil {
+			rejected = append(rejected, newRejection(pubDate, strings.Join(record, ","), err))
+			continue
+		}
+
+		cs = append(cs, Currency{
+			ID:      strings.ToUpper(strings.TrimSpace(record[columns["id"]])),
+			Value:   value,
+			PubDate: dbr.NewNullTime(pubDate),
+		})
+	}
+
+	return groupSnapshots(cs, rejected), nil
+}
+
+// parseJSON transforms a JSON array of currencies into snapshots, i.e.:
+//
+//	[{"id": "USD", "value": 1.0801, "pub_date": "2020-03-19"}]
+//
+// where values are either numbers or strings, parsed exactly as decimals
+//
+// NOTE: malformed items are rejected individually
+func parseJSON(r io.Reader) (ss []Snapshot, err error) {
+	raw := make([]jsoniter.RawMessage, 0)
+
+	if err = json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, errors.Wrap(err, "failed to parse json payload")
+	}
+
+	cs := make([]Currency, 0, len(raw))
+	rejected := make([]Rejection, 0)
+
+	for _, payload := range raw {
+		item := struct {
+			ID      string          `json:"id"`
+			Value   decimal.Decimal `json:"value"`
+			PubDate string          `json:"pub_date"`
+		}{}
+
+		if err = json.Unmarshal(payload, &item); err != nil {
+			rejected = append(rejected, newRejection(time.Time{}, string(payload), errors.Wrap(err, "failed to parse json item")))
+			continue
+		}
+
+		pubDate, err := parsePubDate(item.PubDate)
+		if err != nil {
+			rejected = append(rejected, newRejection(time.Time{}, string(payload), err))
+			continue
+		}
+
+		cs = append(cs, Currency{
+			ID:      strings.ToUpper(strings.TrimSpace(item.ID)),
+			Value:   item.Value,
+			PubDate: dbr.NewNullTime(pubDate),
+		})
+	}
+
+	return groupSnapshots(cs, rejected), nil
+}
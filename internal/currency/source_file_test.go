@@ -0,0 +1,100 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSource_Formats(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		path   string
+		format string
+		dates  int
+		items  int
+	}{
+		{"testdata/rates.csv", currency.FormatCSV, 2, 4},
+		{"testdata/rates.json", currency.FormatJSON, 2, 3},
+		{"testdata/ecb_rss.xml", currency.FormatRSS, 3, 18},
+		{"testdata/eurofxref-hist.xml", currency.FormatECBXML, 4, 12},
+	}
+
+	for _, tt := range tests {
+		src, err := currency.NewFileSource(tt.path, tt.format)
+		a.NoError(err)
+
+		ss, err := src.Fetch(context.Background())
+		a.NoError(err, tt.path)
+		a.Len(ss, tt.dates, tt.path)
+
+		items := 0
+		for _, s := range ss {
+			items += len(s.Currencies)
+		}
+
+		a.Equal(tt.items, items, tt.path)
+	}
+
+	// unsupported format
+	src, err := currency.NewFileSource("testdata/rates.csv", "yaml")
+	a.Error(err)
+	a.Nil(src)
+
+	// mismatching format
+	src, err = currency.NewFileSource("testdata/rates.csv", currency.FormatJSON)
+	a.NoError(err)
+
+	_, err = src.Fetch(context.Background())
+	a.Error(err)
+
+	// records of a wrong length are rejected individually
+	src, err = currency.NewFileSource("testdata/rates_invalid.csv", currency.FormatCSV)
+	a.NoError(err)
+
+	ss, err := src.Fetch(context.Background())
+	a.NoError(err)
+
+	items, rejected := 0, make([]currency.Rejection, 0)
+	for _, s := range ss {
+		items += len(s.Currencies)
+		rejected = append(rejected, s.Rejected...)
+	}
+
+	a.Equal(2, items)
+	a.Len(rejected, 2)
+	a.Equal("2020-03-19,JPY", rejected[0].Payload)
+	a.Equal("2020-03-19,GBP,0.9307,extra", rejected[1].Payload)
+}
+
+func TestManager_ImportFromFile(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore())
+	a.NoError(err)
+
+	src, err := currency.NewFileSource("testdata/rates.json", currency.FormatJSON)
+	a.NoError(err)
+
+	_, err = m.ImportFrom(context.Background(), src)
+	a.NoError(err)
+
+	cs, err := m.GetLatest(context.Background(), "")
+	a.NoError(err)
+	a.Len(cs, 2)
+
+	cs, err = m.GetAllByID(context.Background(), "JPY", "")
+	a.NoError(err)
+	a.Len(cs, 1)
+
+	// items are validated the same way as during the network import
+	src, err = currency.NewFileSource("testdata/rates_invalid.json", currency.FormatJSON)
+	a.NoError(err)
+
+	r, err := m.ImportFrom(context.Background(), src)
+	a.NoError(err)
+	a.Equal(1, r.Rejected)
+}
@@ -0,0 +1,133 @@
+package currency
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/mmcdole/gofeed"
+	"github.com/pkg/errors"
+)
+
+// rssSource reads the bank.lv RSS wrapper of the ECB reference rates,
+// where each item's description is a space separated list of pairs,
+// i.e.: "USD 1.0801 JPY 118.15"
+type rssSource struct {
+	feedURL string
+	client  *http.Client
+}
+
+// NewRSSSource initializes a new RSS feed source
+func NewRSSSource(feedURL string) (FeedSource, error) {
+	feedURL = strings.TrimSpace(feedURL)
+	if feedURL == "" {
+		return nil, ErrEmptyFeedURL
+	}
+
+	s := &rssSource{
+		feedURL: feedURL,
+		client:  http.DefaultClient,
+	}
+
+	return s, nil
+}
+
+func (s *rssSource) Name() string {
+	return s.feedURL
+}
+
+func (s *rssSource) Fetch(ctx context.Context) (ss []Snapshot, err error) {
+	ss, _, err = s.FetchConditional(ctx, FeedState{})
+
+	return ss, err
+}
+
+func (s *rssSource) FetchConditional(ctx context.Context, prev FeedState) (ss []Snapshot, next FeedState, err error) {
+	payload, next, err := fetchConditional(ctx, s.client, s.feedURL, prev)
+	if err != nil {
+		return nil, next, err
+	}
+
+	if ss, err = parseRSS(bytes.NewReader(payload)); err != nil {
+		return nil, next, newImportError(ImportErrorParse, s.Name(), err)
+	}
+
+	return ss, next, nil
+}
+
+// parseRSS transforms raw RSS payload into currency snapshots
+// NOTE: malformed items and pairs are rejected individually
+func parseRSS(r io.Reader) (ss []Snapshot, err error) {
+	f, err := gofeed.NewParser().Parse(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse feed")
+	}
+
+	ss = make([]Snapshot, 0, len(f.Items))
+
+	for _, v := range f.Items {
+		if v.PublishedParsed == nil {
+			ss = append(ss, Snapshot{
+				Rejected: []Rejection{
+					newRejection(time.Time{}, v.Description, errors.Wrapf(ErrInvalidPayloadFormat, "item has no publication date: %s", v.Title)),
+				},
+			})
+
+			continue
+		}
+
+		pubDate := v.PublishedParsed.Local()
+
+		cs, rejected, err := parseRSSPayload(pubDate, strings.Fields(v.Description))
+		if err != nil {
+			rejected = []Rejection{
+				newRejection(pubDate, v.Description, errors.Wrap(err, "failed to parse raw currency payload")),
+			}
+		}
+
+		ss = append(ss, Snapshot{
+			PubDate:    pubDate,
+			Currencies: cs,
+			Rejected:   rejected,
+		})
+	}
+
+	return ss, nil
+}
+
+// parseRSSPayload transforms raw currency payload into currency
+// objects, rejecting the pairs which fail to be parsed
+func parseRSSPayload(pubDate time.Time, s []string) (cs []Currency, rejected []Rejection, err error) {
+	slen := len(s)
+
+	// slice must not be empty or contain an odd number of items
+	if slen == 0 || slen%2 != 0 {
+		return nil, nil, ErrInvalidPayloadFormat
+	}
+
+	// initialzing currency slice
+	cs = make([]Currency, 0, slen/2)
+
+	// pairing values: key -> value
+	for i := 0; i < slen; i += 2 {
+		k, v := s[i], s[i+1]
+
+		value, err := ParseValue(v)
+		if err != nil {
+			rejected = append(rejected, newRejection(pubDate, k+" "+v, err))
+			continue
+		}
+
+		cs = append(cs, Currency{
+			ID:      strings.ToUpper(k),
+			Value:   value,
+			PubDate: dbr.NewNullTime(pubDate),
+		})
+	}
+
+	return cs, rejected, nil
+}
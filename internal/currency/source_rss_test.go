@@ -0,0 +1,89 @@
+package currency_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRSSSource_Fetch(t *testing.T) {
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/ecb_rss.xml")
+	}))
+	defer ts.Close()
+
+	src, err := currency.NewRSSSource(ts.URL)
+	a.NoError(err)
+	a.NotNil(src)
+
+	ss, err := src.Fetch(context.Background())
+	a.NoError(err)
+	a.Len(ss, 3)
+
+	for _, s := range ss {
+		a.Len(s.Currencies, 6)
+
+		for _, c := range s.Currencies {
+			a.NotEmpty(c.ID)
+			a.False(c.Value.IsZero())
+			a.Equal(s.PubDate, c.PubDate.Time)
+		}
+	}
+}
+
+func TestRSSSource_FetchFailure(t *testing.T) {
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	src, err := currency.NewRSSSource(ts.URL)
+	a.NoError(err)
+
+	ss, err := src.Fetch(context.Background())
+	a.Error(err)
+	a.Nil(ss)
+
+	// empty feed url
+	src, err = currency.NewRSSSource(" ")
+	a.Equal(currency.ErrEmptyFeedURL, err)
+	a.Nil(src)
+}
+
+func TestRSSSource_FetchPartiallyMalformed(t *testing.T) {
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>rates</title>
+<item><title>good</title><pubDate>Thu, 19 Mar 2020 02:00:00 +0200</pubDate><description>USD 1.0801 JPY abc GBP 0.9241</description></item>
+<item><title>odd</title><pubDate>Wed, 18 Mar 2020 02:00:00 +0200</pubDate><description>USD 1.0934 JPY</description></item>
+</channel></rss>`))
+	}))
+	defer ts.Close()
+
+	src, err := currency.NewRSSSource(ts.URL)
+	a.NoError(err)
+
+	ss, err := src.Fetch(context.Background())
+	a.NoError(err)
+	a.Len(ss, 2)
+
+	// only the malformed pair is rejected
+	a.Len(ss[0].Currencies, 2)
+	a.Len(ss[0].Rejected, 1)
+	a.Equal("JPY abc", ss[0].Rejected[0].Payload)
+
+	// the whole item is rejected
+	a.Empty(ss[1].Currencies)
+	a.Len(ss[1].Rejected, 1)
+	a.Equal("USD 1.0934 JPY", ss[1].Rejected[0].Payload)
+}
@@ -0,0 +1,308 @@
+package currency
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultStatsWindow is the default number of observations the window
+// statistics are computed over, which is roughly a month of business days
+const DefaultStatsWindow = 20
+
+// DefaultChangeDays are the default periods of changes, in calendar days,
+// i.e. day-over-day and week-over-week
+var DefaultChangeDays = []int{1, 7}
+
+// StatsQuery represents a request of currency statistics
+// NOTE: zero date stands for the latest known values
+type StatsQuery struct {
+	// Window is the number of the most recent observations
+	// the window statistics are computed over
+	Window int
+
+	// ChangeDays are the periods of changes in calendar days
+	ChangeDays []int
+
+	// To is the date the statistics are computed as of
+	To time.Time
+}
+
+// Change represents the change of a value relative to a reference value,
+// which is the most recent one published on or before the given number
+// of calendar days earlier (i.e. Friday's one for day-over-day on Monday)
+type Change struct {
+	Days     int             `json:"days"`
+	PubDate  dbr.NullTime    `json:"pub_date"`
+	Value    decimal.Decimal `json:"value"`
+	Absolute decimal.Decimal `json:"absolute"`
+	Percent  decimal.Decimal `json:"percent"`
+}
+
+// Stats represents the statistics of a single currency as of its latest value
+type Stats struct {
+	ID      string          `json:"id"`
+	Value   decimal.Decimal `json:"value"`
+	PubDate dbr.NullTime    `json:"pub_date"`
+	Changes []Change        `json:"changes"`
+
+	// window statistics, where observations is the actual number
+	// of values within the window (i.e. less for a short history)
+	Window       int             `json:"window"`
+	Observations int             `json:"observations"`
+	Volatility   float64         `json:"volatility"`
+	SMA          decimal.Decimal `json:"sma"`
+	EMA          decimal.Decimal `json:"ema"`
+	Min          decimal.Decimal `json:"min"`
+	Max          decimal.Decimal `json:"max"`
+
+	// VolatilitySeries is the rolling volatility on each date of the window,
+	// oldest first, where the last point is the volatility above
+	VolatilitySeries []VolatilityPoint `json:"volatility_series"`
+}
+
+// VolatilityPoint represents the volatility of a window of values ending
+// on a publication date, where observations is the number of values
+// within that window (i.e. less at the beginning of the history)
+type VolatilityPoint struct {
+	PubDate      dbr.NullTime `json:"pub_date"`
+	Observations int          `json:"observations"`
+	Volatility   float64      `json:"volatility"`
+}
+
+// Stats returns the statistics of given currencies, as of a given date
+func (m *Manager) Stats(ctx context.Context, ids []string, q StatsQuery) (ss []Stats, err error) {
+	if m == nil {
+		return nil, ErrNilManager
+	}
+
+	store, err := m.Store()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain currency store")
+	}
+
+	if q.Window == 0 {
+		q.Window = DefaultStatsWindow
+	}
+
+	if q.Window < 2 || q.Window > MaxHistoryLimit {
+		return nil, errors.Wrapf(ErrInvalidStatsQuery, "window must be within 2..%d: %d", MaxHistoryLimit, q.Window)
+	}
+
+	if q.ChangeDays == nil {
+		q.ChangeDays = DefaultChangeDays
+	}
+
+	for _, days := range q.ChangeDays {
+		if days <= 0 {
+			return nil, errors.Wrapf(ErrInvalidStatsQuery, "invalid change period: %d", days)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrEmptyCurrencyID
+	}
+
+	ss = make([]Stats, 0, len(ids))
+
+	for _, id := range ids {
+		if id = strings.ToUpper(strings.TrimSpace(id)); id == "" {
+			return nil, ErrEmptyCurrencyID
+		}
+
+		// NOTE: the rolling volatility of the earliest date of the window
+		// needs a whole window of values before it, hence fetching
+		// the most recent values of two windows, oldest first
+		history, err := store.RangeByID(ctx, id, Range{To: q.To, Order: OrderDesc, Limit: 2*q.Window - 1})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch currency history for ID: %s", id)
+		}
+
+		if len(history) == 0 {
+			return nil, errors.Wrapf(ErrCurrencyNotFound, "no %s values", id)
+		}
+
+		for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+			history[i], history[j] = history[j], history[i]
+		}
+
+		// the window itself
+		cs := history
+		if len(cs) > q.Window {
+			cs = cs[len(cs)-q.Window:]
+		}
+
+		latest := cs[len(cs)-1]
+
+		series := RollingVolatility(history, q.Window)
+
+		s := Stats{
+			ID:               id,
+			Value:            latest.Value,
+			PubDate:          latest.PubDate,
+			Changes:          make([]Change, 0, len(q.ChangeDays)),
+			Window:           q.Window,
+			Observations:     len(cs),
+			Volatility:       Volatility(cs),
+			SMA:              m.conversion.Round(SMA(cs)),
+			EMA:              m.conversion.Round(EMA(cs, len(cs))),
+			VolatilitySeries: series[len(series)-len(cs):],
+		}
+
+		s.Min, s.Max = MinMax(cs)
+
+		// NOTE: changes without a reference value (i.e. beyond the
+		// beginning of the history) are omitted
+		for _, days := range q.ChangeDays {
+			ref, err := m.rateAt(ctx, id, latest.PubDate.Time.AddDate(0, 0, -days))
+			if err != nil {
+				if errors.Cause(err) == ErrCurrencyNotFound {
+					continue
+				}
+
+				return nil, err
+			}
+
+			s.Changes = append(s.Changes, Change{
+				Days:     days,
+				PubDate:  ref.PubDate,
+				Value:    ref.Value,
+				Absolute: latest.Value.Sub(ref.Value),
+				Percent:  m.conversion.Round(PercentChange(ref.Value, latest.Value)),
+			})
+		}
+
+		ss = append(ss, s)
+	}
+
+	return ss, nil
+}
+
+// PercentChange returns the change from one value to another in percent
+// NOTE: the result is kept at the division precision, zero for the zero reference
+func PercentChange(from, to decimal.Decimal) decimal.Decimal {
+	if from.IsZero() {
+		return decimal.Zero
+	}
+
+	return to.Sub(from).Mul(decimal.New(100, 0)).DivRound(from, divisionPlaces)
+}
+
+// LogReturns returns the natural logarithms of the ratios between
+// consecutive values, which are expected to be ordered by date
+// NOTE: it's a metric, thus computed with floats
+func LogReturns(cs []Currency) []float64 {
+	if len(cs) < 2 {
+		return nil
+	}
+
+	rs := make([]float64, 0, len(cs)-1)
+
+	for i := 1; i < len(cs); i++ {
+		prev, cur := cs[i-1].Value.InexactFloat64(), cs[i].Value.InexactFloat64()
+		if prev <= 0 || cur <= 0 {
+			continue
+		}
+
+		rs = append(rs, math.Log(cur/prev))
+	}
+
+	return rs
+}
+
+// Volatility returns the sample standard deviation of the log returns
+// of given values, which are expected to be ordered by date
+func Volatility(cs []Currency) float64 {
+	rs := LogReturns(cs)
+	if len(rs) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range rs {
+		mean += r
+	}
+
+	mean /= float64(len(rs))
+
+	variance := 0.0
+	for _, r := range rs {
+		variance += (r - mean) * (r - mean)
+	}
+
+	return math.Sqrt(variance / float64(len(rs)-1))
+}
+
+// RollingVolatility returns the volatility of the window of a given number
+// of values ending on each date, values are expected to be ordered by date
+func RollingVolatility(cs []Currency, window int) []VolatilityPoint {
+	ps := make([]VolatilityPoint, 0, len(cs))
+
+	for i := range cs {
+		from := i - window + 1
+		if from < 0 {
+			from = 0
+		}
+
+		ps = append(ps, VolatilityPoint{
+			PubDate:      cs[i].PubDate,
+			Observations: i - from + 1,
+			Volatility:   Volatility(cs[from : i+1]),
+		})
+	}
+
+	return ps
+}
+
+// SMA returns the simple moving average, i.e. the mean of given values
+func SMA(cs []Currency) decimal.Decimal {
+	if len(cs) == 0 {
+		return decimal.Zero
+	}
+
+	sum := decimal.Zero
+	for _, c := range cs {
+		sum = sum.Add(c.Value)
+	}
+
+	return sum.DivRound(decimal.New(int64(len(cs)), 0), divisionPlaces)
+}
+
+// EMA returns the exponential moving average of given values over a window
+// of a given number of periods, values are expected to be ordered by date
+// NOTE: the average is seeded with the first value
+func EMA(cs []Currency, window int) decimal.Decimal {
+	if len(cs) == 0 || window <= 0 {
+		return decimal.Zero
+	}
+
+	// smoothing factor: 2 / (N + 1)
+	alpha := decimal.New(2, 0).DivRound(decimal.New(int64(window)+1, 0), divisionPlaces)
+	ema := cs[0].Value
+
+	for _, c := range cs[1:] {
+		ema = c.Value.Sub(ema).Mul(alpha).Add(ema).Round(divisionPlaces)
+	}
+
+	return ema
+}
+
+// MinMax returns the lowest and the highest one of given values
+func MinMax(cs []Currency) (min, max decimal.Decimal) {
+	for i, c := range cs {
+		if i == 0 || c.Value.LessThan(min) {
+			min = c.Value
+		}
+
+		if i == 0 || c.Value.GreaterThan(max) {
+			max = c.Value
+		}
+	}
+
+	return min, max
+}
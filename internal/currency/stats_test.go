@@ -0,0 +1,157 @@
+package currency_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func values(vs ...string) []currency.Currency {
+	cs := make([]currency.Currency, 0, len(vs))
+	for _, v := range vs {
+		cs = append(cs, currency.Currency{ID: "USD", Value: decimal.RequireFromString(v)})
+	}
+
+	return cs
+}
+
+func TestAnalytics(t *testing.T) {
+	a := assert.New(t)
+
+	cs := values("1.10", "1.21", "1.10", "1.21")
+
+	a.Equal("10", currency.PercentChange(decimal.RequireFromString("1.10"), decimal.RequireFromString("1.21")).String())
+	a.Equal("0", currency.PercentChange(decimal.Zero, decimal.New(1, 0)).String())
+
+	rs := currency.LogReturns(cs)
+	a.Len(rs, 3)
+	a.InDelta(math.Log(1.1), rs[0], 1e-12)
+	a.InDelta(-math.Log(1.1), rs[1], 1e-12)
+
+	// returns are r, -r, r where r = ln(1.1), thus the deviation is r * sqrt(4/3)
+	a.InDelta(math.Log(1.1)*math.Sqrt(4.0/3.0), currency.Volatility(cs), 1e-12)
+	a.Zero(currency.Volatility(values("1.10", "1.21")))
+
+	a.Equal("1.155", currency.SMA(cs).String())
+	a.Equal("0", currency.SMA(nil).String())
+
+	// alpha = 2/3: 1.10 -> 1.1733.. -> 1.1244.. -> 1.1814..
+	a.Equal("1.181481", currency.EMA(cs, 2).Round(6).String())
+	a.Equal("1.1", currency.EMA(cs[:1], 2).String())
+
+	min, max := currency.MinMax(cs)
+	a.Equal("1.1", min.String())
+	a.Equal("1.21", max.String())
+
+	// rolling over 3 values, where the first windows are partial
+	ps := currency.RollingVolatility(values("1.10", "1.21", "1.10", "1.21", "1.21"), 3)
+	a.Len(ps, 5)
+	a.Equal(1, ps[0].Observations)
+	a.Zero(ps[1].Volatility)
+	a.Equal(3, ps[2].Observations)
+	a.InDelta(math.Log(1.1)*math.Sqrt(2), ps[2].Volatility, 1e-12)
+	a.InDelta(math.Log(1.1)*math.Sqrt(2), ps[3].Volatility, 1e-12)
+	a.InDelta(math.Log(1.1)/math.Sqrt(2), ps[4].Volatility, 1e-12)
+}
+
+func TestManager_Stats(t *testing.T) {
+	a := assert.New(t)
+
+	// thursday
+	today := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			newSnapshot(today, map[string]float64{"USD": 1.0801, "JPY": 118.15}),
+			newSnapshot(today.AddDate(0, 0, -1), map[string]float64{"USD": 1.0934, "JPY": 117.67}),
+			newSnapshot(today.AddDate(0, 0, -2), map[string]float64{"USD": 1.0982, "JPY": 117.74}),
+			newSnapshot(today.AddDate(0, 0, -6), map[string]float64{"USD": 1.1104, "JPY": 118.95}),
+			newSnapshot(today.AddDate(0, 0, -7), map[string]float64{"USD": 1.1174, "JPY": 119.92}),
+		},
+	}
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(src))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	ss, err := m.Stats(context.Background(), []string{"usd", "JPY"}, currency.StatsQuery{})
+	a.NoError(err)
+	a.Len(ss, 2)
+
+	s := ss[0]
+	a.Equal("USD", s.ID)
+	a.Equal("1.0801", s.Value.String())
+	a.Equal(today, s.PubDate.Time)
+	a.Equal(currency.DefaultStatsWindow, s.Window)
+	a.Equal(5, s.Observations)
+	a.Equal("1.0801", s.Min.String())
+	a.Equal("1.1174", s.Max.String())
+	a.Equal("1.0999", s.SMA.String())
+	a.True(s.Volatility > 0)
+
+	// day-over-day and week-over-week
+	a.Len(s.Changes, 2)
+	a.Equal(1, s.Changes[0].Days)
+	a.Equal("-0.0133", s.Changes[0].Absolute.String())
+	a.Equal("-1.216389", s.Changes[0].Percent.String())
+	a.Equal(7, s.Changes[1].Days)
+	a.Equal(today.AddDate(0, 0, -7), s.Changes[1].PubDate.Time)
+	a.Equal("-0.0373", s.Changes[1].Absolute.String())
+
+	// the rolling volatility on each date of the window
+	a.Len(s.VolatilitySeries, 5)
+	a.Equal(today.AddDate(0, 0, -7), s.VolatilitySeries[0].PubDate.Time)
+	a.Equal(1, s.VolatilitySeries[0].Observations)
+	a.Equal(today, s.VolatilitySeries[4].PubDate.Time)
+	a.Equal(s.Volatility, s.VolatilitySeries[4].Volatility)
+
+	a.Equal("JPY", ss[1].ID)
+
+	// every point of a shorter window covers a whole window of values
+	ss, err = m.Stats(context.Background(), []string{"USD"}, currency.StatsQuery{Window: 3})
+	a.NoError(err)
+	a.Equal(3, ss[0].Observations)
+	a.Len(ss[0].VolatilitySeries, 3)
+	a.Equal(today.AddDate(0, 0, -2), ss[0].VolatilitySeries[0].PubDate.Time)
+
+	for _, p := range ss[0].VolatilitySeries {
+		a.Equal(3, p.Observations)
+		a.True(p.Volatility > 0)
+	}
+
+	a.Equal(ss[0].Volatility, ss[0].VolatilitySeries[2].Volatility)
+
+	// as of an earlier date over a shorter window, the custom
+	// change is omitted as there is no reference value
+	ss, err = m.Stats(context.Background(), []string{"USD"}, currency.StatsQuery{
+		Window:     2,
+		ChangeDays: []int{4, 30},
+		To:         today.AddDate(0, 0, -2),
+	})
+	a.NoError(err)
+	a.Len(ss, 1)
+	a.Equal("1.0982", ss[0].Value.String())
+	a.Equal(2, ss[0].Observations)
+	a.Equal("1.1104", ss[0].Max.String())
+	a.Len(ss[0].Changes, 1)
+	a.Equal(4, ss[0].Changes[0].Days)
+	a.Equal("1.1104", ss[0].Changes[0].Value.String())
+
+	// invalid queries
+	_, err = m.Stats(context.Background(), []string{"USD"}, currency.StatsQuery{Window: 1})
+	a.Equal(currency.ErrInvalidStatsQuery, errors.Cause(err))
+
+	_, err = m.Stats(context.Background(), []string{"USD"}, currency.StatsQuery{ChangeDays: []int{0}})
+	a.Equal(currency.ErrInvalidStatsQuery, errors.Cause(err))
+
+	_, err = m.Stats(context.Background(), []string{"USD", "XXX"}, currency.StatsQuery{})
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+}
@@ -2,13 +2,44 @@ package currency
 
 import (
 	"context"
+	"time"
 )
 
 // Store represents an API interface contract
 // NOTE: this is a very simplified version, inteded
 // for demonstration purposes only
 type Store interface {
-	BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, err error)
+	BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, stats UpsertStats, err error)
 	AllLatest(ctx context.Context) (cs []Currency, err error)
 	AllByID(ctx context.Context, id string) (cs []Currency, err error)
+
+	// AsOf returns the most recent value of a currency published on or before
+	// a given date, or ErrCurrencyNotFound if there is none
+	AsOf(ctx context.Context, id string, date time.Time) (c Currency, err error)
+
+	// RangeByID returns the values of a currency within a given range
+	// of publication dates, ordered and limited as requested
+	RangeByID(ctx context.Context, id string, r Range) (cs []Currency, err error)
+}
+
+// UpsertStats represents the outcome of storing currencies,
+// where each item is either inserted, updated or left unchanged
+type UpsertStats struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Add accumulates another stats
+func (s *UpsertStats) Add(other UpsertStats) {
+	s.Inserted += other.Inserted
+	s.Updated += other.Updated
+	s.Unchanged += other.Unchanged
+}
+
+// ImportRunStore is implemented by stores which are able
+// to keep the audit log of import runs
+type ImportRunStore interface {
+	CreateImportRun(ctx context.Context, r ImportReport) (_ ImportReport, err error)
+	ImportRuns(ctx context.Context, limit int) (rs []ImportReport, err error)
 }
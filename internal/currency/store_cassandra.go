@@ -1,3 +1,477 @@
 package currency
 
-// TODO: implement similar as with MySQL, In-Memory etc.
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gopkg.in/inf.v0"
+)
+
+// cassandraSchema contains the tables of the Cassandra store: values are
+// partitioned by currency and clustered by the newest dates first, and the
+// latest value of each currency is kept in a separate table, which is
+// maintained on writes because Cassandra can't select it across partitions
+var cassandraSchema = []string{
+	`CREATE TABLE IF NOT EXISTS %s.currency (
+		id text,
+		pub_date date,
+		value decimal,
+		source text,
+		created_at timestamp,
+		updated_at timestamp,
+		PRIMARY KEY ((id), pub_date)
+	) WITH CLUSTERING ORDER BY (pub_date DESC)`,
+	`CREATE TABLE IF NOT EXISTS %s.currency_latest (
+		id text PRIMARY KEY,
+		pub_date date,
+		value decimal,
+		source text,
+		created_at timestamp,
+		updated_at timestamp
+	)`,
+	`CREATE TABLE IF NOT EXISTS %s.feed_state (
+		source text PRIMARY KEY,
+		etag text,
+		last_modified text,
+		content_hash text,
+		checked_at timestamp
+	)`,
+}
+
+// InitCassandraSchema creates the keyspace (with a given replication factor
+// of SimpleStrategy) and the tables of the Cassandra store unless they exist
+// NOTE: the session must not be bound to the keyspace, since it may not exist yet
+func InitCassandraSchema(ctx context.Context, session *gocql.Session, keyspace string, replication int) (err error) {
+	if session == nil {
+		return ErrNilDatabase
+	}
+
+	if replication < 1 {
+		replication = 1
+	}
+
+	q := fmt.Sprintf(
+		"CREATE KEYSPACE IF NOT EXISTS %s WITH replication = {'class': 'SimpleStrategy', 'replication_factor': %d}",
+		keyspace,
+		replication,
+	)
+
+	if err = session.Query(q).WithContext(ctx).Exec(); err != nil {
+		return errors.Wrapf(err, "failed to create keyspace %s", keyspace)
+	}
+
+	for _, table := range cassandraSchema {
+		if err = session.Query(fmt.Sprintf(table, keyspace)).WithContext(ctx).Exec(); err != nil {
+			return errors.Wrap(err, "failed to create table")
+		}
+	}
+
+	return nil
+}
+
+type defaultCassandraStore struct {
+	session *gocql.Session
+}
+
+// NewDefaultCassandraStore initializes a store backed by Cassandra (or ScyllaDB)
+// NOTE: the session must be bound to the keyspace initialized by InitCassandraSchema
+func NewDefaultCassandraStore(session *gocql.Session) (Store, error) {
+	if session == nil {
+		return nil, ErrNilDatabase
+	}
+
+	s := &defaultCassandraStore{
+		session: session,
+	}
+
+	return s, nil
+}
+
+// cassandraColumns are the columns selected into currencies, in the order of scanning
+const cassandraColumns = "id, pub_date, value, source, created_at, updated_at"
+
+// scanCurrency scans a row of cassandraColumns into a currency
+func scanCurrency(scan func(dest ...interface{}) error) (c Currency, err error) {
+	var (
+		value                     inf.Dec
+		pubDate, created, updated time.Time
+	)
+
+	if err = scan(&c.ID, &pubDate, &value, &c.Source, &created, &updated); err != nil {
+		return c, err
+	}
+
+	c.Value = decimal.NewFromBigInt(value.UnscaledBig(), -int32(value.Scale()))
+	c.PubDate = cassandraTime(pubDate)
+	c.CreatedAt = cassandraTime(created)
+	c.UpdatedAt = cassandraTime(updated)
+
+	return c, nil
+}
+
+// cassandraTime converts a scanned time, which is zero if the column is null
+func cassandraTime(t time.Time) dbr.NullTime {
+	if t.IsZero() {
+		return dbr.NullTime{}
+	}
+
+	return dbr.NewNullTime(t)
+}
+
+// cassandraDecimal converts a value into the decimal type of the driver
+func cassandraDecimal(d decimal.Decimal) *inf.Dec {
+	return inf.NewDecBig(d.Coefficient(), inf.Scale(-d.Exponent()))
+}
+
+func (s *defaultCassandraStore) oneByQuery(ctx context.Context, q string, args ...interface{}) (c Currency, err error) {
+	c, err = scanCurrency(s.session.Query(q, args...).WithContext(ctx).Scan)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return c, ErrCurrencyNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (s *defaultCassandraStore) manyByQuery(ctx context.Context, q string, args ...interface{}) (items []Currency, err error) {
+	items = make([]Currency, 0)
+
+	iter := s.session.Query(q, args...).WithContext(ctx).Iter()
+	scanner := iter.Scanner()
+
+	for scanner.Next() {
+		c, err := scanCurrency(scanner.Scan)
+		if err != nil {
+			iter.Close()
+			return nil, err
+		}
+
+		items = append(items, c)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (s *defaultCassandraStore) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, stats UpsertStats, err error) {
+	// there must be something first
+	if len(cs) == 0 {
+		return nil, stats, ErrNoData
+	}
+
+	// validating everything beforehand, since there are no transactions
+	for i := range cs {
+		if err := cs[i].Validate(); err != nil {
+			return nil, stats, err
+		}
+	}
+
+	// latest values which have been read or written within this call
+	latest := make(map[string]Currency)
+
+	// NOTE: each value is compared with the stored one to count it and to keep
+	// its timestamps, which isn't atomic: concurrent imports of the same value
+	// may both count it as inserted, but the stored value is the same either way
+	for i := range cs {
+		c := &cs[i]
+
+		existing, err := s.oneByQuery(
+			ctx,
+			"SELECT "+cassandraColumns+" FROM currency WHERE id = ? AND pub_date = ?",
+			c.ID,
+			c.PubDate.Time.Format(dateLayout),
+		)
+
+		changed := true
+
+		switch {
+		case err == ErrCurrencyNotFound:
+			c.CreatedAt = dbr.NewNullTime(time.Now())
+			stats.Inserted++
+		case err != nil:
+			return nil, stats, errors.Wrap(err, "failed to obtain stored currency")
+		case existing.Value.Equal(c.Value) && existing.Source == c.Source:
+			c.CreatedAt, c.UpdatedAt = existing.CreatedAt, existing.UpdatedAt
+			stats.Unchanged++
+			changed = false
+		default:
+			c.CreatedAt, c.UpdatedAt = existing.CreatedAt, dbr.NewNullTime(time.Now())
+			stats.Updated++
+		}
+
+		if changed {
+			q, args := cassandraUpsert(*c)
+			if err = s.session.Query(q, args...).WithContext(ctx).Exec(); err != nil {
+				return nil, stats, errors.Wrap(err, "failed to store currency")
+			}
+		}
+
+		// NOTE: the latest value is checked even if the value itself is unchanged,
+		// so it's caught up by a repeated import if a previous one failed midway
+		if err = s.updateLatest(ctx, latest, *c); err != nil {
+			return nil, stats, err
+		}
+	}
+
+	return cs, stats, nil
+}
+
+// updateLatest keeps a given currency as the latest value of the currency
+// unless there is a newer one, by lightweight transactions, so that a
+// concurrent import of an older value can't overwrite a newer one
+// NOTE: the latest values are cached in a given map, to skip transactions
+// for the values which are older than or the same as the latest one
+func (s *defaultCassandraStore) updateLatest(ctx context.Context, latest map[string]Currency, c Currency) (err error) {
+	l, ok := latest[c.ID]
+	if !ok {
+		l, err = s.oneByQuery(ctx, "SELECT "+cassandraColumns+" FROM currency_latest WHERE id = ?", c.ID)
+		switch {
+		case err == ErrCurrencyNotFound:
+		case err != nil:
+			return errors.Wrap(err, "failed to obtain latest currency")
+		default:
+			latest[c.ID], ok = l, true
+		}
+	}
+
+	// NOTE: comparing formatted dates to ignore time and location
+	if ok {
+		pubDate, latestPubDate := c.PubDate.Time.Format(dateLayout), l.PubDate.Time.Format(dateLayout)
+
+		if pubDate < latestPubDate || (pubDate == latestPubDate && l.Value.Equal(c.Value) && l.Source == c.Source) {
+			return nil
+		}
+	}
+
+	// inserting the first value, or updating the one which isn't newer otherwise
+	q, args := cassandraLatestInsert(c)
+
+	applied, err := s.session.Query(q, args...).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+	if err != nil {
+		return errors.Wrap(err, "failed to insert latest currency")
+	}
+
+	if !applied {
+		q, args = cassandraLatestUpdate(c)
+
+		// NOTE: if it's not applied either, a newer value has been written meanwhile
+		if _, err = s.session.Query(q, args...).WithContext(ctx).MapScanCAS(make(map[string]interface{})); err != nil {
+			return errors.Wrap(err, "failed to update latest currency")
+		}
+	}
+
+	latest[c.ID] = c
+
+	return nil
+}
+
+// cassandraUpsert builds a statement which stores a given currency
+func cassandraUpsert(c Currency) (string, []interface{}) {
+	args := []interface{}{
+		c.ID,
+		c.PubDate.Time.Format(dateLayout),
+		cassandraDecimal(c.Value),
+		c.Source,
+		c.CreatedAt.Time,
+		nullTimeValue(c.UpdatedAt),
+	}
+
+	return "INSERT INTO currency (" + cassandraColumns + ") VALUES (?, ?, ?, ?, ?, ?)", args
+}
+
+// cassandraLatestInsert builds a statement which stores the first latest value of a currency
+func cassandraLatestInsert(c Currency) (string, []interface{}) {
+	_, args := cassandraUpsert(c)
+
+	return "INSERT INTO currency_latest (" + cassandraColumns + ") VALUES (?, ?, ?, ?, ?, ?) IF NOT EXISTS", args
+}
+
+// cassandraLatestUpdate builds a statement which replaces the latest value
+// of a currency with a given one, unless the stored one is newer
+func cassandraLatestUpdate(c Currency) (string, []interface{}) {
+	pubDate := c.PubDate.Time.Format(dateLayout)
+
+	args := []interface{}{
+		pubDate,
+		cassandraDecimal(c.Value),
+		c.Source,
+		c.CreatedAt.Time,
+		nullTimeValue(c.UpdatedAt),
+		c.ID,
+		pubDate,
+	}
+
+	return "UPDATE currency_latest SET pub_date = ?, value = ?, source = ?, created_at = ?, updated_at = ? WHERE id = ? IF pub_date <= ?", args
+}
+
+// nullTimeValue returns nil for null times, to be stored as null rather than epoch
+func nullTimeValue(t dbr.NullTime) interface{} {
+	if !t.Valid {
+		return nil
+	}
+
+	return t.Time
+}
+
+func (s *defaultCassandraStore) AllLatest(ctx context.Context) (cs []Currency, err error) {
+	latest, err := s.manyByQuery(ctx, "SELECT "+cassandraColumns+" FROM currency_latest")
+	if err != nil {
+		return nil, err
+	}
+
+	// only the currencies of the latest publication date are returned, the
+	// same as with other stores, even though each currency has its latest value
+	var latestPubDate time.Time
+	for _, c := range latest {
+		if c.PubDate.Time.After(latestPubDate) {
+			latestPubDate = c.PubDate.Time
+		}
+	}
+
+	cs = make([]Currency, 0, len(latest))
+	for _, c := range latest {
+		if c.PubDate.Time.Equal(latestPubDate) {
+			cs = append(cs, c)
+		}
+	}
+
+	sort.Slice(cs, func(i, j int) bool { return cs[i].ID < cs[j].ID })
+
+	return cs, nil
+}
+
+func (s *defaultCassandraStore) AllByID(ctx context.Context, id string) (cs []Currency, err error) {
+	return s.manyByQuery(ctx, "SELECT "+cassandraColumns+" FROM currency WHERE id = ?", id)
+}
+
+func (s *defaultCassandraStore) AsOf(ctx context.Context, id string, date time.Time) (c Currency, err error) {
+	return s.oneByQuery(ctx, "SELECT "+cassandraColumns+" FROM currency WHERE id = ? AND pub_date <= ? LIMIT 1", id, date.Format(dateLayout))
+}
+
+func (s *defaultCassandraStore) RangeByID(ctx context.Context, id string, r Range) (cs []Currency, err error) {
+	q, args := cassandraRange(id, r)
+
+	return s.manyByQuery(ctx, q, args...)
+}
+
+// cassandraRange builds a query which selects a range of values of a given currency
+func cassandraRange(id string, r Range) (string, []interface{}) {
+	q := "SELECT " + cassandraColumns + " FROM currency WHERE id = ?"
+	args := []interface{}{id}
+
+	// NOTE: Cassandra allows only one restriction of each bound, so
+	// the cursor replaces the bound it continues from, if it's tighter
+	lower, upper := ">= ?", "<= ?"
+	from, to := r.From, r.To
+
+	if !r.After.IsZero() {
+		if r.Order == OrderAsc && (from.IsZero() || !r.After.Before(from)) {
+			lower, from = "> ?", r.After
+		}
+
+		if r.Order != OrderAsc && (to.IsZero() || !r.After.After(to)) {
+			upper, to = "< ?", r.After
+		}
+	}
+
+	if !from.IsZero() {
+		q += " AND pub_date " + lower
+		args = append(args, from.Format(dateLayout))
+	}
+
+	if !to.IsZero() {
+		q += " AND pub_date " + upper
+		args = append(args, to.Format(dateLayout))
+	}
+
+	if r.Order == OrderAsc {
+		q += " ORDER BY pub_date ASC"
+	} else {
+		q += " ORDER BY pub_date DESC"
+	}
+
+	if r.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, r.Limit)
+	}
+
+	return q, args
+}
+
+func (s *defaultCassandraStore) FeedState(ctx context.Context, source string) (state FeedState, err error) {
+	var checkedAt time.Time
+
+	err = s.session.Query("SELECT source, etag, last_modified, content_hash, checked_at FROM feed_state WHERE source = ?", source).
+		WithContext(ctx).
+		Scan(&state.Source, &state.ETag, &state.LastModified, &state.ContentHash, &checkedAt)
+
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return state, ErrFeedStateNotFound
+		}
+
+		return state, err
+	}
+
+	state.CheckedAt = cassandraTime(checkedAt)
+
+	return state, nil
+}
+
+func (s *defaultCassandraStore) SaveFeedState(ctx context.Context, state FeedState) (err error) {
+	err = s.session.Query(
+		"INSERT INTO feed_state (source, etag, last_modified, content_hash, checked_at) VALUES (?, ?, ?, ?, ?)",
+		state.Source,
+		state.ETag,
+		state.LastModified,
+		state.ContentHash,
+		nullTimeValue(state.CheckedAt),
+	).WithContext(ctx).Exec()
+
+	if err != nil {
+		return errors.Wrap(err, "failed to save feed state")
+	}
+
+	return nil
+}
+
+func (s *defaultCassandraStore) Observations(ctx context.Context) (obs []Observation, err error) {
+	obs = make([]Observation, 0)
+
+	// NOTE: grouping by the partition key requires Cassandra 3.10+ or ScyllaDB 3.2+
+	iter := s.session.Query("SELECT id, MIN(pub_date), MAX(pub_date), COUNT(*) FROM currency GROUP BY id").
+		WithContext(ctx).
+		Iter()
+
+	var (
+		o           Observation
+		first, last time.Time
+		count       int64
+	)
+
+	for iter.Scan(&o.ID, &first, &last, &count) {
+		o.FirstPubDate, o.LastPubDate, o.Count = cassandraTime(first), cassandraTime(last), int(count)
+		obs = append(obs, o)
+	}
+
+	if err = iter.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to load currency observations")
+	}
+
+	// partitions are ordered by tokens rather than ids
+	sort.Slice(obs, func(i, j int) bool { return obs[i].ID < obs[j].ID })
+
+	return obs, nil
+}
@@ -0,0 +1,272 @@
+package currency_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/currency/storetest"
+	"github.com/gocql/gocql"
+	"github.com/gocraft/dbr/v2"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/inf.v0"
+)
+
+// newCassandraTestStore initializes a store in a throwaway keyspace of the
+// cluster at CASSANDRA_HOSTS, i.e. a local container started by:
+// docker run -d -p 9042:9042 cassandra:4
+func newCassandraTestStore(t *testing.T) currency.Store {
+	hosts := strings.TrimSpace(os.Getenv("CASSANDRA_HOSTS"))
+	if hosts == "" {
+		t.Skip("CASSANDRA_HOSTS is not set")
+	}
+
+	a := assert.New(t)
+	ctx := context.Background()
+	keyspace := fmt.Sprintf("tetest_%d", time.Now().UnixNano())
+
+	cluster := gocql.NewCluster(strings.Split(hosts, ",")...)
+	cluster.Timeout = 10 * time.Second
+
+	session, err := cluster.CreateSession()
+	if !a.NoError(err) {
+		t.FailNow()
+	}
+
+	a.NoError(currency.InitCassandraSchema(ctx, session, keyspace, 1))
+
+	t.Cleanup(func() {
+		session.Query("DROP KEYSPACE IF EXISTS " + keyspace).Exec()
+		session.Close()
+	})
+
+	cluster.Keyspace = keyspace
+
+	ksession, err := cluster.CreateSession()
+	if !a.NoError(err) {
+		t.FailNow()
+	}
+
+	t.Cleanup(ksession.Close)
+
+	store, err := currency.NewDefaultCassandraStore(ksession)
+	a.NoError(err)
+
+	return store
+}
+
+func TestNewDefaultCassandraStore(t *testing.T) {
+	a := assert.New(t)
+
+	store, err := currency.NewDefaultCassandraStore(nil)
+	a.Equal(currency.ErrNilDatabase, err)
+	a.Nil(store)
+
+	a.Equal(currency.ErrNilDatabase, currency.InitCassandraSchema(context.Background(), nil, "tetest", 1))
+}
+
+func TestCassandraStatements(t *testing.T) {
+	a := assert.New(t)
+
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		a.NoError(err)
+		return d
+	}
+
+	created := time.Date(2020, 3, 18, 16, 0, 0, 0, time.UTC)
+	c := currency.Currency{
+		ID:        "USD",
+		Value:     decimal.RequireFromString("1.1250"),
+		PubDate:   dbr.NewNullTime(date("2020-03-18")),
+		Source:    "ecb",
+		CreatedAt: dbr.NewNullTime(created),
+	}
+
+	q, args := currency.CassandraUpsert(c)
+
+	a.Equal("INSERT INTO currency (id, pub_date, value, source, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)", q)
+	a.Len(args, 6)
+	a.Equal("USD", args[0])
+	a.Equal("2020-03-18", args[1])
+	a.Equal("1.1250", args[2].(*inf.Dec).String())
+	a.Equal("ecb", args[3])
+	a.Equal(created, args[4])
+	a.Nil(args[5])
+
+	// the latest value is inserted unless there is one
+	q, args = currency.CassandraLatestInsert(c)
+	a.Equal("INSERT INTO currency_latest (id, pub_date, value, source, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?) IF NOT EXISTS", q)
+	a.Len(args, 6)
+	a.Equal("2020-03-18", args[1])
+
+	// and updated only by a value which is at least as recent
+	c.UpdatedAt = dbr.NewNullTime(created.Add(time.Hour))
+
+	q, args = currency.CassandraLatestUpdate(c)
+	a.Equal("UPDATE currency_latest SET pub_date = ?, value = ?, source = ?, created_at = ?, updated_at = ? WHERE id = ? IF pub_date <= ?", q)
+	a.Len(args, 7)
+	a.Equal("2020-03-18", args[0])
+	a.Equal("1.1250", args[1].(*inf.Dec).String())
+	a.Equal("ecb", args[2])
+	a.Equal(created, args[3])
+	a.Equal(created.Add(time.Hour), args[4])
+	a.Equal("USD", args[5])
+	a.Equal("2020-03-18", args[6])
+
+	const selectUSD = "SELECT id, pub_date, value, source, created_at, updated_at FROM currency WHERE id = ?"
+
+	for _, tc := range []struct {
+		r     currency.Range
+		query string
+		args  []interface{}
+	}{
+		{
+			r:     currency.Range{},
+			query: selectUSD + " ORDER BY pub_date DESC",
+			args:  []interface{}{"USD"},
+		},
+		{
+			r:     currency.Range{From: date("2020-03-16"), To: date("2020-03-18"), Order: currency.OrderAsc, Limit: 2},
+			query: selectUSD + " AND pub_date >= ? AND pub_date <= ? ORDER BY pub_date ASC LIMIT ?",
+			args:  []interface{}{"USD", "2020-03-16", "2020-03-18", 2},
+		},
+		// the cursor replaces a looser lower bound when ascending
+		{
+			r:     currency.Range{From: date("2020-03-16"), After: date("2020-03-17"), Order: currency.OrderAsc},
+			query: selectUSD + " AND pub_date > ? ORDER BY pub_date ASC",
+			args:  []interface{}{"USD", "2020-03-17"},
+		},
+		// but not a tighter one
+		{
+			r:     currency.Range{From: date("2020-03-18"), After: date("2020-03-17"), Order: currency.OrderAsc},
+			query: selectUSD + " AND pub_date >= ? ORDER BY pub_date ASC",
+			args:  []interface{}{"USD", "2020-03-18"},
+		},
+		// and the upper bound when descending
+		{
+			r:     currency.Range{To: date("2020-03-18"), After: date("2020-03-18"), Order: currency.OrderDesc},
+			query: selectUSD + " AND pub_date < ? ORDER BY pub_date DESC",
+			args:  []interface{}{"USD", "2020-03-18"},
+		},
+	} {
+		q, args := currency.CassandraRange("USD", tc.r)
+		a.Equal(tc.query, q)
+		a.Equal(tc.args, args)
+	}
+}
+
+func TestDefaultCassandraStore(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	store := newCassandraTestStore(t)
+
+	date := func(s string) dbr.NullTime {
+		d, err := time.Parse("2006-01-02", s)
+		a.NoError(err)
+		return dbr.NewNullTime(d)
+	}
+
+	value := func(s string) decimal.Decimal {
+		return decimal.RequireFromString(s)
+	}
+
+	_, _, err := store.BulkCreate(ctx, nil)
+	a.Equal(currency.ErrNoData, err)
+
+	cs, stats, err := store.BulkCreate(ctx, []currency.Currency{
+		{ID: "USD", Value: value("1.1000"), PubDate: date("2020-03-16"), Source: "rss"},
+		{ID: "USD", Value: value("1.1100"), PubDate: date("2020-03-17"), Source: "rss"},
+		{ID: "USD", Value: value("1.1200"), PubDate: date("2020-03-18"), Source: "rss"},
+		{ID: "JPY", Value: value("118.5000"), PubDate: date("2020-03-18"), Source: "rss"},
+		{ID: "GBP", Value: value("0.9000"), PubDate: date("2020-03-17"), Source: "rss"},
+	})
+
+	a.NoError(err)
+	a.Len(cs, 5)
+	a.Equal(currency.UpsertStats{Inserted: 5}, stats)
+
+	// repeating with an updated, an unchanged and an older value
+	_, stats, err = store.BulkCreate(ctx, []currency.Currency{
+		{ID: "USD", Value: value("1.1250"), PubDate: date("2020-03-18"), Source: "ecb"},
+		{ID: "JPY", Value: value("118.5000"), PubDate: date("2020-03-18"), Source: "rss"},
+		{ID: "JPY", Value: value("118.0000"), PubDate: date("2020-03-13"), Source: "rss"},
+	})
+
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Inserted: 1, Updated: 1, Unchanged: 1}, stats)
+
+	// latest values, which exclude GBP of an earlier date
+	cs, err = store.AllLatest(ctx)
+	a.NoError(err)
+	a.Len(cs, 2)
+	a.Equal("JPY", cs[0].ID)
+	a.True(value("118.5").Equal(cs[0].Value))
+	a.Equal("USD", cs[1].ID)
+	a.True(value("1.125").Equal(cs[1].Value))
+	a.Equal("ecb", cs[1].Source)
+	a.True(cs[1].UpdatedAt.Valid)
+
+	// all values, newest first
+	cs, err = store.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Len(cs, 3)
+	a.Equal("2020-03-18", cs[0].PubDate.Time.Format("2006-01-02"))
+	a.Equal("2020-03-16", cs[2].PubDate.Time.Format("2006-01-02"))
+	a.False(cs[2].UpdatedAt.Valid)
+
+	// as of a date
+	c, err := store.AsOf(ctx, "USD", date("2020-03-17").Time.Add(12*time.Hour))
+	a.NoError(err)
+	a.True(value("1.11").Equal(c.Value))
+
+	_, err = store.AsOf(ctx, "USD", date("2020-03-15").Time)
+	a.Equal(currency.ErrCurrencyNotFound, err)
+
+	// ranges, continuing after a cursor
+	cs, err = store.RangeByID(ctx, "USD", currency.Range{From: date("2020-03-16").Time, Order: currency.OrderAsc, Limit: 2})
+	a.NoError(err)
+	a.Len(cs, 2)
+	a.Equal("2020-03-16", cs[0].PubDate.Time.Format("2006-01-02"))
+
+	cs, err = store.RangeByID(ctx, "USD", currency.Range{From: date("2020-03-16").Time, After: cs[1].PubDate.Time, Order: currency.OrderAsc, Limit: 2})
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal("2020-03-18", cs[0].PubDate.Time.Format("2006-01-02"))
+
+	cs, err = store.RangeByID(ctx, "USD", currency.Range{To: date("2020-03-18").Time, After: date("2020-03-18").Time, Order: currency.OrderDesc})
+	a.NoError(err)
+	a.Len(cs, 2)
+	a.Equal("2020-03-17", cs[0].PubDate.Time.Format("2006-01-02"))
+
+	// observations
+	obs, err := store.(currency.ObservationStore).Observations(ctx)
+	a.NoError(err)
+	a.Len(obs, 3)
+	a.Equal("GBP", obs[0].ID)
+	a.Equal("JPY", obs[1].ID)
+	a.Equal(2, obs[1].Count)
+	a.Equal("2020-03-13", obs[1].FirstPubDate.Time.Format("2006-01-02"))
+
+	// feed state
+	fs := store.(currency.FeedStateStore)
+
+	_, err = fs.FeedState(ctx, "rss")
+	a.Equal(currency.ErrFeedStateNotFound, err)
+
+	a.NoError(fs.SaveFeedState(ctx, currency.FeedState{Source: "rss", ETag: `"abc"`, CheckedAt: dbr.NewNullTime(time.Now())}))
+
+	state, err := fs.FeedState(ctx, "rss")
+	a.NoError(err)
+	a.Equal(`"abc"`, state.ETag)
+	a.True(state.CheckedAt.Valid)
+}
+
+func TestCassandraStore_Conformance(t *testing.T) {
+	storetest.Run(t, newCassandraTestStore)
+}
@@ -2,6 +2,7 @@ package currency
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -9,79 +10,73 @@ import (
 )
 
 type defaultMemoryStore struct {
-	cs map[string]map[string]Currency
+	cs     map[string]map[string]Currency
+	states map[string]FeedState
+	runs   []ImportReport
+	rs     []Rejection
+	ds     []Discrepancy
+	alerts []AlertRule
+	seq    int64
 	sync.RWMutex
 }
 
 func NewMemoryStore() Store {
 	return &defaultMemoryStore{
-		cs: make(map[string]map[string]Currency),
+		cs:     make(map[string]map[string]Currency),
+		states: make(map[string]FeedState),
+		runs:   make([]ImportReport, 0),
+		rs:     make([]Rejection, 0),
+		ds:     make([]Discrepancy, 0),
+		alerts: make([]AlertRule, 0),
 	}
 }
 
-func (s defaultMemoryStore) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, err error) {
+func (s *defaultMemoryStore) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, stats UpsertStats, err error) {
 	if s.cs == nil {
 		panic("in-memory store is nil")
 	}
 
+	// there must be something first
 	if len(cs) == 0 {
-		return cs, nil
+		return nil, stats, ErrNoData
 	}
 
-	s.Lock()
-
-	// adding given items to the runtime cache
-	for k := range cs {
-		c := &cs[k]
-
-		pubDateKey := c.PubDate.Time.Format("2006-02-01")
-
-		// initializing inner map if it hasn't been done yet
-		if s.cs[pubDateKey] == nil {
-			s.cs[pubDateKey] = make(map[string]Currency)
-		}
-
-		// assigning timestamp depending on whether this
-		// currency is already in the store
-		if _, ok := s.cs[pubDateKey][c.ID]; !ok {
-			c.CreatedAt = dbr.NewNullTime(time.Now())
-		} else {
-			c.UpdatedAt = dbr.NewNullTime(time.Now())
-		}
-
-		// caching currency
-		s.cs[pubDateKey][c.ID] = *c
+	if err = ctx.Err(); err != nil {
+		return nil, stats, err
 	}
 
-	s.Unlock()
+	s.Lock()
+	defer s.Unlock()
 
-	return cs, nil
+	stats = s.stamp(cs)
+	s.put(cs)
+
+	return cs, stats, nil
 }
 
-func (s defaultMemoryStore) AllLatest(ctx context.Context) (cs []Currency, err error) {
+func (s *defaultMemoryStore) AllLatest(ctx context.Context) (cs []Currency, err error) {
 	if s.cs == nil {
 		panic("in-memory store is nil")
 	}
 
-	//---------------------------------------------------------------------------
-	// finding latest stored publication date; the initial date is -100 years
-	// (I hope I could debug in 100 years when it could become a problem) ^^
-	//---------------------------------------------------------------------------
-	latestPubDate := time.Now().AddDate(-100, 0, 0)
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
 
-	for i := range s.cs {
-		for j := range s.cs[i] {
-			// first found currency's pubdate
-			cpd := s.cs[i][j].PubDate.Time.Local()
+	// NOTE: keys are formatted dates, which are ordered as strings
+	latestPubDateKey := ""
 
-			if latestPubDate.Before(cpd) {
-				latestPubDate = cpd
-			}
+	for pubDateKey := range s.cs {
+		if len(s.cs[pubDateKey]) > 0 && pubDateKey > latestPubDateKey {
+			latestPubDateKey = pubDateKey
 		}
 	}
 
 	// contains all currencies of the latest pub. date
-	latestDay := s.cs[latestPubDate.Format("2006-02-01")]
+	latestDay := s.cs[latestPubDateKey]
 
 	// initialzing result slice
 	cs = make([]Currency, 0, len(latestDay))
@@ -91,14 +86,25 @@ func (s defaultMemoryStore) AllLatest(ctx context.Context) (cs []Currency, err e
 		cs = append(cs, latestDay[k])
 	}
 
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].ID < cs[j].ID
+	})
+
 	return cs, nil
 }
 
-func (s defaultMemoryStore) AllByID(ctx context.Context, id string) (cs []Currency, err error) {
+func (s *defaultMemoryStore) AllByID(ctx context.Context, id string) (cs []Currency, err error) {
 	if s.cs == nil {
 		panic("in-memory store is nil")
 	}
 
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
 	// NOTE: not checking the validity of an ID
 
 	// initialzing result
@@ -110,5 +116,369 @@ func (s defaultMemoryStore) AllByID(ctx context.Context, id string) (cs []Curren
 		}
 	}
 
+	// latest first
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].PubDate.Time.After(cs[j].PubDate.Time)
+	})
+
 	return cs, nil
 }
+
+// restore puts given currencies into the store as they are, along with
+// their timestamps (i.e. when replaying a persisted state)
+func (s *defaultMemoryStore) restore(cs []Currency) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.put(cs)
+}
+
+// stamp assigns timestamps to given currencies depending on whether they're
+// already stored (or precede in the batch), and counts them, storing nothing
+// NOTE: the caller must hold the lock
+func (s *defaultMemoryStore) stamp(cs []Currency) (stats UpsertStats) {
+	batch := make(map[string]Currency, len(cs))
+
+	for k := range cs {
+		c := &cs[k]
+
+		key := c.PubDate.Time.Format(dateLayout) + "/" + c.ID
+
+		existing, ok := batch[key]
+		if !ok {
+			existing, ok = s.cs[c.PubDate.Time.Format(dateLayout)][c.ID]
+		}
+
+		switch {
+		case !ok:
+			c.CreatedAt = dbr.NewNullTime(time.Now())
+			stats.Inserted++
+		case existing.Value.Equal(c.Value) && existing.Source == c.Source:
+			c.CreatedAt, c.UpdatedAt = existing.CreatedAt, existing.UpdatedAt
+			stats.Unchanged++
+		default:
+			c.CreatedAt, c.UpdatedAt = existing.CreatedAt, dbr.NewNullTime(time.Now())
+			stats.Updated++
+		}
+
+		batch[key] = *c
+	}
+
+	return stats
+}
+
+// put stores given currencies as they are
+// NOTE: the caller must hold the lock
+func (s *defaultMemoryStore) put(cs []Currency) {
+	for _, c := range cs {
+		pubDateKey := c.PubDate.Time.Format(dateLayout)
+
+		// initializing inner map if it hasn't been done yet
+		if s.cs[pubDateKey] == nil {
+			s.cs[pubDateKey] = make(map[string]Currency)
+		}
+
+		s.cs[pubDateKey][c.ID] = c
+	}
+}
+
+// all returns every stored currency, ordered by date and ID
+func (s *defaultMemoryStore) all() (cs []Currency) {
+	s.RLock()
+	defer s.RUnlock()
+
+	cs = make([]Currency, 0)
+
+	for pubDate := range s.cs {
+		for _, c := range s.cs[pubDate] {
+			cs = append(cs, c)
+		}
+	}
+
+	sort.Slice(cs, func(i, j int) bool {
+		if !cs[i].PubDate.Time.Equal(cs[j].PubDate.Time) {
+			return cs[i].PubDate.Time.Before(cs[j].PubDate.Time)
+		}
+
+		return cs[i].ID < cs[j].ID
+	})
+
+	return cs
+}
+
+func (s *defaultMemoryStore) AsOf(ctx context.Context, id string, date time.Time) (c Currency, err error) {
+	if err = ctx.Err(); err != nil {
+		return c, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	// NOTE: comparing formatted dates to ignore time and location
+	day := date.Format(dateLayout)
+	found := false
+
+	for pubDate := range s.cs {
+		v, ok := s.cs[pubDate][id]
+		if !ok || v.PubDate.Time.Format(dateLayout) > day {
+			continue
+		}
+
+		if !found || v.PubDate.Time.After(c.PubDate.Time) {
+			c, found = v, true
+		}
+	}
+
+	if !found {
+		return c, ErrCurrencyNotFound
+	}
+
+	return c, nil
+}
+
+func (s *defaultMemoryStore) RangeByID(ctx context.Context, id string, r Range) (cs []Currency, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	// NOTE: comparing formatted dates to ignore time and location
+	from, to, after := r.From.Format(dateLayout), r.To.Format(dateLayout), r.After.Format(dateLayout)
+
+	cs = make([]Currency, 0)
+
+	for pubDate := range s.cs {
+		c, ok := s.cs[pubDate][id]
+		if !ok {
+			continue
+		}
+
+		day := c.PubDate.Time.Format(dateLayout)
+
+		switch {
+		case !r.From.IsZero() && day < from:
+			continue
+		case !r.To.IsZero() && day > to:
+			continue
+		case !r.After.IsZero() && r.Order == OrderAsc && day <= after:
+			continue
+		case !r.After.IsZero() && r.Order != OrderAsc && day >= after:
+			continue
+		}
+
+		cs = append(cs, c)
+	}
+
+	sort.Slice(cs, func(i, j int) bool {
+		if r.Order == OrderAsc {
+			return cs[i].PubDate.Time.Before(cs[j].PubDate.Time)
+		}
+
+		return cs[i].PubDate.Time.After(cs[j].PubDate.Time)
+	})
+
+	if r.Limit > 0 && len(cs) > r.Limit {
+		cs = cs[:r.Limit]
+	}
+
+	return cs, nil
+}
+
+func (s *defaultMemoryStore) FeedState(ctx context.Context, source string) (state FeedState, err error) {
+	s.RLock()
+	state, ok := s.states[source]
+	s.RUnlock()
+
+	if !ok {
+		return state, ErrFeedStateNotFound
+	}
+
+	return state, nil
+}
+
+func (s *defaultMemoryStore) SaveFeedState(ctx context.Context, state FeedState) (err error) {
+	s.Lock()
+	s.states[state.Source] = state
+	s.Unlock()
+
+	return nil
+}
+
+func (s *defaultMemoryStore) CreateImportRun(ctx context.Context, r ImportReport) (_ ImportReport, err error) {
+	s.Lock()
+	r.ID = int64(len(s.runs) + 1)
+	s.runs = append(s.runs, r)
+	s.Unlock()
+
+	return r, nil
+}
+
+func (s *defaultMemoryStore) ImportRuns(ctx context.Context, limit int) (rs []ImportReport, err error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if limit <= 0 || limit > len(s.runs) {
+		limit = len(s.runs)
+	}
+
+	// latest first
+	rs = make([]ImportReport, 0, limit)
+	for i := len(s.runs) - 1; i >= len(s.runs)-limit; i-- {
+		rs = append(rs, s.runs[i])
+	}
+
+	return rs, nil
+}
+
+func (s *defaultMemoryStore) Quarantine(ctx context.Context, rs []Rejection) (err error) {
+	s.Lock()
+	for _, r := range rs {
+		r.ID = int64(len(s.rs) + 1)
+		s.rs = append(s.rs, r)
+	}
+	s.Unlock()
+
+	return nil
+}
+
+func (s *defaultMemoryStore) Rejections(ctx context.Context, limit int) (rs []Rejection, err error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if limit <= 0 || limit > len(s.rs) {
+		limit = len(s.rs)
+	}
+
+	// latest first
+	rs = make([]Rejection, 0, limit)
+	for i := len(s.rs) - 1; i >= len(s.rs)-limit; i-- {
+		rs = append(rs, s.rs[i])
+	}
+
+	return rs, nil
+}
+
+func (s *defaultMemoryStore) CreateDiscrepancies(ctx context.Context, ds []Discrepancy) (err error) {
+	s.Lock()
+	for _, d := range ds {
+		d.ID = int64(len(s.ds) + 1)
+		s.ds = append(s.ds, d)
+	}
+	s.Unlock()
+
+	return nil
+}
+
+func (s *defaultMemoryStore) Discrepancies(ctx context.Context, limit int) (ds []Discrepancy, err error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if limit <= 0 || limit > len(s.ds) {
+		limit = len(s.ds)
+	}
+
+	// latest first
+	ds = make([]Discrepancy, 0, limit)
+	for i := len(s.ds) - 1; i >= len(s.ds)-limit; i-- {
+		ds = append(ds, s.ds[i])
+	}
+
+	return ds, nil
+}
+
+func (s *defaultMemoryStore) Observations(ctx context.Context) (obs []Observation, err error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	observed := make(map[string]*Observation)
+
+	for pubDate := range s.cs {
+		for id, c := range s.cs[pubDate] {
+			o, ok := observed[id]
+			if !ok {
+				o = &Observation{ID: id, FirstPubDate: c.PubDate, LastPubDate: c.PubDate}
+				observed[id] = o
+			}
+
+			if c.PubDate.Time.Before(o.FirstPubDate.Time) {
+				o.FirstPubDate = c.PubDate
+			}
+
+			if c.PubDate.Time.After(o.LastPubDate.Time) {
+				o.LastPubDate = c.PubDate
+			}
+
+			o.Count++
+		}
+	}
+
+	obs = make([]Observation, 0, len(observed))
+	for _, o := range observed {
+		obs = append(obs, *o)
+	}
+
+	sort.Slice(obs, func(i, j int) bool {
+		return obs[i].ID < obs[j].ID
+	})
+
+	return obs, nil
+}
+
+func (s *defaultMemoryStore) Aggregate(ctx context.Context, id string, period Period, from, to time.Time) (as []Aggregate, err error) {
+	cs, err := s.RangeByID(ctx, id, Range{From: from, To: to, Order: OrderAsc})
+	if err != nil {
+		return nil, err
+	}
+
+	return aggregateValues(cs, period), nil
+}
+
+func (s *defaultMemoryStore) CreateAlertRule(ctx context.Context, r AlertRule) (_ AlertRule, err error) {
+	s.Lock()
+	s.seq++
+	r.ID = s.seq
+	s.alerts = append(s.alerts, r)
+	s.Unlock()
+
+	return r, nil
+}
+
+func (s *defaultMemoryStore) AlertRules(ctx context.Context) (rs []AlertRule, err error) {
+	s.RLock()
+	rs = make([]AlertRule, len(s.alerts))
+	copy(rs, s.alerts)
+	s.RUnlock()
+
+	return rs, nil
+}
+
+func (s *defaultMemoryStore) DeleteAlertRule(ctx context.Context, id int64) (err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i := range s.alerts {
+		if s.alerts[i].ID == id {
+			s.alerts = append(s.alerts[:i], s.alerts[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrAlertRuleNotFound
+}
+
+func (s *defaultMemoryStore) AlertFired(ctx context.Context, id int64, firedAt time.Time, fingerprint string) (err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i := range s.alerts {
+		if s.alerts[i].ID == id {
+			s.alerts[i].LastFiredAt = dbr.NewNullTime(firedAt)
+			s.alerts[i].LastFingerprint = fingerprint
+			return nil
+		}
+	}
+
+	return ErrAlertRuleNotFound
+}
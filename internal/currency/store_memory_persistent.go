@@ -0,0 +1,458 @@
+package currency
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// SyncPolicy represents when the log of a persistent memory store is flushed to
+// disk; every batch is written to the file right away, so it survives a crash
+// of the process regardless of the policy, but not a crash of the system
+type SyncPolicy string
+
+// sync policies
+const (
+	// SyncAlways flushes the log after every batch before returning
+	SyncAlways SyncPolicy = "always"
+
+	// SyncInterval flushes the log in the background, once in a while
+	SyncInterval SyncPolicy = "interval"
+
+	// SyncNever leaves flushing to the operating system
+	SyncNever SyncPolicy = "never"
+)
+
+// defaults of the persistent memory store
+const (
+	DefaultSyncInterval  = time.Second
+	DefaultSnapshotEvery = 100
+)
+
+// files of the persistent memory store
+const (
+	memoryLogFile      = "wal.log"
+	memorySnapshotFile = "snapshot.json"
+)
+
+// memoryBatch is a batch of stored currencies, which is either a record of the
+// log or the snapshot, where seq is the number of the last batch it includes
+type memoryBatch struct {
+	Seq        int64      `json:"seq"`
+	Currencies []Currency `json:"currencies"`
+}
+
+// PersistenceOption configures the persistent memory store
+type PersistenceOption func(s *persistentMemoryStore) error
+
+// WithSyncPolicy sets when the log is flushed to disk (SyncAlways by default)
+func WithSyncPolicy(p SyncPolicy) PersistenceOption {
+	return func(s *persistentMemoryStore) error {
+		switch p {
+		case SyncAlways, SyncInterval, SyncNever:
+			s.policy = p
+			return nil
+		default:
+			return errors.Errorf("unsupported sync policy: %s", p)
+		}
+	}
+}
+
+// WithSyncInterval sets how often the log is flushed by SyncInterval
+func WithSyncInterval(d time.Duration) PersistenceOption {
+	return func(s *persistentMemoryStore) error {
+		if d <= 0 {
+			return errors.Errorf("invalid sync interval: %s", d)
+		}
+
+		s.interval = d
+
+		return nil
+	}
+}
+
+// WithSnapshotEvery sets the number of logged batches after which the
+// whole state is written into a snapshot and the log is truncated
+func WithSnapshotEvery(batches int) PersistenceOption {
+	return func(s *persistentMemoryStore) error {
+		if batches < 1 {
+			return errors.Errorf("invalid number of batches between snapshots: %d", batches)
+		}
+
+		s.snapshotEvery = batches
+
+		return nil
+	}
+}
+
+// WithPersistenceLogger sets the logger of failures which aren't returned
+// (i.e. of a snapshot, which is retried after the next batch)
+func WithPersistenceLogger(logger *zap.Logger) PersistenceOption {
+	return func(s *persistentMemoryStore) error {
+		if logger == nil {
+			return errors.New("logger is nil")
+		}
+
+		s.logger = logger
+
+		return nil
+	}
+}
+
+// persistentMemoryStore is the memory store, which appends stored batches
+// of currencies to a log and periodically compacts it into a snapshot
+// NOTE: only currencies are persisted, feed states, import runs, rejections,
+// discrepancies and alert rules are kept in memory as usual
+type persistentMemoryStore struct {
+	*defaultMemoryStore
+
+	dir           string
+	policy        SyncPolicy
+	interval      time.Duration
+	snapshotEvery int
+	logger        *zap.Logger
+
+	log       *os.File
+	lastBatch int64
+	pending   int
+	dirty     bool
+	syncErr   error
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+}
+
+// NewPersistentMemoryStore initializes the memory store persisted in a given
+// directory, replaying the snapshot and the log which are already there
+// NOTE: the store must be closed to flush the log, see io.Closer
+func NewPersistentMemoryStore(dir string, opts ...PersistenceOption) (Store, error) {
+	s := &persistentMemoryStore{
+		defaultMemoryStore: NewMemoryStore().(*defaultMemoryStore),
+		dir:                dir,
+		policy:             SyncAlways,
+		interval:           DefaultSyncInterval,
+		snapshotEvery:      DefaultSnapshotEvery,
+		logger:             zap.NewNop(),
+		done:               make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create data directory: %s", dir)
+	}
+
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+
+	if s.policy == SyncInterval {
+		s.wg.Add(1)
+		go s.syncPeriodically()
+	}
+
+	return s, nil
+}
+
+// replay restores the snapshot and the batches logged after it, and opens the log
+func (s *persistentMemoryStore) replay() (err error) {
+	// snapshot
+	payload, err := ioutil.ReadFile(filepath.Join(s.dir, memorySnapshotFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return errors.Wrap(err, "failed to read snapshot")
+	default:
+		var snapshot memoryBatch
+		if err = json.Unmarshal(payload, &snapshot); err != nil {
+			return errors.Wrap(err, "failed to parse snapshot")
+		}
+
+		s.restore(snapshot.Currencies)
+		s.lastBatch = snapshot.Seq
+	}
+
+	// log
+	s.log, err = os.OpenFile(filepath.Join(s.dir, memoryLogFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open log")
+	}
+
+	// offset of the end of the last valid record
+	var offset int64
+
+	r := bufio.NewReader(s.log)
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+
+		batch, perr := parseMemoryRecord(line)
+		if err != nil || perr != nil {
+			// NOTE: a broken record at the end is a write torn by a crash,
+			// which is discarded, but anything after it means corruption
+			if _, err := r.Peek(1); err != io.EOF {
+				s.log.Close()
+				return errors.Wrapf(ErrCorruptLog, "invalid record at offset %d", offset)
+			}
+
+			break
+		}
+
+		offset += int64(len(line))
+
+		// batches which are already in the snapshot
+		if batch.Seq <= s.lastBatch {
+			continue
+		}
+
+		s.restore(batch.Currencies)
+		s.lastBatch = batch.Seq
+		s.pending++
+	}
+
+	if err = s.log.Truncate(offset); err != nil {
+		s.log.Close()
+		return errors.Wrap(err, "failed to truncate log")
+	}
+
+	return nil
+}
+
+// parseMemoryRecord parses a log record, which is a batch
+// encoded as JSON and prefixed with its checksum
+func parseMemoryRecord(line []byte) (batch memoryBatch, err error) {
+	parts := bytes.SplitN(bytes.TrimSuffix(line, []byte("\n")), []byte(" "), 2)
+	if len(parts) != 2 {
+		return batch, errors.New("missing checksum")
+	}
+
+	sum, err := strconv.ParseUint(string(parts[0]), 16, 32)
+	if err != nil || uint32(sum) != crc32.ChecksumIEEE(parts[1]) {
+		return batch, errors.New("checksum mismatch")
+	}
+
+	if err = json.Unmarshal(parts[1], &batch); err != nil {
+		return batch, err
+	}
+
+	return batch, nil
+}
+
+func (s *persistentMemoryStore) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, stats UpsertStats, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log == nil {
+		return nil, stats, ErrClosedStore
+	}
+
+	// reporting a failure of the background sync
+	if s.syncErr != nil {
+		err, s.syncErr = s.syncErr, nil
+		return nil, stats, errors.Wrap(err, "failed to sync log")
+	}
+
+	// there must be something first
+	if len(cs) == 0 {
+		return nil, stats, ErrNoData
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, stats, err
+	}
+
+	// NOTE: timestamps are assigned before the batch is logged, so it's replayed
+	// as it is, and it's stored in memory only once it's logged, so a failed
+	// write doesn't leave values in memory which would be lost on restart
+	// (writes are serialized by the store's own lock, so the stamps hold)
+	s.defaultMemoryStore.RLock()
+	stats = s.stamp(cs)
+	s.defaultMemoryStore.RUnlock()
+
+	// nothing has changed
+	if stats.Inserted == 0 && stats.Updated == 0 {
+		return cs, stats, nil
+	}
+
+	if err = s.append(memoryBatch{Seq: s.lastBatch + 1, Currencies: cs}); err != nil {
+		return nil, stats, err
+	}
+
+	s.restore(cs)
+
+	// NOTE: the batch is already stored, so a failed snapshot doesn't fail it,
+	// the log is kept as it is, and the snapshot is retried after the next batch
+	if s.pending >= s.snapshotEvery {
+		if err := s.snapshot(); err != nil {
+			s.logger.Error("failed to snapshot memory store", zap.String("dir", s.dir), zap.Error(err))
+		}
+	}
+
+	return cs, stats, nil
+}
+
+// append writes a batch to the log
+// NOTE: a failed write is cut off the log, so it holds neither a batch
+// which isn't stored in memory, nor a torn record followed by others
+func (s *persistentMemoryStore) append(batch memoryBatch) (err error) {
+	payload, err := json.Marshal(batch)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode batch")
+	}
+
+	record := fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(payload), payload)
+
+	info, err := s.log.Stat()
+	if err != nil {
+		return errors.Wrap(err, "failed to stat log")
+	}
+
+	if _, err = s.log.WriteString(record); err != nil {
+		s.log.Truncate(info.Size())
+		return errors.Wrap(err, "failed to write log")
+	}
+
+	if s.policy == SyncAlways {
+		if err = s.log.Sync(); err != nil {
+			s.log.Truncate(info.Size())
+			return errors.Wrap(err, "failed to sync log")
+		}
+	} else {
+		s.dirty = true
+	}
+
+	s.lastBatch = batch.Seq
+	s.pending++
+
+	return nil
+}
+
+// sync flushes the log to disk
+func (s *persistentMemoryStore) sync() (err error) {
+	if !s.dirty {
+		return nil
+	}
+
+	if err = s.log.Sync(); err != nil {
+		return errors.Wrap(err, "failed to sync log")
+	}
+
+	s.dirty = false
+
+	return nil
+}
+
+// snapshot writes the whole state into a snapshot and truncates the log
+// NOTE: the snapshot replaces the previous one atomically, and if the log
+// isn't truncated afterwards, its batches are skipped by their numbers
+func (s *persistentMemoryStore) snapshot() (err error) {
+	payload, err := json.Marshal(memoryBatch{Seq: s.lastBatch, Currencies: s.all()})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode snapshot")
+	}
+
+	path := filepath.Join(s.dir, memorySnapshotFile)
+
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create snapshot")
+	}
+
+	if _, err = f.Write(payload); err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to write snapshot")
+	}
+
+	if err = os.Rename(path+".tmp", path); err != nil {
+		return errors.Wrap(err, "failed to replace snapshot")
+	}
+
+	// the rename itself is durable once the directory is synced
+	if dir, err := os.Open(s.dir); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	if err = s.log.Truncate(0); err != nil {
+		return errors.Wrap(err, "failed to truncate log")
+	}
+
+	s.pending = 0
+	s.dirty = true
+
+	return s.sync()
+}
+
+// syncPeriodically flushes the log every interval until the store is closed
+func (s *persistentMemoryStore) syncPeriodically() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.log != nil {
+				if err := s.sync(); err != nil {
+					s.syncErr = err
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close flushes and closes the log, the store can't be written afterwards
+func (s *persistentMemoryStore) Close() (err error) {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log == nil {
+		return nil
+	}
+
+	err = s.sync()
+
+	if cerr := s.log.Close(); err == nil && cerr != nil {
+		err = errors.Wrap(cerr, "failed to close log")
+	}
+
+	s.log = nil
+
+	return err
+}
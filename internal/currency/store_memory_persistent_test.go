@@ -0,0 +1,287 @@
+package currency_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/currency/storetest"
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistentMemoryStore(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "tetest")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	batch := func(date string, values ...string) []currency.Currency {
+		d, err := time.Parse("2006-01-02", date)
+		a.NoError(err)
+
+		ids := []string{"USD", "JPY"}
+		cs := make([]currency.Currency, len(values))
+
+		for i, v := range values {
+			cs[i] = currency.Currency{ID: ids[i], Value: decimal.RequireFromString(v), PubDate: dbr.NewNullTime(d), Source: "rss"}
+		}
+
+		return cs
+	}
+
+	store, err := currency.NewPersistentMemoryStore(dir, currency.WithSnapshotEvery(3))
+	a.NoError(err)
+
+	_, stats, err := store.BulkCreate(ctx, batch("2020-03-17", "1.1100", "118.0000"))
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Inserted: 2}, stats)
+
+	_, stats, err = store.BulkCreate(ctx, batch("2020-03-18", "1.1200", "118.5000"))
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Inserted: 2}, stats)
+
+	// unchanged batches aren't logged
+	_, stats, err = store.BulkCreate(ctx, batch("2020-03-18", "1.1200", "118.5000"))
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Unchanged: 2}, stats)
+
+	usd, err := store.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Len(usd, 2)
+
+	a.NoError(store.(io.Closer).Close())
+	a.NoError(store.(io.Closer).Close())
+
+	_, _, err = store.BulkCreate(ctx, batch("2020-03-19", "1.0801"))
+	a.Equal(currency.ErrClosedStore, err)
+
+	// replaying the log, along with timestamps
+	store, err = currency.NewPersistentMemoryStore(dir, currency.WithSnapshotEvery(3))
+	a.NoError(err)
+
+	cs, err := store.AllLatest(ctx)
+	a.NoError(err)
+	a.Len(cs, 2)
+
+	replayed, err := store.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Len(replayed, 2)
+
+	for _, c := range replayed {
+		for _, o := range usd {
+			if c.PubDate.Time.Equal(o.PubDate.Time) {
+				a.True(c.Value.Equal(o.Value))
+				a.True(c.CreatedAt.Time.Equal(o.CreatedAt.Time))
+			}
+		}
+	}
+
+	// the third logged batch is compacted into a snapshot
+	_, stats, err = store.BulkCreate(ctx, batch("2020-03-18", "1.1250"))
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Updated: 1}, stats)
+
+	info, err := os.Stat(filepath.Join(dir, "wal.log"))
+	a.NoError(err)
+	a.Zero(info.Size())
+
+	_, err = os.Stat(filepath.Join(dir, "snapshot.json"))
+	a.NoError(err)
+
+	_, _, err = store.BulkCreate(ctx, batch("2020-03-19", "1.0801"))
+	a.NoError(err)
+	a.NoError(store.(io.Closer).Close())
+
+	// a record torn by a crash is discarded
+	f, err := os.OpenFile(filepath.Join(dir, "wal.log"), os.O_WRONLY|os.O_APPEND, 0644)
+	a.NoError(err)
+	_, err = f.WriteString(`1234abcd {"seq": 5, "curr`)
+	a.NoError(err)
+	a.NoError(f.Close())
+
+	store, err = currency.NewPersistentMemoryStore(dir)
+	a.NoError(err)
+
+	c, err := store.AsOf(ctx, "USD", time.Date(2020, 3, 18, 0, 0, 0, 0, time.UTC))
+	a.NoError(err)
+	a.Equal("1.125", c.Value.String())
+	a.True(c.UpdatedAt.Valid)
+
+	cs, err = store.AllLatest(ctx)
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal("1.0801", cs[0].Value.String())
+
+	a.NoError(store.(io.Closer).Close())
+
+	// but a broken record followed by others is corruption
+	payload, err := ioutil.ReadFile(filepath.Join(dir, "wal.log"))
+	a.NoError(err)
+	a.NoError(ioutil.WriteFile(filepath.Join(dir, "wal.log"), append([]byte("00000000 {}\n"), payload...), 0644))
+
+	_, err = currency.NewPersistentMemoryStore(dir)
+	a.Equal(currency.ErrCorruptLog, errors.Cause(err))
+}
+
+func TestPersistentMemoryStore_SyncPolicy(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "tetest")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	_, err = currency.NewPersistentMemoryStore(dir, currency.WithSyncPolicy("sometimes"))
+	a.Error(err)
+
+	_, err = currency.NewPersistentMemoryStore(dir, currency.WithSyncInterval(0))
+	a.Error(err)
+
+	_, err = currency.NewPersistentMemoryStore(dir, currency.WithSnapshotEvery(0))
+	a.Error(err)
+
+	for _, p := range []currency.SyncPolicy{currency.SyncAlways, currency.SyncInterval, currency.SyncNever} {
+		store, err := currency.NewPersistentMemoryStore(dir, currency.WithSyncPolicy(p), currency.WithSyncInterval(time.Millisecond))
+		a.NoError(err)
+
+		_, _, err = store.BulkCreate(ctx, []currency.Currency{
+			{ID: "USD", Value: decimal.RequireFromString("1.1"), PubDate: dbr.NewNullTime(time.Now()), Source: string(p)},
+		})
+		a.NoError(err)
+
+		time.Sleep(5 * time.Millisecond)
+		a.NoError(store.(io.Closer).Close())
+	}
+
+	// the last source wins
+	store, err := currency.NewPersistentMemoryStore(dir)
+	a.NoError(err)
+
+	cs, err := store.AllLatest(ctx)
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal(string(currency.SyncNever), cs[0].Source)
+}
+
+func TestPersistentMemoryStore_FailedWrite(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "tetest")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	value := func(date, v string) []currency.Currency {
+		d, err := time.Parse("2006-01-02", date)
+		a.NoError(err)
+
+		return []currency.Currency{{ID: "USD", Value: decimal.RequireFromString(v), PubDate: dbr.NewNullTime(d), Source: "rss"}}
+	}
+
+	store, err := currency.NewPersistentMemoryStore(dir)
+	a.NoError(err)
+
+	_, _, err = store.BulkCreate(ctx, value("2020-03-17", "1.1100"))
+	a.NoError(err)
+
+	// a batch which isn't logged isn't stored in memory either
+	a.NoError(currency.BreakMemoryLog(store))
+
+	_, _, err = store.BulkCreate(ctx, value("2020-03-18", "1.1200"))
+	a.Error(err)
+
+	_, _, err = store.BulkCreate(ctx, value("2020-03-17", "1.1150"))
+	a.Error(err)
+
+	cs, err := store.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal("1.11", cs[0].Value.String())
+
+	store.(io.Closer).Close()
+
+	// which is what is replayed after a restart
+	store, err = currency.NewPersistentMemoryStore(dir)
+	a.NoError(err)
+	defer store.(io.Closer).Close()
+
+	cs, err = store.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal("1.11", cs[0].Value.String())
+}
+
+func TestPersistentMemoryStore_FailedSnapshot(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "tetest")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	value := func(date, v string) []currency.Currency {
+		d, err := time.Parse("2006-01-02", date)
+		a.NoError(err)
+
+		return []currency.Currency{{ID: "USD", Value: decimal.RequireFromString(v), PubDate: dbr.NewNullTime(d), Source: "rss"}}
+	}
+
+	// a directory in place of the temporary snapshot file fails the snapshot
+	tmp := filepath.Join(dir, "snapshot.json.tmp")
+	a.NoError(os.Mkdir(tmp, 0755))
+
+	store, err := currency.NewPersistentMemoryStore(dir, currency.WithSnapshotEvery(1))
+	a.NoError(err)
+	defer store.(io.Closer).Close()
+
+	// the batch is stored regardless
+	cs, stats, err := store.BulkCreate(ctx, value("2020-03-17", "1.1100"))
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal(currency.UpsertStats{Inserted: 1}, stats)
+
+	_, err = os.Stat(filepath.Join(dir, "snapshot.json"))
+	a.True(os.IsNotExist(err))
+
+	// and the snapshot is retried after the next one
+	a.NoError(os.Remove(tmp))
+
+	_, stats, err = store.BulkCreate(ctx, value("2020-03-18", "1.1200"))
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Inserted: 1}, stats)
+
+	_, err = os.Stat(filepath.Join(dir, "snapshot.json"))
+	a.NoError(err)
+
+	usd, err := store.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Len(usd, 2)
+}
+
+func TestPersistentMemoryStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) currency.Store {
+		dir, err := ioutil.TempDir("", "tetest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		store, err := currency.NewPersistentMemoryStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.(io.Closer).Close() })
+
+		return store
+	})
+}
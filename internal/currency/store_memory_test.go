@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/currency/storetest"
 	"github.com/gocraft/dbr/v2"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,15 +21,25 @@ func TestDefaultMemoryStore_AllInOne(t *testing.T) {
 	//---------------------------------------------------------------------------
 	// creating test items
 	//---------------------------------------------------------------------------
-	cs, err := s.BulkCreate(context.Background(), []currency.Currency{
-		{ID: "LVL", Value: 1.00, PubDate: dbr.NewNullTime(time.Now())},
-		{ID: "EUR", Value: 2.00, PubDate: dbr.NewNullTime(time.Now())},
-		{ID: "USD", Value: 3.00, PubDate: dbr.NewNullTime(time.Now())},
+	cs, stats, err := s.BulkCreate(context.Background(), []currency.Currency{
+		{ID: "LVL", Value: decimal.New(1, 0), PubDate: dbr.NewNullTime(time.Now())},
+		{ID: "EUR", Value: decimal.New(2, 0), PubDate: dbr.NewNullTime(time.Now())},
+		{ID: "USD", Value: decimal.New(3, 0), PubDate: dbr.NewNullTime(time.Now())},
 	})
 
 	a.NoError(err)
 	a.NotNil(cs)
 	a.Len(cs, 3)
+	a.Equal(currency.UpsertStats{Inserted: 3}, stats)
+
+	//---------------------------------------------------------------------------
+	// storing the same items again (one of them has changed)
+	//---------------------------------------------------------------------------
+	cs[0].Value = decimal.New(150, -2)
+
+	_, stats, err = s.BulkCreate(context.Background(), cs)
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Updated: 1, Unchanged: 2}, stats)
 
 	//---------------------------------------------------------------------------
 	// obtaining all latest stored values
@@ -45,3 +57,9 @@ func TestDefaultMemoryStore_AllInOne(t *testing.T) {
 	a.NotNil(cs)
 	a.Len(cs, 1)
 }
+
+func TestDefaultMemoryStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) currency.Store {
+		return currency.NewMemoryStore()
+	})
+}
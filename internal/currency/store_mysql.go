@@ -3,6 +3,7 @@ package currency
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/gocraft/dbr/v2"
 	"github.com/pkg/errors"
@@ -30,7 +31,7 @@ func (s *defaultMySQLStore) oneByQuery(ctx context.Context, q string, args ...in
 		LoadOneContext(ctx, &c)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if err == sql.ErrNoRows || err == dbr.ErrNotFound {
 			return c, ErrCurrencyNotFound
 		}
 
@@ -58,18 +59,18 @@ func (s *defaultMySQLStore) manyByQuery(ctx context.Context, q string, args ...i
 	return items, nil
 }
 
-func (s *defaultMySQLStore) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, err error) {
+func (s *defaultMySQLStore) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, stats UpsertStats, err error) {
 	currencyLen := len(cs)
 
 	// there must be something first
 	if currencyLen == 0 {
-		return nil, ErrNoData
+		return nil, stats, ErrNoData
 	}
 
 	// NOTE: sqlmock panics without &dbr.NullEventReceiver{}
 	tx, err := s.connection.NewSession(&dbr.NullEventReceiver{}).Begin()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to initialize database transaction")
+		return nil, stats, errors.Wrap(err, "failed to initialize database transaction")
 	}
 	defer tx.RollbackUnlessCommitted()
 
@@ -80,11 +81,18 @@ func (s *defaultMySQLStore) BulkCreate(ctx context.Context, cs []Currency) (_ []
 	// insert attempts (i.e. multiple import runs or external changes),
 	// so I'm using a prepared statement, otherwise I'd simply go for the following:
 	// stmt := tx.InsertInto("currency").Columns(guard.DBColumnsFrom(&cs[0])...)
+	//
+	// NOTE: updated_at is assigned before the value, and only if either the value
+	// or its source differs, thus the number of affected rows is 1 for inserted,
+	// 2 for updated and 0 for unchanged rows (unless the connection uses CLIENT_FOUND_ROWS)
+	//
+	// NOTE: decimal values are passed as strings, which MySQL compares with
+	// decimal columns as floats, hence casting it back to the column type
 
-	stmt, err := tx.PrepareContext(ctx, `INSERT INTO currency(id, value, pub_date, created_at) VALUES(?, ?, ?, NOW()) ON DUPLICATE KEY UPDATE value = ?, updated_at = NOW()`)
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO currency(id, value, pub_date, source, created_at) VALUES(?, ?, ?, ?, NOW()) ON DUPLICATE KEY UPDATE updated_at = IF(value = CAST(? AS DECIMAL(15,4)) AND source = ?, updated_at, NOW()), value = ?, source = ?`)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to prepare statement")
+		return nil, stats, errors.Wrap(err, "failed to prepare statement")
 	}
 
 	// statement must be closed afterwards
@@ -93,33 +101,402 @@ func (s *defaultMySQLStore) BulkCreate(ctx context.Context, cs []Currency) (_ []
 	// validating each c individually
 	for i := range cs {
 		if err := cs[i].Validate(); err != nil {
-			return nil, err
+			return nil, stats, err
 		}
 
 		c := cs[i]
 
-		// NOTE: ignoring execution result
-		if _, err = stmt.ExecContext(ctx, c.ID, c.Value, c.PubDate, c.Value); err != nil {
-			return nil, errors.Wrap(err, "failed to execute statement")
+		result, err := stmt.ExecContext(ctx, c.ID, c.Value, c.PubDate, c.Source, c.Value, c.Source, c.Value, c.Source)
+		if err != nil {
+			return nil, stats, errors.Wrap(err, "failed to execute statement")
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, stats, errors.Wrap(err, "failed to obtain affected rows")
+		}
+
+		switch affected {
+		case 0:
+			stats.Unchanged++
+		case 1:
+			stats.Inserted++
+		default:
+			stats.Updated++
 		}
 	}
 
 	// committing
 	if err = tx.Commit(); err != nil {
-		return nil, errors.Wrap(err, "failed to commit database transaction")
+		return nil, stats, errors.Wrap(err, "failed to commit database transaction")
 	}
 
 	// NOTE: since this is a very simple test case, thus
-	// ignoring execution result and not assigning
-	// keys (because in this case ID is the currency name)
+	// not assigning keys (because in this case ID is the currency name)
 
-	return cs, nil
+	return cs, stats, nil
 }
 
 func (s *defaultMySQLStore) AllByID(ctx context.Context, id string) (cs []Currency, err error) {
 	return s.manyByQuery(ctx, "SELECT * FROM `currency` WHERE id = ? ORDER BY pub_date DESC", id)
 }
 
+func (s *defaultMySQLStore) AsOf(ctx context.Context, id string, date time.Time) (c Currency, err error) {
+	return s.oneByQuery(ctx, "SELECT * FROM `currency` WHERE id = ? AND pub_date <= ? ORDER BY pub_date DESC LIMIT 1", id, date.Format(dateLayout))
+}
+
+func (s *defaultMySQLStore) RangeByID(ctx context.Context, id string, r Range) (cs []Currency, err error) {
+	// NOTE: every condition is on (id, pub_date), so the range
+	// is scanned by the primary key without sorting
+	q := "SELECT * FROM `currency` WHERE id = ?"
+	args := []interface{}{id}
+
+	if !r.From.IsZero() {
+		q += " AND pub_date >= ?"
+		args = append(args, r.From.Format(dateLayout))
+	}
+
+	if !r.To.IsZero() {
+		q += " AND pub_date <= ?"
+		args = append(args, r.To.Format(dateLayout))
+	}
+
+	// keyset pagination, continuing after the last date of the previous page
+	if !r.After.IsZero() {
+		if r.Order == OrderAsc {
+			q += " AND pub_date > ?"
+		} else {
+			q += " AND pub_date < ?"
+		}
+
+		args = append(args, r.After.Format(dateLayout))
+	}
+
+	if r.Order == OrderAsc {
+		q += " ORDER BY pub_date ASC"
+	} else {
+		q += " ORDER BY pub_date DESC"
+	}
+
+	if r.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, r.Limit)
+	}
+
+	return s.manyByQuery(ctx, q, args...)
+}
+
 func (s *defaultMySQLStore) AllLatest(ctx context.Context) (cs []Currency, err error) {
-	return s.manyByQuery(ctx, "SELECT * FROM `currency` WHERE `pub_date` = (SELECT MAX(pub_date) FROM `currency`)")
+	return s.manyByQuery(ctx, "SELECT * FROM `currency` WHERE `pub_date` = (SELECT MAX(pub_date) FROM `currency`) ORDER BY id")
+}
+
+func (s *defaultMySQLStore) FeedState(ctx context.Context, source string) (state FeedState, err error) {
+	err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql("SELECT * FROM `feed_state` WHERE source = ?", source).
+		LoadOneContext(ctx, &state)
+
+	if err != nil {
+		if err == dbr.ErrNotFound {
+			return state, ErrFeedStateNotFound
+		}
+
+		return state, err
+	}
+
+	return state, nil
+}
+
+func (s *defaultMySQLStore) SaveFeedState(ctx context.Context, state FeedState) (err error) {
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertBySql(
+			"INSERT INTO `feed_state`(source, etag, last_modified, content_hash, checked_at) VALUES(?, ?, ?, ?, ?) "+
+				"ON DUPLICATE KEY UPDATE etag = VALUES(etag), last_modified = VALUES(last_modified), content_hash = VALUES(content_hash), checked_at = VALUES(checked_at)",
+			state.Source,
+			state.ETag,
+			state.LastModified,
+			state.ContentHash,
+			state.CheckedAt,
+		).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to save feed state")
+	}
+
+	return nil
+}
+
+func (s *defaultMySQLStore) CreateImportRun(ctx context.Context, r ImportReport) (_ ImportReport, err error) {
+	result, err := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("import_runs").
+		Columns(
+			"source",
+			"started_at",
+			"finished_at",
+			"skipped",
+			"snapshots",
+			"items_seen",
+			"items_inserted",
+			"items_updated",
+			"items_unchanged",
+			"items_rejected",
+			"discrepancies",
+			"error",
+		).
+		Record(&r).
+		ExecContext(ctx)
+
+	if err != nil {
+		return r, errors.Wrap(err, "failed to create import run")
+	}
+
+	if r.ID, err = result.LastInsertId(); err != nil {
+		return r, errors.Wrap(err, "failed to obtain import run id")
+	}
+
+	return r, nil
+}
+
+func (s *defaultMySQLStore) ImportRuns(ctx context.Context, limit int) (rs []ImportReport, err error) {
+	rs = make([]ImportReport, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("import_runs").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &rs); err != nil {
+		return nil, errors.Wrap(err, "failed to load import runs")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultMySQLStore) Quarantine(ctx context.Context, rs []Rejection) (err error) {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("quarantine").
+		Columns("source", "pub_date", "payload", "reason", "created_at")
+
+	for i := range rs {
+		stmt = stmt.Record(&rs[i])
+	}
+
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to quarantine rejections")
+	}
+
+	return nil
+}
+
+func (s *defaultMySQLStore) Rejections(ctx context.Context, limit int) (rs []Rejection, err error) {
+	rs = make([]Rejection, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("quarantine").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &rs); err != nil {
+		return nil, errors.Wrap(err, "failed to load rejections")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultMySQLStore) CreateDiscrepancies(ctx context.Context, ds []Discrepancy) (err error) {
+	if len(ds) == 0 {
+		return nil
+	}
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("discrepancies").
+		Columns("currency_id", "pub_date", "source", "value", "chosen_source", "chosen_value", "deviation", "created_at")
+
+	for i := range ds {
+		stmt = stmt.Record(&ds[i])
+	}
+
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to create discrepancies")
+	}
+
+	return nil
+}
+
+func (s *defaultMySQLStore) Discrepancies(ctx context.Context, limit int) (ds []Discrepancy, err error) {
+	ds = make([]Discrepancy, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("discrepancies").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &ds); err != nil {
+		return nil, errors.Wrap(err, "failed to load discrepancies")
+	}
+
+	return ds, nil
+}
+
+func (s *defaultMySQLStore) Observations(ctx context.Context) (obs []Observation, err error) {
+	obs = make([]Observation, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql("SELECT id, MIN(pub_date) AS first_pub_date, MAX(pub_date) AS last_pub_date, COUNT(*) AS observations FROM `currency` GROUP BY id ORDER BY id").
+		LoadContext(ctx, &obs)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load currency observations")
+	}
+
+	return obs, nil
+}
+
+// periodStartSQL maps periods to the expressions of their first days
+var periodStartSQL = map[Period]string{
+	PeriodWeek:    "DATE_SUB(pub_date, INTERVAL WEEKDAY(pub_date) DAY)",
+	PeriodMonth:   "DATE_SUB(pub_date, INTERVAL DAYOFMONTH(pub_date) - 1 DAY)",
+	PeriodQuarter: "MAKEDATE(YEAR(pub_date), 1) + INTERVAL QUARTER(pub_date) - 1 QUARTER",
+	PeriodYear:    "MAKEDATE(YEAR(pub_date), 1)",
+}
+
+func (s *defaultMySQLStore) Aggregate(ctx context.Context, id string, period Period, from, to time.Time) (as []Aggregate, err error) {
+	start, ok := periodStartSQL[period]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupportedPeriod, "%s", period)
+	}
+
+	// NOTE: open and close are the first items of the values concatenated
+	// in the order of dates, which are never cut off by group_concat_max_len
+	q := "SELECT " + start + " AS period_start, " +
+		"MIN(pub_date) AS first_pub_date, " +
+		"MAX(pub_date) AS last_pub_date, " +
+		"SUBSTRING_INDEX(GROUP_CONCAT(value ORDER BY pub_date ASC), ',', 1) AS open, " +
+		"MAX(value) AS high, " +
+		"MIN(value) AS low, " +
+		"SUBSTRING_INDEX(GROUP_CONCAT(value ORDER BY pub_date DESC), ',', 1) AS close, " +
+		"AVG(value) AS mean, " +
+		"COUNT(*) AS observations " +
+		"FROM `currency` WHERE id = ?"
+
+	args := []interface{}{id}
+
+	if !from.IsZero() {
+		q += " AND pub_date >= ?"
+		args = append(args, from.Format(dateLayout))
+	}
+
+	if !to.IsZero() {
+		q += " AND pub_date <= ?"
+		args = append(args, to.Format(dateLayout))
+	}
+
+	q += " GROUP BY period_start ORDER BY period_start"
+
+	as = make([]Aggregate, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql(q, args...).
+		LoadContext(ctx, &as)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "failed to aggregate currency values")
+	}
+
+	return as, nil
+}
+
+func (s *defaultMySQLStore) CreateAlertRule(ctx context.Context, r AlertRule) (_ AlertRule, err error) {
+	result, err := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("alert_rules").
+		Columns(
+			"name",
+			"kind",
+			"currency_id",
+			"base",
+			"direction",
+			"threshold",
+			"days",
+			"cooldown_minutes",
+			"created_at",
+		).
+		Record(&r).
+		ExecContext(ctx)
+
+	if err != nil {
+		return r, errors.Wrap(err, "failed to create alert rule")
+	}
+
+	if r.ID, err = result.LastInsertId(); err != nil {
+		return r, errors.Wrap(err, "failed to obtain alert rule id")
+	}
+
+	return r, nil
+}
+
+func (s *defaultMySQLStore) AlertRules(ctx context.Context) (rs []AlertRule, err error) {
+	rs = make([]AlertRule, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("alert_rules").
+		OrderAsc("id").
+		LoadContext(ctx, &rs)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load alert rules")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultMySQLStore) DeleteAlertRule(ctx context.Context, id int64) (err error) {
+	result, err := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		DeleteFrom("alert_rules").
+		Where("id = ?", id).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to delete alert rule")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain affected rows")
+	}
+
+	if affected == 0 {
+		return ErrAlertRuleNotFound
+	}
+
+	return nil
+}
+
+func (s *defaultMySQLStore) AlertFired(ctx context.Context, id int64, firedAt time.Time, fingerprint string) (err error) {
+	// NOTE: the statement is written out, since dbr sets columns in random order
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		UpdateBySql("UPDATE `alert_rules` SET last_fired_at = ?, last_fingerprint = ? WHERE id = ?", firedAt, fingerprint, id).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to update alert rule")
+	}
+
+	return nil
 }
@@ -2,13 +2,19 @@ package currency_test
 
 import (
 	"context"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/currency/storetest"
+	_ "github.com/go-sql-driver/mysql"
 	"github.com/gocraft/dbr/v2"
 	"github.com/gocraft/dbr/v2/dialect"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,34 +37,37 @@ func TestDefaultMySQLStore_BulkCreate(t *testing.T) {
 	a.NotNil(store)
 
 	testdata := []currency.Currency{
-		{ID: "LVL", Value: 1.00, PubDate: dbr.NewNullTime(time.Now())},
-		{ID: "EUR", Value: 2.00, PubDate: dbr.NewNullTime(time.Now())},
-		{ID: "USD", Value: 3.00, PubDate: dbr.NewNullTime(time.Now())},
+		{ID: "GBP", Value: decimal.New(1, 0), PubDate: dbr.NewNullTime(time.Now()), Source: "rss"},
+		{ID: "EUR", Value: decimal.New(2, 0), PubDate: dbr.NewNullTime(time.Now()), Source: "rss"},
+		{ID: "USD", Value: decimal.New(3, 0), PubDate: dbr.NewNullTime(time.Now()), Source: "ecb"},
 	}
 
 	mock.ExpectBegin()
 
 	// assigning to variable for re-use due to multiple exec calls,
+	// NOTE: the number of affected rows tells whether a row has been
+	// inserted (1), updated (2) or left unchanged (0)
 	stmt := mock.ExpectPrepare("INSERT INTO currency")
 
 	stmt.ExpectExec().
-		WithArgs(testdata[0].ID, testdata[0].Value, testdata[0].PubDate, testdata[0].Value).
+		WithArgs(testdata[0].ID, testdata[0].Value, testdata[0].PubDate, testdata[0].Source, testdata[0].Value, testdata[0].Source, testdata[0].Value, testdata[0].Source).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	stmt.ExpectExec().
-		WithArgs(testdata[1].ID, testdata[1].Value, testdata[1].PubDate, testdata[1].Value).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+		WithArgs(testdata[1].ID, testdata[1].Value, testdata[1].PubDate, testdata[1].Source, testdata[1].Value, testdata[1].Source, testdata[1].Value, testdata[1].Source).
+		WillReturnResult(sqlmock.NewResult(0, 2))
 
 	stmt.ExpectExec().
-		WithArgs(testdata[2].ID, testdata[2].Value, testdata[2].PubDate, testdata[2].Value).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+		WithArgs(testdata[2].ID, testdata[2].Value, testdata[2].PubDate, testdata[2].Source, testdata[2].Value, testdata[2].Source, testdata[2].Value, testdata[2].Source).
+		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	mock.ExpectCommit()
 
-	cs, err := store.BulkCreate(context.Background(), testdata)
+	cs, stats, err := store.BulkCreate(context.Background(), testdata)
 	a.NoError(err)
 	a.NotNil(cs)
 	a.Len(cs, len(testdata))
+	a.Equal(currency.UpsertStats{Inserted: 1, Updated: 1, Unchanged: 1}, stats)
 
 	a.NoError(mock.ExpectationsWereMet())
 }
@@ -86,7 +95,7 @@ func TestDefaultMySQLStore_AllLatest(t *testing.T) {
 		AddRow("EUR", 2.00, dbr.NewNullTime(time.Now()), dbr.NewNullTime(time.Now()), dbr.NewNullTime(time.Now())).
 		AddRow("USD", 3.00, dbr.NewNullTime(time.Now()), dbr.NewNullTime(time.Now()), dbr.NewNullTime(time.Now()))
 
-	mock.ExpectQuery("SELECT * FROM `currency` WHERE `pub_date` = (SELECT MAX(pub_date) FROM `currency`)").
+	mock.ExpectQuery("SELECT * FROM `currency` WHERE `pub_date` = (SELECT MAX(pub_date) FROM `currency`) ORDER BY id").
 		WillReturnRows(rows)
 
 	cs, err := store.AllLatest(context.Background())
@@ -130,3 +139,441 @@ func TestDefaultMySQLStore_HistoryByID(t *testing.T) {
 
 	a.NoError(mock.ExpectationsWereMet())
 }
+
+func TestDefaultMySQLStore_AsOf(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT * FROM `currency` WHERE id = 'USD' AND pub_date <= '2020-03-22' ORDER BY pub_date DESC LIMIT 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "value", "pub_date"}).AddRow("USD", "1.0801", pubDate))
+
+	c, err := store.AsOf(context.Background(), "USD", time.Date(2020, 3, 22, 0, 0, 0, 0, time.UTC))
+	a.NoError(err)
+	a.Equal("1.0801", c.Value.String())
+	a.Equal(pubDate, c.PubDate.Time)
+
+	// nothing on or before the date
+	mock.ExpectQuery("SELECT * FROM `currency` WHERE id = 'USD' AND pub_date <= '2020-03-01' ORDER BY pub_date DESC LIMIT 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "value", "pub_date"}))
+
+	_, err = store.AsOf(context.Background(), "USD", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC))
+	a.Equal(currency.ErrCurrencyNotFound, err)
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDefaultMySQLStore_RangeByID(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	// unbounded
+	mock.ExpectQuery("SELECT * FROM `currency` WHERE id = 'USD' ORDER BY pub_date DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "value", "pub_date"}).AddRow("USD", "1.0801", pubDate))
+
+	cs, err := store.RangeByID(context.Background(), "USD", currency.Range{})
+	a.NoError(err)
+	a.Len(cs, 1)
+
+	// bounded, continuing after a cursor
+	mock.ExpectQuery("SELECT * FROM `currency` WHERE id = 'USD' AND pub_date >= '2020-03-01' AND pub_date <= '2020-03-31' AND pub_date > '2020-03-17' ORDER BY pub_date ASC LIMIT 11").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "value", "pub_date"}).
+			AddRow("USD", "1.0934", pubDate.AddDate(0, 0, -1)).
+			AddRow("USD", "1.0801", pubDate))
+
+	cs, err = store.RangeByID(context.Background(), "USD", currency.Range{
+		From:  time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC),
+		After: time.Date(2020, 3, 17, 0, 0, 0, 0, time.UTC),
+		Order: currency.OrderAsc,
+		Limit: 11,
+	})
+	a.NoError(err)
+	a.Len(cs, 2)
+	a.Equal("1.0934", cs[0].Value.String())
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDefaultMySQLStore_FeedState(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	fss, ok := store.(currency.FeedStateStore)
+	a.True(ok)
+
+	// not found
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `feed_state` WHERE source = 'feed'")).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "etag", "last_modified", "content_hash", "checked_at"}))
+
+	_, err = fss.FeedState(context.Background(), "feed")
+	a.Equal(currency.ErrFeedStateNotFound, err)
+
+	// found
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `feed_state` WHERE source = 'feed'")).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "etag", "last_modified", "content_hash", "checked_at"}).
+			AddRow("feed", `"v1"`, "", "abc", time.Now()))
+
+	state, err := fss.FeedState(context.Background(), "feed")
+	a.NoError(err)
+	a.Equal(`"v1"`, state.ETag)
+	a.Equal("abc", state.ContentHash)
+
+	// saving
+	mock.ExpectExec("INSERT INTO `feed_state`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(fss.SaveFeedState(context.Background(), currency.FeedState{Source: "feed", ETag: `"v2"`}))
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDefaultMySQLStore_ImportRuns(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	irs, ok := store.(currency.ImportRunStore)
+	a.True(ok)
+
+	// creating
+	mock.ExpectExec("INSERT INTO `import_runs`").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	r, err := irs.CreateImportRun(context.Background(), currency.ImportReport{
+		Source:    "feed",
+		StartedAt: dbr.NewNullTime(time.Now()),
+		Seen:      3,
+		Inserted:  3,
+	})
+
+	a.NoError(err)
+	a.EqualValues(7, r.ID)
+
+	// listing
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM import_runs ORDER BY id DESC LIMIT 10")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source", "started_at", "items_seen", "items_inserted", "error"}).
+			AddRow(7, "feed", time.Now(), 3, 3, "").
+			AddRow(6, "feed", time.Now(), 3, 0, "failed"))
+
+	rs, err := irs.ImportRuns(context.Background(), 10)
+	a.NoError(err)
+	a.Len(rs, 2)
+	a.EqualValues(7, rs[0].ID)
+	a.Equal("failed", rs[1].Error)
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDefaultMySQLStore_Quarantine(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	qs, ok := store.(currency.QuarantineStore)
+	a.True(ok)
+
+	// quarantining
+	mock.ExpectExec("INSERT INTO `quarantine`").
+		WillReturnResult(sqlmock.NewResult(2, 2))
+
+	err = qs.Quarantine(context.Background(), []currency.Rejection{
+		{Source: "feed", Payload: "USD abc", Reason: "failed to parse value: abc", CreatedAt: dbr.NewNullTime(time.Now())},
+		{Source: "feed", Payload: "JPY 0", Reason: "invalid currency value", CreatedAt: dbr.NewNullTime(time.Now())},
+	})
+
+	a.NoError(err)
+
+	// nothing to quarantine
+	a.NoError(qs.Quarantine(context.Background(), nil))
+
+	// listing
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM quarantine ORDER BY id DESC LIMIT 10")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source", "payload", "reason", "created_at"}).
+			AddRow(2, "feed", "JPY 0", "invalid currency value", time.Now()).
+			AddRow(1, "feed", "USD abc", "failed to parse value: abc", time.Now()))
+
+	rs, err := qs.Rejections(context.Background(), 10)
+	a.NoError(err)
+	a.Len(rs, 2)
+	a.EqualValues(2, rs[0].ID)
+	a.Equal("USD abc", rs[1].Payload)
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDefaultMySQLStore_Discrepancies(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	dstore, ok := store.(currency.DiscrepancyStore)
+	a.True(ok)
+
+	// creating
+	mock.ExpectExec("INSERT INTO `discrepancies`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = dstore.CreateDiscrepancies(context.Background(), []currency.Discrepancy{
+		{
+			CurrencyID:   "JPY",
+			PubDate:      dbr.NewNullTime(time.Now()),
+			Source:       "secondary",
+			Value:        decimal.RequireFromString("118.15"),
+			ChosenSource: "primary",
+			ChosenValue:  decimal.RequireFromString("118.00"),
+			Deviation:    0.0013,
+			CreatedAt:    dbr.NewNullTime(time.Now()),
+		},
+	})
+
+	a.NoError(err)
+
+	// listing
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM discrepancies ORDER BY id DESC LIMIT 5")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "currency_id", "source", "value", "chosen_source", "chosen_value"}).
+			AddRow(1, "JPY", "secondary", 118.15, "primary", 118.00))
+
+	ds, err := dstore.Discrepancies(context.Background(), 5)
+	a.NoError(err)
+	a.Len(ds, 1)
+	a.Equal("primary", ds[0].ChosenSource)
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDefaultMySQLStore_Observations(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	ostore, ok := store.(currency.ObservationStore)
+	a.True(ok)
+
+	first := time.Date(2020, 3, 17, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, MIN(pub_date) AS first_pub_date, MAX(pub_date) AS last_pub_date, COUNT(*) AS observations FROM `currency` GROUP BY id ORDER BY id")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_pub_date", "last_pub_date", "observations"}).
+			AddRow("USD", first, last, 3))
+
+	obs, err := ostore.Observations(context.Background())
+	a.NoError(err)
+	a.Len(obs, 1)
+	a.Equal("USD", obs[0].ID)
+	a.Equal(3, obs[0].Count)
+	a.Equal(first, obs[0].FirstPubDate.Time)
+	a.Equal(last, obs[0].LastPubDate.Time)
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDefaultMySQLStore_Aggregate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	astore, ok := store.(currency.AggregateStore)
+	a.True(ok)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DATE_SUB(pub_date, INTERVAL DAYOFMONTH(pub_date) - 1 DAY) AS period_start, MIN(pub_date) AS first_pub_date, MAX(pub_date) AS last_pub_date, SUBSTRING_INDEX(GROUP_CONCAT(value ORDER BY pub_date ASC), ',', 1) AS open, MAX(value) AS high, MIN(value) AS low, SUBSTRING_INDEX(GROUP_CONCAT(value ORDER BY pub_date DESC), ',', 1) AS close, AVG(value) AS mean, COUNT(*) AS observations FROM `currency` WHERE id = 'USD' AND pub_date >= '2020-01-01' GROUP BY period_start ORDER BY period_start")).
+		WillReturnRows(sqlmock.NewRows([]string{"period_start", "first_pub_date", "last_pub_date", "open", "high", "low", "close", "mean", "observations"}).
+			AddRow("2020-03-01", "2020-03-02", "2020-03-19", "1.1126", "1.1456", "1.0801", "1.0801", "1.11204286", 14))
+
+	as, err := astore.Aggregate(context.Background(), "USD", currency.PeriodMonth, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	a.NoError(err)
+	a.Len(as, 1)
+	a.Equal(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), as[0].PeriodStart.Time)
+	a.Equal("1.1126", as[0].Open.String())
+	a.Equal("1.0801", as[0].Close.String())
+	a.Equal("1.11204286", as[0].Mean.String())
+	a.Equal(14, as[0].Count)
+
+	// unsupported period isn't sent to the database
+	_, err = astore.Aggregate(context.Background(), "USD", "fortnight", time.Time{}, time.Time{})
+	a.Error(err)
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDefaultMySQLStore_AlertRules(t *testing.T) {
+	a := assert.New(t)
+
+	db, mock, err := sqlmock.New()
+	a.NoError(err)
+	defer db.Close()
+
+	store, err := currency.NewDefaultMySQLStore(&dbr.Connection{
+		DB:            db,
+		Dialect:       dialect.MySQL,
+		EventReceiver: nil,
+	})
+
+	a.NoError(err)
+
+	astore, ok := store.(currency.AlertStore)
+	a.True(ok)
+
+	// creating
+	mock.ExpectExec("INSERT INTO `alert_rules`").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	r, err := astore.CreateAlertRule(context.Background(), currency.AlertRule{
+		Name:       "EUR/USD crosses 1.1",
+		Kind:       currency.AlertThreshold,
+		CurrencyID: "USD",
+		Base:       "EUR",
+		Direction:  currency.DirectionCross,
+		Threshold:  decimal.RequireFromString("1.1"),
+		CreatedAt:  dbr.NewNullTime(time.Now()),
+	})
+
+	a.NoError(err)
+	a.Equal(int64(3), r.ID)
+
+	// listing
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM alert_rules ORDER BY id ASC")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "kind", "currency_id", "threshold", "last_fingerprint"}).
+			AddRow(3, "EUR/USD crosses 1.1", "threshold", "USD", "1.100000", ""))
+
+	rs, err := astore.AlertRules(context.Background())
+	a.NoError(err)
+	a.Len(rs, 1)
+	a.Equal(currency.AlertThreshold, rs[0].Kind)
+	a.Equal("1.1", rs[0].Threshold.String())
+
+	// remembering the last alert
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `alert_rules` SET last_fired_at = '2020-03-19 17:00:00.000000', last_fingerprint = '3:threshold:2020-03-19' WHERE id = 3")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(astore.AlertFired(context.Background(), 3, time.Date(2020, 3, 19, 17, 0, 0, 0, time.UTC), "3:threshold:2020-03-19"))
+
+	// deleting
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `alert_rules` WHERE (id = 3)")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(astore.DeleteAlertRule(context.Background(), 3))
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `alert_rules` WHERE (id = 4)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a.Equal(currency.ErrAlertRuleNotFound, astore.DeleteAlertRule(context.Background(), 4))
+
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+// TestDefaultMySQLStore_Conformance runs the conformance suite against the
+// database at MYSQL_DSN, which must have db/baseline.sql and db/migrations applied
+// and parse times, i.e. "root:secret@tcp(localhost:3306)/tetest?parseTime=true"
+// NOTE: the currency table is truncated before each test
+func TestDefaultMySQLStore_Conformance(t *testing.T) {
+	dsn := strings.TrimSpace(os.Getenv("MYSQL_DSN"))
+	if dsn == "" {
+		t.Skip("MYSQL_DSN is not set")
+	}
+
+	connection, err := dbr.Open("mysql", dsn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connection.Close()
+
+	storetest.Run(t, func(t *testing.T) currency.Store {
+		if _, err := connection.Exec("TRUNCATE TABLE `currency`"); err != nil {
+			t.Fatal(err)
+		}
+
+		store, err := currency.NewDefaultMySQLStore(connection)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return store
+	})
+}
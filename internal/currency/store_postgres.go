@@ -0,0 +1,478 @@
+package currency
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+)
+
+type defaultPostgresStore struct {
+	connection *dbr.Connection
+}
+
+// NewPostgresStore initializes a store backed by PostgreSQL,
+// with the schema of db/postgres.sql
+func NewPostgresStore(connection *dbr.Connection) (Store, error) {
+	if connection == nil {
+		return nil, ErrNilDatabase
+	}
+
+	s := &defaultPostgresStore{
+		connection: connection,
+	}
+
+	return s, nil
+}
+
+func (s *defaultPostgresStore) oneByQuery(ctx context.Context, q string, args ...interface{}) (c Currency, err error) {
+	err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql(q, args...).
+		LoadOneContext(ctx, &c)
+
+	if err != nil {
+		if err == sql.ErrNoRows || err == dbr.ErrNotFound {
+			return c, ErrCurrencyNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (s *defaultPostgresStore) manyByQuery(ctx context.Context, q string, args ...interface{}) (items []Currency, err error) {
+	items = make([]Currency, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql(q, args...).
+		LoadContext(ctx, &items)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return items, nil
+		}
+
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (s *defaultPostgresStore) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, stats UpsertStats, err error) {
+	// there must be something first
+	if len(cs) == 0 {
+		return nil, stats, ErrNoData
+	}
+
+	// NOTE: sqlmock panics without &dbr.NullEventReceiver{}
+	tx, err := s.connection.NewSession(&dbr.NullEventReceiver{}).Begin()
+	if err != nil {
+		return nil, stats, errors.Wrap(err, "failed to initialize database transaction")
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	// NOTE: the conflicting row is only updated if either the value or its source
+	// differs, so nothing is returned for unchanged rows, and xmax (the deleting
+	// transaction) is zero for inserted rows and set for updated ones
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO currency(id, value, pub_date, source, created_at) VALUES($1, $2, $3, $4, NOW()) ON CONFLICT (id, pub_date) DO UPDATE SET updated_at = NOW(), value = EXCLUDED.value, source = EXCLUDED.source WHERE currency.value <> EXCLUDED.value OR currency.source <> EXCLUDED.source RETURNING (xmax = 0) AS inserted`)
+
+	if err != nil {
+		return nil, stats, errors.Wrap(err, "failed to prepare statement")
+	}
+
+	// statement must be closed afterwards
+	defer stmt.Close()
+
+	// validating each c individually
+	for i := range cs {
+		if err := cs[i].Validate(); err != nil {
+			return nil, stats, err
+		}
+
+		c := cs[i]
+
+		var inserted bool
+
+		err = stmt.QueryRowContext(ctx, c.ID, c.Value, c.PubDate, c.Source).Scan(&inserted)
+		switch {
+		case err == sql.ErrNoRows:
+			stats.Unchanged++
+		case err != nil:
+			return nil, stats, errors.Wrap(err, "failed to execute statement")
+		case inserted:
+			stats.Inserted++
+		default:
+			stats.Updated++
+		}
+	}
+
+	// committing
+	if err = tx.Commit(); err != nil {
+		return nil, stats, errors.Wrap(err, "failed to commit database transaction")
+	}
+
+	return cs, stats, nil
+}
+
+func (s *defaultPostgresStore) AllByID(ctx context.Context, id string) (cs []Currency, err error) {
+	return s.manyByQuery(ctx, "SELECT * FROM currency WHERE id = ? ORDER BY pub_date DESC", id)
+}
+
+func (s *defaultPostgresStore) AsOf(ctx context.Context, id string, date time.Time) (c Currency, err error) {
+	return s.oneByQuery(ctx, "SELECT * FROM currency WHERE id = ? AND pub_date <= ? ORDER BY pub_date DESC LIMIT 1", id, date.Format(dateLayout))
+}
+
+func (s *defaultPostgresStore) RangeByID(ctx context.Context, id string, r Range) (cs []Currency, err error) {
+	q := "SELECT * FROM currency WHERE id = ?"
+	args := []interface{}{id}
+
+	if !r.From.IsZero() {
+		q += " AND pub_date >= ?"
+		args = append(args, r.From.Format(dateLayout))
+	}
+
+	if !r.To.IsZero() {
+		q += " AND pub_date <= ?"
+		args = append(args, r.To.Format(dateLayout))
+	}
+
+	// keyset pagination, continuing after the last date of the previous page
+	if !r.After.IsZero() {
+		if r.Order == OrderAsc {
+			q += " AND pub_date > ?"
+		} else {
+			q += " AND pub_date < ?"
+		}
+
+		args = append(args, r.After.Format(dateLayout))
+	}
+
+	if r.Order == OrderAsc {
+		q += " ORDER BY pub_date ASC"
+	} else {
+		q += " ORDER BY pub_date DESC"
+	}
+
+	if r.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, r.Limit)
+	}
+
+	return s.manyByQuery(ctx, q, args...)
+}
+
+func (s *defaultPostgresStore) AllLatest(ctx context.Context) (cs []Currency, err error) {
+	// NOTE: the latest value of each currency is picked by DISTINCT ON, but only
+	// those of the latest publication date are returned, the same as with other
+	// stores, so a currency which is missing on that date isn't returned at all
+	return s.manyByQuery(ctx, "SELECT * FROM (SELECT DISTINCT ON (id) * FROM currency ORDER BY id, pub_date DESC) AS latest WHERE pub_date = (SELECT MAX(pub_date) FROM currency) ORDER BY id")
+}
+
+func (s *defaultPostgresStore) FeedState(ctx context.Context, source string) (state FeedState, err error) {
+	err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql("SELECT * FROM feed_state WHERE source = ?", source).
+		LoadOneContext(ctx, &state)
+
+	if err != nil {
+		if err == dbr.ErrNotFound {
+			return state, ErrFeedStateNotFound
+		}
+
+		return state, err
+	}
+
+	return state, nil
+}
+
+func (s *defaultPostgresStore) SaveFeedState(ctx context.Context, state FeedState) (err error) {
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertBySql(
+			"INSERT INTO feed_state(source, etag, last_modified, content_hash, checked_at) VALUES(?, ?, ?, ?, ?) "+
+				"ON CONFLICT (source) DO UPDATE SET etag = EXCLUDED.etag, last_modified = EXCLUDED.last_modified, content_hash = EXCLUDED.content_hash, checked_at = EXCLUDED.checked_at",
+			state.Source,
+			state.ETag,
+			state.LastModified,
+			state.ContentHash,
+			state.CheckedAt,
+		).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to save feed state")
+	}
+
+	return nil
+}
+
+func (s *defaultPostgresStore) CreateImportRun(ctx context.Context, r ImportReport) (_ ImportReport, err error) {
+	// NOTE: PostgreSQL doesn't report the last insert id, thus returning it
+	err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("import_runs").
+		Columns(
+			"source",
+			"started_at",
+			"finished_at",
+			"skipped",
+			"snapshots",
+			"items_seen",
+			"items_inserted",
+			"items_updated",
+			"items_unchanged",
+			"items_rejected",
+			"discrepancies",
+			"error",
+		).
+		Record(&r).
+		Returning("id").
+		LoadContext(ctx, &r.ID)
+
+	if err != nil {
+		return r, errors.Wrap(err, "failed to create import run")
+	}
+
+	return r, nil
+}
+
+func (s *defaultPostgresStore) ImportRuns(ctx context.Context, limit int) (rs []ImportReport, err error) {
+	rs = make([]ImportReport, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("import_runs").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &rs); err != nil {
+		return nil, errors.Wrap(err, "failed to load import runs")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultPostgresStore) Quarantine(ctx context.Context, rs []Rejection) (err error) {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("quarantine").
+		Columns("source", "pub_date", "payload", "reason", "created_at")
+
+	for i := range rs {
+		stmt = stmt.Record(&rs[i])
+	}
+
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to quarantine rejections")
+	}
+
+	return nil
+}
+
+func (s *defaultPostgresStore) Rejections(ctx context.Context, limit int) (rs []Rejection, err error) {
+	rs = make([]Rejection, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("quarantine").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &rs); err != nil {
+		return nil, errors.Wrap(err, "failed to load rejections")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultPostgresStore) CreateDiscrepancies(ctx context.Context, ds []Discrepancy) (err error) {
+	if len(ds) == 0 {
+		return nil
+	}
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("discrepancies").
+		Columns("currency_id", "pub_date", "source", "value", "chosen_source", "chosen_value", "deviation", "created_at")
+
+	for i := range ds {
+		stmt = stmt.Record(&ds[i])
+	}
+
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to create discrepancies")
+	}
+
+	return nil
+}
+
+func (s *defaultPostgresStore) Discrepancies(ctx context.Context, limit int) (ds []Discrepancy, err error) {
+	ds = make([]Discrepancy, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("discrepancies").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &ds); err != nil {
+		return nil, errors.Wrap(err, "failed to load discrepancies")
+	}
+
+	return ds, nil
+}
+
+func (s *defaultPostgresStore) Observations(ctx context.Context) (obs []Observation, err error) {
+	obs = make([]Observation, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql("SELECT id, MIN(pub_date) AS first_pub_date, MAX(pub_date) AS last_pub_date, COUNT(*) AS observations FROM currency GROUP BY id ORDER BY id").
+		LoadContext(ctx, &obs)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load currency observations")
+	}
+
+	return obs, nil
+}
+
+// periodStartPostgres maps periods to the expressions of their first days
+// NOTE: weeks of date_trunc are ISO weeks, which start on Monday
+var periodStartPostgres = map[Period]string{
+	PeriodWeek:    "date_trunc('week', pub_date)::date",
+	PeriodMonth:   "date_trunc('month', pub_date)::date",
+	PeriodQuarter: "date_trunc('quarter', pub_date)::date",
+	PeriodYear:    "date_trunc('year', pub_date)::date",
+}
+
+func (s *defaultPostgresStore) Aggregate(ctx context.Context, id string, period Period, from, to time.Time) (as []Aggregate, err error) {
+	start, ok := periodStartPostgres[period]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupportedPeriod, "%s", period)
+	}
+
+	// NOTE: open and close are the first items of the values aggregated in the order of dates
+	q := "SELECT " + start + " AS period_start, " +
+		"MIN(pub_date) AS first_pub_date, " +
+		"MAX(pub_date) AS last_pub_date, " +
+		"(array_agg(value ORDER BY pub_date ASC))[1] AS open, " +
+		"MAX(value) AS high, " +
+		"MIN(value) AS low, " +
+		"(array_agg(value ORDER BY pub_date DESC))[1] AS close, " +
+		"AVG(value) AS mean, " +
+		"COUNT(*) AS observations " +
+		"FROM currency WHERE id = ?"
+
+	args := []interface{}{id}
+
+	if !from.IsZero() {
+		q += " AND pub_date >= ?"
+		args = append(args, from.Format(dateLayout))
+	}
+
+	if !to.IsZero() {
+		q += " AND pub_date <= ?"
+		args = append(args, to.Format(dateLayout))
+	}
+
+	q += " GROUP BY period_start ORDER BY period_start"
+
+	as = make([]Aggregate, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql(q, args...).
+		LoadContext(ctx, &as)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "failed to aggregate currency values")
+	}
+
+	return as, nil
+}
+
+func (s *defaultPostgresStore) CreateAlertRule(ctx context.Context, r AlertRule) (_ AlertRule, err error) {
+	err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("alert_rules").
+		Columns(
+			"name",
+			"kind",
+			"currency_id",
+			"base",
+			"direction",
+			"threshold",
+			"days",
+			"cooldown_minutes",
+			"created_at",
+		).
+		Record(&r).
+		Returning("id").
+		LoadContext(ctx, &r.ID)
+
+	if err != nil {
+		return r, errors.Wrap(err, "failed to create alert rule")
+	}
+
+	return r, nil
+}
+
+func (s *defaultPostgresStore) AlertRules(ctx context.Context) (rs []AlertRule, err error) {
+	rs = make([]AlertRule, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("alert_rules").
+		OrderAsc("id").
+		LoadContext(ctx, &rs)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load alert rules")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultPostgresStore) DeleteAlertRule(ctx context.Context, id int64) (err error) {
+	result, err := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		DeleteFrom("alert_rules").
+		Where("id = ?", id).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to delete alert rule")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain affected rows")
+	}
+
+	if affected == 0 {
+		return ErrAlertRuleNotFound
+	}
+
+	return nil
+}
+
+func (s *defaultPostgresStore) AlertFired(ctx context.Context, id int64, firedAt time.Time, fingerprint string) (err error) {
+	// NOTE: the statement is written out, since dbr sets columns in random order
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		UpdateBySql("UPDATE alert_rules SET last_fired_at = ?, last_fingerprint = ? WHERE id = ?", firedAt, fingerprint, id).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to update alert rule")
+	}
+
+	return nil
+}
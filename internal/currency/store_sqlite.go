@@ -0,0 +1,546 @@
+package currency
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+)
+
+// sqliteSchema contains the tables of the SQLite store, which are created
+// automatically; values are kept as text, because SQLite has no exact decimals
+// NOTE: values are written by decimal.Decimal.String(), so that equal
+// values are always equal strings
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS currency (
+		id TEXT NOT NULL,
+		value TEXT NOT NULL,
+		pub_date DATE NOT NULL,
+		source TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NULL DEFAULT NULL,
+		PRIMARY KEY (id, pub_date)
+	)`,
+	`CREATE INDEX IF NOT EXISTS currency_pub_date ON currency (pub_date)`,
+	`CREATE TABLE IF NOT EXISTS feed_state (
+		source TEXT NOT NULL PRIMARY KEY,
+		etag TEXT NOT NULL DEFAULT '',
+		last_modified TEXT NOT NULL DEFAULT '',
+		content_hash TEXT NOT NULL DEFAULT '',
+		checked_at TIMESTAMP NULL DEFAULT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS import_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP NULL DEFAULT NULL,
+		skipped BOOLEAN NOT NULL DEFAULT 0,
+		snapshots INTEGER NOT NULL DEFAULT 0,
+		items_seen INTEGER NOT NULL DEFAULT 0,
+		items_inserted INTEGER NOT NULL DEFAULT 0,
+		items_updated INTEGER NOT NULL DEFAULT 0,
+		items_unchanged INTEGER NOT NULL DEFAULT 0,
+		items_rejected INTEGER NOT NULL DEFAULT 0,
+		discrepancies INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS quarantine (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT NOT NULL,
+		pub_date DATE NULL DEFAULT NULL,
+		payload TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS discrepancies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		currency_id TEXT NOT NULL,
+		pub_date DATE NOT NULL,
+		source TEXT NOT NULL,
+		value TEXT NOT NULL,
+		chosen_source TEXT NOT NULL,
+		chosen_value TEXT NOT NULL,
+		deviation REAL NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS alert_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		currency_id TEXT NOT NULL,
+		base TEXT NOT NULL DEFAULT 'EUR',
+		direction TEXT NOT NULL DEFAULT '',
+		threshold TEXT NOT NULL DEFAULT '0',
+		days INTEGER NOT NULL DEFAULT 0,
+		cooldown_minutes INTEGER NOT NULL DEFAULT 0,
+		last_fired_at TIMESTAMP NULL DEFAULT NULL,
+		last_fingerprint TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	)`,
+}
+
+// OpenSQLite opens (or creates) a SQLite database file in WAL mode, so that
+// readers don't block the writer, which waits for a lock instead of failing
+func OpenSQLite(path string) (*dbr.Connection, error) {
+	connection, err := dbr.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000", nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open sqlite database: %s", path)
+	}
+
+	return connection, nil
+}
+
+type defaultSQLiteStore struct {
+	connection *dbr.Connection
+}
+
+// NewSQLiteStore initializes a store backed by SQLite, creating its schema
+// NOTE: the connection is expected to be opened by OpenSQLite
+func NewSQLiteStore(connection *dbr.Connection) (Store, error) {
+	if connection == nil {
+		return nil, ErrNilDatabase
+	}
+
+	for _, q := range sqliteSchema {
+		if _, err := connection.Exec(q); err != nil {
+			return nil, errors.Wrap(err, "failed to create sqlite schema")
+		}
+	}
+
+	s := &defaultSQLiteStore{
+		connection: connection,
+	}
+
+	return s, nil
+}
+
+func (s *defaultSQLiteStore) oneByQuery(ctx context.Context, q string, args ...interface{}) (c Currency, err error) {
+	err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql(q, args...).
+		LoadOneContext(ctx, &c)
+
+	if err != nil {
+		if err == sql.ErrNoRows || err == dbr.ErrNotFound {
+			return c, ErrCurrencyNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (s *defaultSQLiteStore) manyByQuery(ctx context.Context, q string, args ...interface{}) (items []Currency, err error) {
+	items = make([]Currency, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql(q, args...).
+		LoadContext(ctx, &items)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return items, nil
+		}
+
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (s *defaultSQLiteStore) BulkCreate(ctx context.Context, cs []Currency) (_ []Currency, stats UpsertStats, err error) {
+	// there must be something first
+	if len(cs) == 0 {
+		return nil, stats, ErrNoData
+	}
+
+	tx, err := s.connection.NewSession(&dbr.NullEventReceiver{}).BeginTx(ctx, nil)
+	if err != nil {
+		return nil, stats, errors.Wrap(err, "failed to initialize database transaction")
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	// NOTE: SQLite reports a single affected row for both inserted and updated
+	// rows of an upsert, thus inserting first and updating the value only if
+	// either the value or its source differs, which tells all three apart
+	insert, err := tx.PrepareContext(ctx, `INSERT INTO currency(id, value, pub_date, source, created_at) VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP) ON CONFLICT (id, pub_date) DO NOTHING`)
+	if err != nil {
+		return nil, stats, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer insert.Close()
+
+	update, err := tx.PrepareContext(ctx, `UPDATE currency SET updated_at = CURRENT_TIMESTAMP, value = ?, source = ? WHERE id = ? AND pub_date = ? AND (value <> ? OR source <> ?)`)
+	if err != nil {
+		return nil, stats, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer update.Close()
+
+	// validating each c individually
+	for i := range cs {
+		if err := cs[i].Validate(); err != nil {
+			return nil, stats, err
+		}
+
+		c := cs[i]
+		value, pubDate := c.Value.String(), c.PubDate.Time.Format(dateLayout)
+
+		result, err := insert.ExecContext(ctx, c.ID, value, pubDate, c.Source)
+		if err != nil {
+			return nil, stats, errors.Wrap(err, "failed to execute statement")
+		}
+
+		if affected, err := result.RowsAffected(); err != nil {
+			return nil, stats, errors.Wrap(err, "failed to obtain affected rows")
+		} else if affected > 0 {
+			stats.Inserted++
+			continue
+		}
+
+		result, err = update.ExecContext(ctx, value, c.Source, c.ID, pubDate, value, c.Source)
+		if err != nil {
+			return nil, stats, errors.Wrap(err, "failed to execute statement")
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, stats, errors.Wrap(err, "failed to obtain affected rows")
+		}
+
+		if affected > 0 {
+			stats.Updated++
+		} else {
+			stats.Unchanged++
+		}
+	}
+
+	// committing
+	if err = tx.Commit(); err != nil {
+		return nil, stats, errors.Wrap(err, "failed to commit database transaction")
+	}
+
+	return cs, stats, nil
+}
+
+func (s *defaultSQLiteStore) AllByID(ctx context.Context, id string) (cs []Currency, err error) {
+	return s.manyByQuery(ctx, "SELECT * FROM currency WHERE id = ? ORDER BY pub_date DESC", id)
+}
+
+func (s *defaultSQLiteStore) AsOf(ctx context.Context, id string, date time.Time) (c Currency, err error) {
+	return s.oneByQuery(ctx, "SELECT * FROM currency WHERE id = ? AND pub_date <= ? ORDER BY pub_date DESC LIMIT 1", id, date.Format(dateLayout))
+}
+
+func (s *defaultSQLiteStore) RangeByID(ctx context.Context, id string, r Range) (cs []Currency, err error) {
+	// NOTE: dates are stored as YYYY-MM-DD, which are ordered as strings
+	q := "SELECT * FROM currency WHERE id = ?"
+	args := []interface{}{id}
+
+	if !r.From.IsZero() {
+		q += " AND pub_date >= ?"
+		args = append(args, r.From.Format(dateLayout))
+	}
+
+	if !r.To.IsZero() {
+		q += " AND pub_date <= ?"
+		args = append(args, r.To.Format(dateLayout))
+	}
+
+	// keyset pagination, continuing after the last date of the previous page
+	if !r.After.IsZero() {
+		if r.Order == OrderAsc {
+			q += " AND pub_date > ?"
+		} else {
+			q += " AND pub_date < ?"
+		}
+
+		args = append(args, r.After.Format(dateLayout))
+	}
+
+	if r.Order == OrderAsc {
+		q += " ORDER BY pub_date ASC"
+	} else {
+		q += " ORDER BY pub_date DESC"
+	}
+
+	if r.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, r.Limit)
+	}
+
+	return s.manyByQuery(ctx, q, args...)
+}
+
+func (s *defaultSQLiteStore) AllLatest(ctx context.Context) (cs []Currency, err error) {
+	return s.manyByQuery(ctx, "SELECT * FROM currency WHERE pub_date = (SELECT MAX(pub_date) FROM currency) ORDER BY id")
+}
+
+func (s *defaultSQLiteStore) FeedState(ctx context.Context, source string) (state FeedState, err error) {
+	err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql("SELECT * FROM feed_state WHERE source = ?", source).
+		LoadOneContext(ctx, &state)
+
+	if err != nil {
+		if err == dbr.ErrNotFound {
+			return state, ErrFeedStateNotFound
+		}
+
+		return state, err
+	}
+
+	return state, nil
+}
+
+func (s *defaultSQLiteStore) SaveFeedState(ctx context.Context, state FeedState) (err error) {
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertBySql(
+			"INSERT INTO feed_state(source, etag, last_modified, content_hash, checked_at) VALUES(?, ?, ?, ?, ?) "+
+				"ON CONFLICT (source) DO UPDATE SET etag = excluded.etag, last_modified = excluded.last_modified, content_hash = excluded.content_hash, checked_at = excluded.checked_at",
+			state.Source,
+			state.ETag,
+			state.LastModified,
+			state.ContentHash,
+			state.CheckedAt,
+		).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to save feed state")
+	}
+
+	return nil
+}
+
+func (s *defaultSQLiteStore) CreateImportRun(ctx context.Context, r ImportReport) (_ ImportReport, err error) {
+	result, err := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("import_runs").
+		Columns(
+			"source",
+			"started_at",
+			"finished_at",
+			"skipped",
+			"snapshots",
+			"items_seen",
+			"items_inserted",
+			"items_updated",
+			"items_unchanged",
+			"items_rejected",
+			"discrepancies",
+			"error",
+		).
+		Record(&r).
+		ExecContext(ctx)
+
+	if err != nil {
+		return r, errors.Wrap(err, "failed to create import run")
+	}
+
+	if r.ID, err = result.LastInsertId(); err != nil {
+		return r, errors.Wrap(err, "failed to obtain import run id")
+	}
+
+	return r, nil
+}
+
+func (s *defaultSQLiteStore) ImportRuns(ctx context.Context, limit int) (rs []ImportReport, err error) {
+	rs = make([]ImportReport, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("import_runs").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &rs); err != nil {
+		return nil, errors.Wrap(err, "failed to load import runs")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultSQLiteStore) Quarantine(ctx context.Context, rs []Rejection) (err error) {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("quarantine").
+		Columns("source", "pub_date", "payload", "reason", "created_at")
+
+	for i := range rs {
+		stmt = stmt.Record(&rs[i])
+	}
+
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to quarantine rejections")
+	}
+
+	return nil
+}
+
+func (s *defaultSQLiteStore) Rejections(ctx context.Context, limit int) (rs []Rejection, err error) {
+	rs = make([]Rejection, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("quarantine").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &rs); err != nil {
+		return nil, errors.Wrap(err, "failed to load rejections")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultSQLiteStore) CreateDiscrepancies(ctx context.Context, ds []Discrepancy) (err error) {
+	if len(ds) == 0 {
+		return nil
+	}
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("discrepancies").
+		Columns("currency_id", "pub_date", "source", "value", "chosen_source", "chosen_value", "deviation", "created_at")
+
+	for i := range ds {
+		stmt = stmt.Record(&ds[i])
+	}
+
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to create discrepancies")
+	}
+
+	return nil
+}
+
+func (s *defaultSQLiteStore) Discrepancies(ctx context.Context, limit int) (ds []Discrepancy, err error) {
+	ds = make([]Discrepancy, 0)
+
+	stmt := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("discrepancies").
+		OrderDesc("id")
+
+	if limit > 0 {
+		stmt = stmt.Limit(uint64(limit))
+	}
+
+	if _, err = stmt.LoadContext(ctx, &ds); err != nil {
+		return nil, errors.Wrap(err, "failed to load discrepancies")
+	}
+
+	return ds, nil
+}
+
+func (s *defaultSQLiteStore) Observations(ctx context.Context) (obs []Observation, err error) {
+	obs = make([]Observation, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		SelectBySql("SELECT id, MIN(pub_date) AS first_pub_date, MAX(pub_date) AS last_pub_date, COUNT(*) AS observations FROM currency GROUP BY id ORDER BY id").
+		LoadContext(ctx, &obs)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load currency observations")
+	}
+
+	return obs, nil
+}
+
+func (s *defaultSQLiteStore) Aggregate(ctx context.Context, id string, period Period, from, to time.Time) (as []Aggregate, err error) {
+	if err = period.Validate(); err != nil {
+		return nil, err
+	}
+
+	// NOTE: text values can't be compared by SQL, thus aggregating them here
+	cs, err := s.RangeByID(ctx, id, Range{From: from, To: to, Order: OrderAsc})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load currency values")
+	}
+
+	return aggregateValues(cs, period), nil
+}
+
+func (s *defaultSQLiteStore) CreateAlertRule(ctx context.Context, r AlertRule) (_ AlertRule, err error) {
+	result, err := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		InsertInto("alert_rules").
+		Columns(
+			"name",
+			"kind",
+			"currency_id",
+			"base",
+			"direction",
+			"threshold",
+			"days",
+			"cooldown_minutes",
+			"created_at",
+		).
+		Record(&r).
+		ExecContext(ctx)
+
+	if err != nil {
+		return r, errors.Wrap(err, "failed to create alert rule")
+	}
+
+	if r.ID, err = result.LastInsertId(); err != nil {
+		return r, errors.Wrap(err, "failed to obtain alert rule id")
+	}
+
+	return r, nil
+}
+
+func (s *defaultSQLiteStore) AlertRules(ctx context.Context) (rs []AlertRule, err error) {
+	rs = make([]AlertRule, 0)
+
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		Select("*").
+		From("alert_rules").
+		OrderAsc("id").
+		LoadContext(ctx, &rs)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load alert rules")
+	}
+
+	return rs, nil
+}
+
+func (s *defaultSQLiteStore) DeleteAlertRule(ctx context.Context, id int64) (err error) {
+	result, err := s.connection.NewSession(&dbr.NullEventReceiver{}).
+		DeleteFrom("alert_rules").
+		Where("id = ?", id).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to delete alert rule")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain affected rows")
+	}
+
+	if affected == 0 {
+		return ErrAlertRuleNotFound
+	}
+
+	return nil
+}
+
+func (s *defaultSQLiteStore) AlertFired(ctx context.Context, id int64, firedAt time.Time, fingerprint string) (err error) {
+	_, err = s.connection.NewSession(&dbr.NullEventReceiver{}).
+		UpdateBySql("UPDATE alert_rules SET last_fired_at = ?, last_fingerprint = ? WHERE id = ?", firedAt, fingerprint, id).
+		ExecContext(ctx)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to update alert rule")
+	}
+
+	return nil
+}
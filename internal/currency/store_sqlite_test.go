@@ -0,0 +1,276 @@
+package currency_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/currency/storetest"
+	"github.com/gocraft/dbr/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSQLiteTestStore initializes a store in a temporary database file
+func newSQLiteTestStore(t *testing.T) (currency.Store, *dbr.Connection) {
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "tetest")
+	a.NoError(err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	connection, err := currency.OpenSQLite(filepath.Join(dir, "rates.db"))
+	if !a.NoError(err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { connection.Close() })
+
+	store, err := currency.NewSQLiteStore(connection)
+	if !a.NoError(err) {
+		t.FailNow()
+	}
+
+	return store, connection
+}
+
+func TestNewSQLiteStore(t *testing.T) {
+	a := assert.New(t)
+
+	store, err := currency.NewSQLiteStore(nil)
+	a.Equal(currency.ErrNilDatabase, err)
+	a.Nil(store)
+
+	// the schema is created in WAL mode, and creating it again is harmless
+	_, connection := newSQLiteTestStore(t)
+
+	var mode string
+	a.NoError(connection.QueryRow("PRAGMA journal_mode").Scan(&mode))
+	a.Equal("wal", mode)
+
+	_, err = currency.NewSQLiteStore(connection)
+	a.NoError(err)
+}
+
+func TestSQLiteStore(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	store, _ := newSQLiteTestStore(t)
+
+	date := func(s string) dbr.NullTime {
+		d, err := time.Parse("2006-01-02", s)
+		a.NoError(err)
+		return dbr.NewNullTime(d)
+	}
+
+	_, _, err := store.BulkCreate(ctx, nil)
+	a.Equal(currency.ErrNoData, err)
+
+	// a transaction isn't even started within a cancelled context
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, _, err = store.BulkCreate(cancelled, []currency.Currency{
+		{ID: "USD", Value: decimal.RequireFromString("1.1000"), PubDate: date("2020-03-16"), Source: "rss"},
+	})
+	a.Equal(context.Canceled, errors.Cause(err))
+	a.Contains(err.Error(), "failed to initialize database transaction")
+
+	_, stats, err := store.BulkCreate(ctx, []currency.Currency{
+		{ID: "USD", Value: decimal.RequireFromString("1.1000"), PubDate: date("2020-03-16"), Source: "rss"},
+		{ID: "USD", Value: decimal.RequireFromString("1.1100"), PubDate: date("2020-03-17"), Source: "rss"},
+		{ID: "USD", Value: decimal.RequireFromString("1.1200"), PubDate: date("2020-03-18"), Source: "rss"},
+		{ID: "JPY", Value: decimal.RequireFromString("118.5000"), PubDate: date("2020-03-18"), Source: "rss"},
+		{ID: "GBP", Value: decimal.RequireFromString("0.9000"), PubDate: date("2020-03-17"), Source: "rss"},
+	})
+
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Inserted: 5}, stats)
+
+	// the same upsert semantics as the MySQL store
+	_, stats, err = store.BulkCreate(ctx, []currency.Currency{
+		{ID: "USD", Value: decimal.RequireFromString("1.1250"), PubDate: date("2020-03-18"), Source: "rss"},
+		{ID: "JPY", Value: decimal.RequireFromString("118.5"), PubDate: date("2020-03-18"), Source: "rss"},
+		{ID: "GBP", Value: decimal.RequireFromString("0.9000"), PubDate: date("2020-03-17"), Source: "ecb"},
+		{ID: "JPY", Value: decimal.RequireFromString("118.0000"), PubDate: date("2020-03-13"), Source: "rss"},
+	})
+
+	a.NoError(err)
+	a.Equal(currency.UpsertStats{Inserted: 1, Updated: 2, Unchanged: 1}, stats)
+
+	// latest values, exact decimals
+	cs, err := store.AllLatest(ctx)
+	a.NoError(err)
+	a.Len(cs, 2)
+	a.Equal("JPY", cs[0].ID)
+	a.Equal("118.5", cs[0].Value.String())
+	a.False(cs[0].UpdatedAt.Valid)
+	a.Equal("USD", cs[1].ID)
+	a.Equal("1.125", cs[1].Value.String())
+	a.True(cs[1].UpdatedAt.Valid)
+	a.Equal(date("2020-03-18").Time, cs[1].PubDate.Time)
+
+	cs, err = store.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Len(cs, 3)
+	a.Equal(date("2020-03-18").Time, cs[0].PubDate.Time)
+
+	// as of a date
+	c, err := store.AsOf(ctx, "USD", date("2020-03-17").Time.Add(12*time.Hour))
+	a.NoError(err)
+	a.Equal("1.11", c.Value.String())
+
+	_, err = store.AsOf(ctx, "USD", date("2020-03-15").Time)
+	a.Equal(currency.ErrCurrencyNotFound, err)
+
+	// ranges, continuing after a cursor
+	cs, err = store.RangeByID(ctx, "USD", currency.Range{
+		From:  date("2020-03-01").Time,
+		To:    date("2020-03-31").Time,
+		After: date("2020-03-16").Time,
+		Order: currency.OrderAsc,
+		Limit: 1,
+	})
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal(date("2020-03-17").Time, cs[0].PubDate.Time)
+
+	// observations
+	ostore := store.(currency.ObservationStore)
+
+	obs, err := ostore.Observations(ctx)
+	a.NoError(err)
+	a.Len(obs, 3)
+	a.Equal("JPY", obs[1].ID)
+	a.Equal(2, obs[1].Count)
+	a.Equal(date("2020-03-13").Time, obs[1].FirstPubDate.Time)
+	a.Equal(date("2020-03-18").Time, obs[1].LastPubDate.Time)
+
+	// aggregates
+	astore := store.(currency.AggregateStore)
+
+	as, err := astore.Aggregate(ctx, "USD", currency.PeriodWeek, time.Time{}, time.Time{})
+	a.NoError(err)
+	a.Len(as, 1)
+	a.Equal(date("2020-03-16").Time, as[0].PeriodStart.Time)
+	a.Equal("1.1", as[0].Open.String())
+	a.Equal("1.125", as[0].High.String())
+	a.Equal("1.1", as[0].Low.String())
+	a.Equal("1.125", as[0].Close.String())
+	a.Equal(3, as[0].Count)
+
+	_, err = astore.Aggregate(ctx, "USD", "fortnight", time.Time{}, time.Time{})
+	a.Error(err)
+}
+
+func TestSQLiteStore_Logs(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	store, _ := newSQLiteTestStore(t)
+	now := time.Date(2020, 3, 19, 17, 0, 0, 0, time.UTC)
+
+	// feed state
+	fss := store.(currency.FeedStateStore)
+
+	_, err := fss.FeedState(ctx, "feed")
+	a.Equal(currency.ErrFeedStateNotFound, err)
+
+	a.NoError(fss.SaveFeedState(ctx, currency.FeedState{Source: "feed", ETag: `"v1"`}))
+	a.NoError(fss.SaveFeedState(ctx, currency.FeedState{Source: "feed", ETag: `"v2"`, CheckedAt: dbr.NewNullTime(now)}))
+
+	state, err := fss.FeedState(ctx, "feed")
+	a.NoError(err)
+	a.Equal(`"v2"`, state.ETag)
+	a.Equal(now, state.CheckedAt.Time)
+
+	// import runs
+	irs := store.(currency.ImportRunStore)
+
+	r, err := irs.CreateImportRun(ctx, currency.ImportReport{Source: "feed", StartedAt: dbr.NewNullTime(now), Seen: 3, Inserted: 3})
+	a.NoError(err)
+	a.EqualValues(1, r.ID)
+
+	r, err = irs.CreateImportRun(ctx, currency.ImportReport{Source: "feed", StartedAt: dbr.NewNullTime(now), Skipped: true})
+	a.NoError(err)
+	a.EqualValues(2, r.ID)
+
+	rs, err := irs.ImportRuns(ctx, 10)
+	a.NoError(err)
+	a.Len(rs, 2)
+	a.True(rs[0].Skipped)
+	a.Equal(3, rs[1].Inserted)
+
+	// quarantine
+	qs := store.(currency.QuarantineStore)
+
+	a.NoError(qs.Quarantine(ctx, []currency.Rejection{
+		{Source: "feed", Payload: "USD abc", Reason: "failed to parse value: abc", CreatedAt: dbr.NewNullTime(now)},
+	}))
+
+	rejections, err := qs.Rejections(ctx, 10)
+	a.NoError(err)
+	a.Len(rejections, 1)
+	a.Equal("USD abc", rejections[0].Payload)
+	a.False(rejections[0].PubDate.Valid)
+
+	// discrepancies
+	dstore := store.(currency.DiscrepancyStore)
+
+	a.NoError(dstore.CreateDiscrepancies(ctx, []currency.Discrepancy{
+		{
+			CurrencyID:   "JPY",
+			PubDate:      dbr.NewNullTime(now),
+			Source:       "secondary",
+			Value:        decimal.RequireFromString("118.15"),
+			ChosenSource: "primary",
+			ChosenValue:  decimal.RequireFromString("118.00"),
+			Deviation:    0.0013,
+			CreatedAt:    dbr.NewNullTime(now),
+		},
+	}))
+
+	ds, err := dstore.Discrepancies(ctx, 5)
+	a.NoError(err)
+	a.Len(ds, 1)
+	a.Equal("118.15", ds[0].Value.String())
+	a.Equal(0.0013, ds[0].Deviation)
+
+	// alert rules
+	alerts := store.(currency.AlertStore)
+
+	rule, err := alerts.CreateAlertRule(ctx, currency.AlertRule{
+		Name:       "EUR/USD crosses 1.1",
+		Kind:       currency.AlertThreshold,
+		CurrencyID: "USD",
+		Base:       "EUR",
+		Direction:  currency.DirectionCross,
+		Threshold:  decimal.RequireFromString("1.1"),
+		CreatedAt:  dbr.NewNullTime(now),
+	})
+	a.NoError(err)
+	a.EqualValues(1, rule.ID)
+
+	a.NoError(alerts.AlertFired(ctx, rule.ID, now, "1:threshold:2020-03-19"))
+
+	rules, err := alerts.AlertRules(ctx)
+	a.NoError(err)
+	a.Len(rules, 1)
+	a.Equal("1.1", rules[0].Threshold.String())
+	a.Equal(now, rules[0].LastFiredAt.Time)
+	a.Equal("1:threshold:2020-03-19", rules[0].LastFingerprint)
+
+	a.NoError(alerts.DeleteAlertRule(ctx, rule.ID))
+	a.Equal(currency.ErrAlertRuleNotFound, alerts.DeleteAlertRule(ctx, rule.ID))
+}
+
+func TestSQLiteStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) currency.Store {
+		store, _ := newSQLiteTestStore(t)
+		return store
+	})
+}
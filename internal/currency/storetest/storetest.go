@@ -0,0 +1,391 @@
+// Package storetest is the conformance suite, which every implementation
+// of currency.Store must pass, i.e. from a test of the store's package:
+//
+//	func TestMyStore_Conformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) currency.Store {
+//			return newMyTestStore(t)
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/gocraft/dbr/v2"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// Factory initializes a new empty store for a single test,
+// releasing it afterwards by t.Cleanup if necessary
+type Factory func(t *testing.T) currency.Store
+
+// Run runs the whole suite against the stores of a given factory,
+// each test is given a store of its own
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s currency.Store)
+	}{
+		{"Upsert", testUpsert},
+		{"Latest", testLatest},
+		{"History", testHistory},
+		{"Empty", testEmpty},
+		{"Concurrency", testConcurrency},
+		{"Cancellation", testCancellation},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, factory(t))
+		})
+	}
+}
+
+// date parses a publication date
+func date(s string) dbr.NullTime {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+
+	return dbr.NewNullTime(d)
+}
+
+// item returns a currency value published on a given date
+func item(id string, value string, pubDate string, source string) currency.Currency {
+	return currency.Currency{
+		ID:      id,
+		Value:   decimal.RequireFromString(value),
+		PubDate: date(pubDate),
+		Source:  source,
+	}
+}
+
+// days returns the publication dates of given currencies
+// NOTE: comparing formatted dates to ignore time and location
+func days(cs []currency.Currency) (ds []string) {
+	ds = make([]string, len(cs))
+	for i, c := range cs {
+		ds[i] = c.PubDate.Time.Format("2006-01-02")
+	}
+
+	return ds
+}
+
+// ids returns the IDs of given currencies
+func ids(cs []currency.Currency) (ss []string) {
+	ss = make([]string, len(cs))
+	for i, c := range cs {
+		ss[i] = c.ID
+	}
+
+	return ss
+}
+
+// store stores given currencies, stopping the test on failure
+func store(t *testing.T, s currency.Store, cs ...currency.Currency) currency.UpsertStats {
+	_, stats, err := s.BulkCreate(context.Background(), cs)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return stats
+}
+
+// one returns the only stored value of a currency of a given date
+func one(t *testing.T, s currency.Store, id string, pubDate string) (c currency.Currency) {
+	cs, err := s.AllByID(context.Background(), id)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	for _, v := range cs {
+		if v.PubDate.Time.Format("2006-01-02") == pubDate {
+			return v
+		}
+	}
+
+	t.Fatalf("currency %s isn't stored for date: %s", id, pubDate)
+
+	return c
+}
+
+// testUpsert tells inserted, updated and unchanged items apart, where an
+// item is updated only if either its value or its source has changed
+func testUpsert(t *testing.T, s currency.Store) {
+	a := assert.New(t)
+
+	stats := store(t, s,
+		item("USD", "1.1000", "2020-03-17", "rss"),
+		item("JPY", "118.0000", "2020-03-17", "rss"),
+	)
+	a.Equal(currency.UpsertStats{Inserted: 2}, stats)
+
+	usd := one(t, s, "USD", "2020-03-17")
+	a.True(usd.Value.Equal(decimal.RequireFromString("1.1")))
+	a.Equal("rss", usd.Source)
+	a.True(usd.CreatedAt.Valid)
+	a.False(usd.UpdatedAt.Valid)
+
+	// the same value with a different scale is unchanged
+	stats = store(t, s,
+		item("USD", "1.1", "2020-03-17", "rss"),
+		item("JPY", "118.5000", "2020-03-17", "rss"),
+		item("GBP", "0.9000", "2020-03-17", "ecb"),
+	)
+	a.Equal(currency.UpsertStats{Inserted: 1, Updated: 1, Unchanged: 1}, stats)
+
+	// a different source alone is an update
+	stats = store(t, s, item("GBP", "0.9000", "2020-03-17", "rss"))
+	a.Equal(currency.UpsertStats{Updated: 1}, stats)
+
+	usd = one(t, s, "USD", "2020-03-17")
+	a.False(usd.UpdatedAt.Valid)
+
+	jpy := one(t, s, "JPY", "2020-03-17")
+	a.True(jpy.Value.Equal(decimal.RequireFromString("118.5")))
+	a.True(jpy.CreatedAt.Valid)
+	a.True(jpy.UpdatedAt.Valid)
+
+	gbp := one(t, s, "GBP", "2020-03-17")
+	a.Equal("rss", gbp.Source)
+	a.True(gbp.UpdatedAt.Valid)
+
+	// the same currency of another date is a separate item
+	stats = store(t, s, item("USD", "1.1000", "2020-03-18", "rss"))
+	a.Equal(currency.UpsertStats{Inserted: 1}, stats)
+
+	cs, err := s.AllByID(context.Background(), "USD")
+	a.NoError(err)
+	a.Len(cs, 2)
+}
+
+// testLatest selects every currency of the latest publication date, ordered by ID
+func testLatest(t *testing.T, s currency.Store) {
+	a := assert.New(t)
+
+	store(t, s,
+		item("USD", "1.1000", "2020-03-16", "rss"),
+		item("JPY", "118.0000", "2020-03-16", "rss"),
+		item("GBP", "0.9000", "2020-03-16", "rss"),
+	)
+
+	store(t, s,
+		item("USD", "1.1100", "2020-03-17", "rss"),
+		item("GBP", "0.9100", "2020-03-17", "rss"),
+	)
+
+	// storing an older date afterwards doesn't matter
+	store(t, s, item("JPY", "117.0000", "2020-03-13", "rss"))
+
+	cs, err := s.AllLatest(context.Background())
+	a.NoError(err)
+	a.Equal([]string{"GBP", "USD"}, ids(cs))
+	a.Equal([]string{"2020-03-17", "2020-03-17"}, days(cs))
+
+	if a.Len(cs, 2) {
+		a.True(cs[0].Value.Equal(decimal.RequireFromString("0.91")))
+		a.True(cs[1].Value.Equal(decimal.RequireFromString("1.11")))
+	}
+}
+
+// testHistory orders the values of a currency by publication date, where
+// dates differ by their days and months only (i.e. 2020-02-03 and 2020-03-02)
+func testHistory(t *testing.T, s currency.Store) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	store(t, s,
+		item("USD", "1.0300", "2020-03-10", "rss"),
+		item("USD", "1.0100", "2020-02-03", "rss"),
+		item("JPY", "118.0000", "2020-03-02", "rss"),
+		item("USD", "1.0400", "2020-03-20", "rss"),
+		item("USD", "1.0200", "2020-03-02", "rss"),
+	)
+
+	// latest first
+	cs, err := s.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Equal([]string{"2020-03-20", "2020-03-10", "2020-03-02", "2020-02-03"}, days(cs))
+	a.Equal([]string{"USD", "USD", "USD", "USD"}, ids(cs))
+
+	// ranges are inclusive
+	cs, err = s.RangeByID(ctx, "USD", currency.Range{
+		From:  date("2020-03-02").Time,
+		To:    date("2020-03-20").Time,
+		Order: currency.OrderAsc,
+	})
+	a.NoError(err)
+	a.Equal([]string{"2020-03-02", "2020-03-10", "2020-03-20"}, days(cs))
+
+	cs, err = s.RangeByID(ctx, "USD", currency.Range{Order: currency.OrderDesc, Limit: 2})
+	a.NoError(err)
+	a.Equal([]string{"2020-03-20", "2020-03-10"}, days(cs))
+
+	// continuing after a cursor
+	cs, err = s.RangeByID(ctx, "USD", currency.Range{After: date("2020-03-10").Time, Order: currency.OrderDesc})
+	a.NoError(err)
+	a.Equal([]string{"2020-03-02", "2020-02-03"}, days(cs))
+
+	cs, err = s.RangeByID(ctx, "USD", currency.Range{After: date("2020-03-02").Time, Order: currency.OrderAsc, Limit: 1})
+	a.NoError(err)
+	a.Equal([]string{"2020-03-10"}, days(cs))
+
+	// as of a date, which is inclusive too
+	c, err := s.AsOf(ctx, "USD", date("2020-03-15").Time)
+	a.NoError(err)
+	a.Equal("2020-03-10", c.PubDate.Time.Format("2006-01-02"))
+	a.True(c.Value.Equal(decimal.RequireFromString("1.03")))
+
+	c, err = s.AsOf(ctx, "USD", date("2020-03-02").Time)
+	a.NoError(err)
+	a.Equal("2020-03-02", c.PubDate.Time.Format("2006-01-02"))
+
+	_, err = s.AsOf(ctx, "USD", date("2020-02-02").Time)
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+}
+
+// testEmpty reads nothing from an empty store, and stores nothing
+func testEmpty(t *testing.T, s currency.Store) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	_, _, err := s.BulkCreate(ctx, nil)
+	a.Equal(currency.ErrNoData, errors.Cause(err))
+
+	_, _, err = s.BulkCreate(ctx, []currency.Currency{})
+	a.Equal(currency.ErrNoData, errors.Cause(err))
+
+	cs, err := s.AllLatest(ctx)
+	a.NoError(err)
+	a.Empty(cs)
+
+	cs, err = s.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Empty(cs)
+
+	cs, err = s.RangeByID(ctx, "USD", currency.Range{Order: currency.OrderAsc})
+	a.NoError(err)
+	a.Empty(cs)
+
+	_, err = s.AsOf(ctx, "USD", date("2020-03-17").Time)
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+
+	// other currencies are still missing
+	store(t, s, item("USD", "1.1000", "2020-03-17", "rss"))
+
+	cs, err = s.AllByID(ctx, "JPY")
+	a.NoError(err)
+	a.Empty(cs)
+
+	_, err = s.AsOf(ctx, "JPY", date("2020-03-17").Time)
+	a.Equal(currency.ErrCurrencyNotFound, errors.Cause(err))
+}
+
+// testConcurrency stores and reads from several goroutines at once
+func testConcurrency(t *testing.T, s currency.Store) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	const writers = 8
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+
+		pubDate := fmt.Sprintf("2020-03-%02d", i+1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _, err := s.BulkCreate(ctx, []currency.Currency{
+				item("USD", "1.1000", pubDate, "rss"),
+				item("JPY", "118.0000", pubDate, "rss"),
+			})
+			a.NoError(err)
+
+			// every writer stores the same item too
+			_, _, err = s.BulkCreate(ctx, []currency.Currency{item("GBP", "0.9000", "2020-03-01", "rss")})
+			a.NoError(err)
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_, err := s.AllLatest(ctx)
+			a.NoError(err)
+
+			_, err = s.AllByID(ctx, "USD")
+			a.NoError(err)
+
+			_, err = s.AsOf(ctx, "USD", date("2020-03-31").Time)
+			if errors.Cause(err) != currency.ErrCurrencyNotFound {
+				a.NoError(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	cs, err := s.AllByID(ctx, "USD")
+	a.NoError(err)
+	a.Len(cs, writers)
+
+	cs, err = s.AllByID(ctx, "GBP")
+	a.NoError(err)
+	a.Len(cs, 1)
+
+	cs, err = s.AllLatest(ctx)
+	a.NoError(err)
+	a.Equal([]string{"JPY", "USD"}, ids(cs))
+
+	latest := fmt.Sprintf("2020-03-%02d", writers)
+	a.Equal([]string{latest, latest}, days(cs))
+}
+
+// testCancellation fails every call of a canceled context, storing nothing
+func testCancellation(t *testing.T, s currency.Store) {
+	a := assert.New(t)
+
+	store(t, s, item("USD", "1.1000", "2020-03-16", "rss"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := s.BulkCreate(ctx, []currency.Currency{
+		item("USD", "1.1200", "2020-03-16", "rss"),
+		item("USD", "1.1300", "2020-03-17", "rss"),
+	})
+	a.Equal(context.Canceled, errors.Cause(err))
+
+	_, err = s.AllLatest(ctx)
+	a.Equal(context.Canceled, errors.Cause(err))
+
+	_, err = s.AllByID(ctx, "USD")
+	a.Equal(context.Canceled, errors.Cause(err))
+
+	_, err = s.AsOf(ctx, "USD", date("2020-03-17").Time)
+	a.Equal(context.Canceled, errors.Cause(err))
+
+	_, err = s.RangeByID(ctx, "USD", currency.Range{Order: currency.OrderAsc})
+	a.Equal(context.Canceled, errors.Cause(err))
+
+	// neither item of the batch is stored
+	cs, err := s.AllByID(context.Background(), "USD")
+	a.NoError(err)
+	a.Equal([]string{"2020-03-16"}, days(cs))
+
+	if a.Len(cs, 1) {
+		a.True(cs[0].Value.Equal(decimal.RequireFromString("1.1")))
+	}
+}
@@ -0,0 +1,87 @@
+package currency
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// divisionPlaces is the number of decimal places kept by intermediate
+// divisions (i.e. cross rates), before the final rounding is applied
+const divisionPlaces = 16
+
+// RoundingMode represents the way a value is rounded to the precision
+type RoundingMode string
+
+// rounding modes
+const (
+	// RoundHalfUp rounds half away from zero (i.e. 1.00005 -> 1.0001)
+	RoundHalfUp RoundingMode = "half_up"
+
+	// RoundHalfEven rounds half to the nearest even digit, also
+	// known as the banker's rounding (i.e. 1.00005 -> 1.0000)
+	RoundHalfEven RoundingMode = "half_even"
+
+	// RoundDown truncates towards zero
+	RoundDown RoundingMode = "down"
+
+	// RoundUp rounds away from zero
+	RoundUp RoundingMode = "up"
+)
+
+// Rounding represents the precision of values and the way they're rounded
+type Rounding struct {
+	Places int32
+	Mode   RoundingMode
+}
+
+// DefaultValueRounding matches the precision of stored values, i.e. decimal(15,4)
+var DefaultValueRounding = Rounding{
+	Places: 4,
+	Mode:   RoundHalfUp,
+}
+
+// DefaultConversionRounding is the precision of conversion rates and amounts
+var DefaultConversionRounding = Rounding{
+	Places: 6,
+	Mode:   RoundHalfEven,
+}
+
+// Validate checks whether the rounding is supported
+func (r Rounding) Validate() (err error) {
+	if r.Places < 0 || r.Places > divisionPlaces {
+		return errors.Errorf("invalid rounding precision: %d", r.Places)
+	}
+
+	switch r.Mode {
+	case RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return nil
+	default:
+		return errors.Errorf("unsupported rounding mode: %s", r.Mode)
+	}
+}
+
+// Round rounds a given value to the precision
+func (r Rounding) Round(d decimal.Decimal) decimal.Decimal {
+	switch r.Mode {
+	case RoundHalfEven:
+		return d.RoundBank(r.Places)
+	case RoundDown:
+		return d.RoundDown(r.Places)
+	case RoundUp:
+		return d.RoundUp(r.Places)
+	default:
+		return d.Round(r.Places)
+	}
+}
+
+// ParseValue parses a currency value as an exact decimal
+func ParseValue(s string) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return d, errors.Wrapf(err, "failed to parse value: %s", s)
+	}
+
+	return d, nil
+}
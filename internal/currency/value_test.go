@@ -0,0 +1,98 @@
+package currency_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/gocraft/dbr/v2"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRounding_Round(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		mode     currency.RoundingMode
+		value    string
+		expected string
+	}{
+		{currency.RoundHalfUp, "1.00005", "1.0001"},
+		{currency.RoundHalfUp, "-1.00005", "-1.0001"},
+		{currency.RoundHalfEven, "1.00005", "1"},
+		{currency.RoundHalfEven, "1.00015", "1.0002"},
+		{currency.RoundDown, "1.00009", "1"},
+		{currency.RoundUp, "1.00001", "1.0001"},
+	}
+
+	for _, tt := range tests {
+		r := currency.Rounding{Places: 4, Mode: tt.mode}
+		a.NoError(r.Validate())
+		a.Equal(tt.expected, r.Round(decimal.RequireFromString(tt.value)).String(), "%s %s", tt.mode, tt.value)
+	}
+
+	// unsupported rounding
+	a.Error(currency.Rounding{Places: 4, Mode: "ceil"}.Validate())
+	a.Error(currency.Rounding{Places: -1, Mode: currency.RoundHalfUp}.Validate())
+}
+
+func TestParseValue(t *testing.T) {
+	a := assert.New(t)
+
+	v, err := currency.ParseValue(" 118.15 ")
+	a.NoError(err)
+	a.Equal("118.15", v.String())
+
+	_, err = currency.ParseValue("abc")
+	a.Error(err)
+}
+
+func TestManager_ImportRounding(t *testing.T) {
+	a := assert.New(t)
+
+	pubDate := time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC)
+
+	src := &staticSource{
+		ss: []currency.Snapshot{
+			{
+				PubDate: pubDate,
+				Currencies: []currency.Currency{
+					{ID: "USD", Value: decimal.RequireFromString("1.08015"), PubDate: dbr.NewNullTime(pubDate)},
+				},
+			},
+		},
+	}
+
+	store := currency.NewMemoryStore()
+
+	m, err := currency.NewManager(store, currency.WithFeedSource(src))
+	a.NoError(err)
+
+	// values are rounded to the stored precision
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	cs, err := store.AllLatest(context.Background())
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.Equal("1.0802", cs[0].Value.String())
+
+	// the same value of a different scale is not an update
+	src.ss[0].Currencies[0].Value = decimal.RequireFromString("1.080200")
+
+	r, err := m.Import(context.Background())
+	a.NoError(err)
+	a.Equal(1, r.Unchanged)
+
+	// values are encoded as exact strings
+	payload, err := json.Marshal(cs[0])
+	a.NoError(err)
+	a.Contains(string(payload), `"value":"1.0802"`)
+
+	// invalid rounding
+	_, err = currency.NewManager(store, currency.WithValueRounding(currency.Rounding{Places: 2, Mode: "sideways"}))
+	a.Error(err)
+}
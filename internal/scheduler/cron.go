@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cronSchedule is a standard 5-field cron expression, where
+// each field is represented as a set of allowed values
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// standard cron treats day of month and day of week as
+	// alternatives if both are restricted
+	domStar, dowStar bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week (0 is Sunday)
+}
+
+// ParseCron parses a 5-field cron expression: minute, hour, day of month,
+// month and day of week; each field supports "*", lists ("1,15"),
+// ranges ("1-5") and steps ("*/15", "0-30/10")
+func ParseCron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, errors.Wrapf(ErrInvalidSchedule, "expected %d cron fields, got %d", len(cronFields), len(fields))
+	}
+
+	sets := make([]uint64, len(fields))
+
+	for i, f := range fields {
+		set, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cron field: %s", f)
+		}
+
+		sets[i] = set
+	}
+
+	s := &cronSchedule{
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     sets[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}
+
+	return s, nil
+}
+
+func parseCronField(f string, bounds cronField) (set uint64, err error) {
+	for _, part := range strings.Split(f, ",") {
+		lo, hi, step := bounds.min, bounds.max, 1
+
+		// extracting step
+		if i := strings.Index(part, "/"); i != -1 {
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, ErrInvalidSchedule
+			}
+
+			part = part[:i]
+		}
+
+		// extracting range
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bs := strings.SplitN(part, "-", 2)
+
+			if lo, err = strconv.Atoi(bs[0]); err != nil {
+				return 0, ErrInvalidSchedule
+			}
+
+			if hi, err = strconv.Atoi(bs[1]); err != nil {
+				return 0, ErrInvalidSchedule
+			}
+		default:
+			if lo, err = strconv.Atoi(part); err != nil {
+				return 0, ErrInvalidSchedule
+			}
+
+			// a single value with a step means "starting from"
+			if step == 1 {
+				hi = lo
+			}
+		}
+
+		if lo < bounds.min || hi > bounds.max || lo > hi {
+			return 0, ErrInvalidSchedule
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch, dowMatch := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+
+	// starting from the next whole minute
+	t := after.Truncate(time.Minute).Add(time.Minute)
+
+	// giving up if nothing matches within 5 years (i.e. "0 0 31 2 *")
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errors
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+// Schedule describes when a job must run
+type Schedule interface {
+	// Next returns the next activation time after a given time,
+	// zero time means that there is no next activation
+	Next(after time.Time) time.Time
+}
+
+// Parse parses a schedule specification, which is either a descriptor:
+//
+//	@every <duration>  -- fixed interval (i.e. "@every 1h30m")
+//	@hourly, @daily    -- shorthands for the respective cron expressions
+//	@ecb               -- ECB publication time (see ECB)
+//
+// or a standard 5-field cron expression (i.e. "15 16 * * 1-5"); a given
+// check of business days is used by @ecb (see ECB)
+func Parse(spec string, isBusinessDay func(day time.Time) bool) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	switch {
+	case spec == "":
+		return nil, errors.Wrap(ErrInvalidSchedule, "empty specification")
+	case strings.HasPrefix(spec, "@every "):
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidSchedule, "invalid interval: %s", err)
+		}
+
+		return Every(d)
+	case spec == "@hourly":
+		return ParseCron("0 * * * *")
+	case spec == "@daily":
+		return ParseCron("0 0 * * *")
+	case spec == "@ecb":
+		return ECB(DefaultECBDelay, isBusinessDay), nil
+	}
+
+	return ParseCron(spec)
+}
+
+//---------------------------------------------------------------------------
+// fixed interval
+//---------------------------------------------------------------------------
+
+type interval time.Duration
+
+// Every returns a schedule which activates at a fixed interval
+func Every(d time.Duration) (Schedule, error) {
+	if d < time.Second {
+		return nil, errors.Wrapf(ErrInvalidSchedule, "interval is too short: %s", d)
+	}
+
+	return interval(d), nil
+}
+
+func (i interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+//---------------------------------------------------------------------------
+// ECB publication time
+//---------------------------------------------------------------------------
+
+// DefaultECBDelay is a margin after the ECB publication time,
+// because the reference rates are not published exactly on time
+const DefaultECBDelay = 15 * time.Minute
+
+// ecbLocation is the time zone of the ECB publication time (CET/CEST)
+var ecbLocation = loadLocation("Europe/Berlin", time.FixedZone("CET", 3600))
+
+func loadLocation(name string, fallback *time.Location) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fallback
+	}
+
+	return loc
+}
+
+type ecbSchedule struct {
+	delay         time.Duration
+	isBusinessDay func(day time.Time) bool
+}
+
+// ECB returns a schedule which activates on business days after the ECB
+// reference rates are published, which is around 16:00 CET, plus a given
+// delay; business days are Monday through Friday unless a check is given
+// (i.e. of the TARGET2 calendar, which also excludes the closing days)
+func ECB(delay time.Duration, isBusinessDay func(day time.Time) bool) Schedule {
+	if isBusinessDay == nil {
+		isBusinessDay = isWeekday
+	}
+
+	return ecbSchedule{delay: delay, isBusinessDay: isBusinessDay}
+}
+
+// isWeekday checks whether a given day is Monday through Friday
+func isWeekday(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+func (s ecbSchedule) Next(after time.Time) time.Time {
+	t := after.In(ecbLocation)
+
+	// checking today and the following days until a business day
+	// is found with the publication time after a given time
+	// NOTE: closing days and weekends never add up to more than a week
+	for i := 0; i < 8; i++ {
+		day := t.AddDate(0, 0, i)
+		next := time.Date(day.Year(), day.Month(), day.Day(), 16, 0, 0, 0, ecbLocation).Add(s.delay)
+
+		if !s.isBusinessDay(day) {
+			continue
+		}
+
+		if next.After(after) {
+			return next.In(after.Location())
+		}
+	}
+
+	return time.Time{}
+}
+
+//---------------------------------------------------------------------------
+// jitter
+//---------------------------------------------------------------------------
+
+type jittered struct {
+	schedule Schedule
+	max      time.Duration
+}
+
+// WithJitter delays each activation of a given schedule by a random
+// duration within [0, max), to avoid hitting upstream at the same time
+func WithJitter(s Schedule, max time.Duration) Schedule {
+	if max <= 0 {
+		return s
+	}
+
+	return jittered{schedule: s, max: max}
+}
+
+func (j jittered) Next(after time.Time) time.Time {
+	next := j.schedule.Next(after)
+	if next.IsZero() {
+		return next
+	}
+
+	return next.Add(time.Duration(rand.Int63n(int64(j.max))))
+}
@@ -0,0 +1,121 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	a := assert.New(t)
+
+	// Thursday
+	after := time.Date(2020, 3, 19, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2020, 3, 19, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 3, 19, 10, 15, 0, 0, time.UTC)},
+		{"15 16 * * 1-5", time.Date(2020, 3, 19, 16, 15, 0, 0, time.UTC)},
+		{"0 9 * * 6,0", time.Date(2020, 3, 21, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 2 29 2 *", time.Date(2024, 2, 29, 2, 30, 0, 0, time.UTC)},
+		{"0 0-6/3 * * *", time.Date(2020, 3, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := scheduler.ParseCron(tt.expr)
+		a.NoError(err, tt.expr)
+		a.Equal(tt.next, s.Next(after), tt.expr)
+	}
+
+	// impossible date
+	s, err := scheduler.ParseCron("0 0 31 2 *")
+	a.NoError(err)
+	a.True(s.Next(after).IsZero())
+
+	// invalid expressions
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := scheduler.ParseCron(expr)
+		a.Error(err, expr)
+	}
+}
+
+func TestParse(t *testing.T) {
+	a := assert.New(t)
+
+	after := time.Date(2020, 3, 19, 10, 7, 30, 0, time.UTC)
+
+	s, err := scheduler.Parse("@every 90m", nil)
+	a.NoError(err)
+	a.Equal(after.Add(90*time.Minute), s.Next(after))
+
+	s, err = scheduler.Parse("@hourly", nil)
+	a.NoError(err)
+	a.Equal(time.Date(2020, 3, 19, 11, 0, 0, 0, time.UTC), s.Next(after))
+
+	s, err = scheduler.Parse("@ecb", nil)
+	a.NoError(err)
+	a.NotNil(s)
+
+	for _, spec := range []string{"", "@every", "@every 1ms", "@yearly"} {
+		_, err = scheduler.Parse(spec, nil)
+		a.Error(err, spec)
+	}
+}
+
+func TestECB(t *testing.T) {
+	a := assert.New(t)
+
+	cet := time.FixedZone("CET", 3600)
+	s := scheduler.ECB(15*time.Minute, nil)
+
+	// Thursday morning: same day
+	a.True(time.Date(2020, 3, 19, 16, 15, 0, 0, cet).Equal(s.Next(time.Date(2020, 3, 19, 9, 0, 0, 0, cet))))
+
+	// Thursday evening: next day
+	a.True(time.Date(2020, 3, 20, 16, 15, 0, 0, cet).Equal(s.Next(time.Date(2020, 3, 19, 17, 0, 0, 0, cet))))
+
+	// Friday evening: skipping the weekend
+	a.True(time.Date(2020, 3, 23, 16, 15, 0, 0, cet).Equal(s.Next(time.Date(2020, 3, 20, 17, 0, 0, 0, cet))))
+
+	// Saturday: skipping to Monday
+	a.True(time.Date(2020, 3, 23, 16, 15, 0, 0, cet).Equal(s.Next(time.Date(2020, 3, 21, 12, 0, 0, 0, cet))))
+
+	// Thursday evening before Easter: only weekends are skipped by default, but
+	// Good Friday and Easter Monday are skipped as closing days of a given calendar
+	closed := map[string]bool{"2020-04-10": true, "2020-04-13": true, "2020-12-25": true}
+	s = scheduler.ECB(15*time.Minute, func(day time.Time) bool {
+		return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday && !closed[day.Format("2006-01-02")]
+	})
+
+	cest := time.FixedZone("CEST", 7200)
+	a.True(time.Date(2020, 4, 10, 16, 15, 0, 0, cest).Equal(scheduler.ECB(15*time.Minute, nil).Next(time.Date(2020, 4, 9, 17, 0, 0, 0, cest))))
+	a.True(time.Date(2020, 4, 14, 16, 15, 0, 0, cest).Equal(s.Next(time.Date(2020, 4, 9, 17, 0, 0, 0, cest))))
+
+	// Christmas Eve evening: skipping both Christmas days and the weekend
+	a.True(time.Date(2020, 12, 28, 16, 15, 0, 0, cet).Equal(s.Next(time.Date(2020, 12, 24, 17, 0, 0, 0, cet))))
+}
+
+func TestWithJitter(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := scheduler.Every(time.Hour)
+	a.NoError(err)
+
+	after := time.Date(2020, 3, 19, 10, 0, 0, 0, time.UTC)
+	j := scheduler.WithJitter(s, 5*time.Minute)
+
+	for i := 0; i < 100; i++ {
+		next := j.Next(after)
+		a.False(next.Before(after.Add(time.Hour)))
+		a.True(next.Before(after.Add(time.Hour + 5*time.Minute)))
+	}
+
+	// no jitter
+	a.Equal(s, scheduler.WithJitter(s, 0))
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// errors
+var (
+	ErrNilSchedule = errors.New("schedule is nil")
+	ErrNilJob      = errors.New("job is nil")
+)
+
+// Job is a function which is run by the scheduler
+type Job func(ctx context.Context) error
+
+// Scheduler runs a job in the background according to its schedule
+type Scheduler struct {
+	name     string
+	schedule Schedule
+	job      Job
+	logger   *zap.Logger
+}
+
+// New initializes a new scheduler
+func New(name string, s Schedule, job Job, logger *zap.Logger) (*Scheduler, error) {
+	if s == nil {
+		return nil, ErrNilSchedule
+	}
+
+	if job == nil {
+		return nil, ErrNilJob
+	}
+
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	sch := &Scheduler{
+		name:     name,
+		schedule: s,
+		job:      job,
+		logger:   logger.Named("[scheduler]").With(zap.String("job", name)),
+	}
+
+	return sch, nil
+}
+
+// Run blocks and runs the job on schedule until the context is cancelled
+// NOTE: job errors are logged, but never stop the scheduler
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.logger.Warn("schedule has no next activation; stopping")
+			return nil
+		}
+
+		s.logger.Info("next run scheduled", zap.Time("at", next))
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		start := time.Now()
+
+		if err := s.job(ctx); err != nil {
+			s.logger.Error("job run has failed", zap.Duration("took", time.Since(start)), zap.Error(err))
+			continue
+		}
+
+		s.logger.Info("job run has succeeded", zap.Duration("took", time.Since(start)))
+	}
+}
@@ -0,0 +1,50 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/scheduler"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// fastSchedule activates every few milliseconds
+type fastSchedule struct{}
+
+func (fastSchedule) Next(after time.Time) time.Time {
+	return after.Add(5 * time.Millisecond)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	a := assert.New(t)
+
+	var runs int32
+
+	s, err := scheduler.New("test", fastSchedule{}, func(ctx context.Context) error {
+		// failing runs must not stop the scheduler
+		if atomic.AddInt32(&runs, 1)%2 == 0 {
+			return errors.New("failed")
+		}
+
+		return nil
+	}, nil)
+
+	a.NoError(err)
+	a.NotNil(s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	a.Equal(context.DeadlineExceeded, s.Run(ctx))
+	a.True(atomic.LoadInt32(&runs) >= 3)
+
+	// invalid arguments
+	_, err = scheduler.New("test", nil, func(ctx context.Context) error { return nil }, nil)
+	a.Equal(scheduler.ErrNilSchedule, err)
+
+	_, err = scheduler.New("test", fastSchedule{}, nil, nil)
+	a.Equal(scheduler.ErrNilJob, err)
+}
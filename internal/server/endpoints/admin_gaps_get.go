@@ -0,0 +1,50 @@
+package endpoints
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+)
+
+func AdminGapsGet(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	q := r.URL.Query()
+
+	// the last 30 days until yesterday by default
+	var from, to time.Time
+
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid from (expected YYYY-MM-DD): %s", v)
+		}
+	}
+
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid to (expected YYYY-MM-DD): %s", v)
+		}
+	}
+
+	// every stored currency unless specific ones are requested, i.e. ?ids=USD,JPY
+	var ids []string
+	if v := q.Get("ids"); v != "" {
+		ids = strings.Split(v, ",")
+	}
+
+	result, err = e.manager.Gaps(r.Context(), from, to, ids...)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrInvalidHistoryQuery:
+		return nil, http.StatusBadRequest, err
+	case currency.ErrCurrencyNotFound: // handling 404
+		return nil, http.StatusNotFound, err
+	case currency.ErrUnsupportedByStore:
+		return nil, http.StatusNotImplemented, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
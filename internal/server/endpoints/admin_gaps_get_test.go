@@ -0,0 +1,50 @@
+package endpoints_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/server/endpoints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointAdminGapsGet(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/v1/admin/gaps?"+query, nil)
+		a.NoError(err)
+
+		rr := httptest.NewRecorder()
+		endpoints.NewEndpoint(m, endpoints.AdminGapsGet).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	resp := struct {
+		endpoints.Response
+		Payload currency.GapReport `json:"payload"`
+	}{}
+
+	// stored values end on Thursday, Friday is missing
+	a.NoError(json.Unmarshal(get("from=2020-03-16&to=2020-03-22&ids=USD").Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Equal(5, resp.Payload.BusinessDays)
+	a.Equal([]string{"2020-03-20"}, resp.Payload.MissingDates)
+	a.Len(resp.Payload.Gaps, 1)
+	a.Equal("USD", resp.Payload.Gaps[0].CurrencyID)
+	a.Equal(4, resp.Payload.Gaps[0].Expected)
+
+	a.Equal(http.StatusBadRequest, get("from=x").Code)
+	a.Equal(http.StatusBadRequest, get("from=2020-03-22&to=2020-03-16").Code)
+	a.Equal(http.StatusNotFound, get("ids=CHF").Code)
+}
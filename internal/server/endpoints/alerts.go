@@ -0,0 +1,64 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+func AlertRulesGet(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	result, err = e.manager.AlertRules(r.Context())
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrUnsupportedByStore:
+		return nil, http.StatusNotImplemented, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
+
+func AlertRuleCreate(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	var rule currency.AlertRule
+
+	if err = json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(err, "invalid alert rule payload")
+	}
+
+	result, err = e.manager.CreateAlertRule(r.Context(), rule)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusCreated, nil
+	case currency.ErrInvalidAlertRule:
+		return nil, http.StatusBadRequest, err
+	case currency.ErrUnsupportedByStore:
+		return nil, http.StatusNotImplemented, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
+
+func AlertRuleDelete(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Errorf("invalid alert rule id: %s", chi.URLParam(r, "id"))
+	}
+
+	err = e.manager.DeleteAlertRule(r.Context(), id)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return nil, http.StatusOK, nil
+	case currency.ErrAlertRuleNotFound: // handling 404
+		return nil, http.StatusNotFound, err
+	case currency.ErrUnsupportedByStore:
+		return nil, http.StatusNotImplemented, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
@@ -0,0 +1,85 @@
+package endpoints_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/server/endpoints"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointAlertRules(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore())
+	a.NoError(err)
+
+	create := func(payload string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/api/v1/admin/alerts", strings.NewReader(payload))
+		a.NoError(err)
+
+		rr := httptest.NewRecorder()
+		endpoints.NewEndpoint(m, endpoints.AlertRuleCreate).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	remove := func(id string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("DELETE", "/api/v1/admin/alerts/"+id, nil)
+		a.NoError(err)
+
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("id", id)
+
+		req = req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, chiCtx))
+		rr := httptest.NewRecorder()
+
+		endpoints.NewEndpoint(m, endpoints.AlertRuleDelete).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	// creating
+	created := struct {
+		endpoints.Response
+		Payload currency.AlertRule `json:"payload"`
+	}{}
+
+	rr := create(`{"kind": "movement", "currency_id": "jpy", "threshold": "2", "cooldown_minutes": 30}`)
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &created))
+	a.Equal(http.StatusCreated, created.StatusCode)
+	a.Equal(int64(1), created.Payload.ID)
+	a.Equal("JPY", created.Payload.CurrencyID)
+	a.Equal(1, created.Payload.Days)
+	a.Equal("JPY moves more than 2% over 1 days", created.Payload.Name)
+
+	a.Equal(http.StatusBadRequest, create(`{"kind": "movement", "currency_id": "JPY"}`).Code)
+	a.Equal(http.StatusBadRequest, create(`not json`).Code)
+
+	// listing
+	req, err := http.NewRequest("GET", "/api/v1/admin/alerts", nil)
+	a.NoError(err)
+
+	rr = httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.AlertRulesGet).ServeHTTP(rr, req)
+
+	listed := struct {
+		endpoints.Response
+		Payload []currency.AlertRule `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &listed))
+	a.Equal(http.StatusOK, listed.StatusCode)
+	a.Len(listed.Payload, 1)
+	a.Equal(30, listed.Payload[0].CooldownMinutes)
+
+	// deleting
+	a.Equal(http.StatusOK, remove("1").Code)
+	a.Equal(http.StatusNotFound, remove("1").Code)
+	a.Equal(http.StatusBadRequest, remove("x").Code)
+}
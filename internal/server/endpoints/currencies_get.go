@@ -0,0 +1,31 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+)
+
+func CurrenciesGet(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	// listing only the stored currencies unless the whole catalog is requested
+	all := false
+
+	if v := r.URL.Query().Get("all"); v != "" {
+		if all, err = strconv.ParseBool(v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid all: %s", v)
+		}
+	}
+
+	result, err = e.manager.Currencies(r.Context(), all)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrUnsupportedByStore:
+		return nil, http.StatusNotImplemented, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
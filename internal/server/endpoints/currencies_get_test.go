@@ -0,0 +1,69 @@
+package endpoints_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/server/endpoints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointCurrenciesGet(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	req, err := http.NewRequest("GET", "/api/v1/currencies", nil)
+	a.NoError(err)
+
+	rr := httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.CurrenciesGet).ServeHTTP(rr, req)
+
+	resp := struct {
+		endpoints.Response
+		Payload []currency.KnownCurrency `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Empty(resp.Error)
+	a.Len(resp.Payload, 2)
+
+	// ordered by code
+	a.Equal("GBP", resp.Payload[0].Code)
+	a.Equal("826", resp.Payload[0].Numeric)
+	a.Equal("Pound Sterling", resp.Payload[0].Name)
+	a.Equal(2, resp.Payload[0].MinorUnits)
+
+	a.Equal("USD", resp.Payload[1].Code)
+	a.Equal(3, resp.Payload[1].Count)
+	a.Equal(time.Date(2020, 3, 17, 0, 0, 0, 0, time.UTC), resp.Payload[1].FirstPubDate.Time.UTC())
+	a.Equal(time.Date(2020, 3, 19, 0, 0, 0, 0, time.UTC), resp.Payload[1].LastPubDate.Time.UTC())
+
+	// the whole catalog
+	req, err = http.NewRequest("GET", "/api/v1/currencies?all=true", nil)
+	a.NoError(err)
+
+	rr = httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.CurrenciesGet).ServeHTTP(rr, req)
+
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Len(resp.Payload, len(currency.Catalog()))
+
+	// invalid flag
+	req, err = http.NewRequest("GET", "/api/v1/currencies?all=maybe", nil)
+	a.NoError(err)
+
+	rr = httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.CurrenciesGet).ServeHTTP(rr, req)
+	a.Equal(http.StatusBadRequest, rr.Code)
+}
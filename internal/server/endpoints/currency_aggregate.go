@@ -0,0 +1,50 @@
+package endpoints
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+func CurrencyAggregate(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	q := r.URL.Query()
+
+	// aggregating by month unless another period is requested
+	period := currency.PeriodMonth
+	if v := q.Get("period"); v != "" {
+		period = currency.Period(v)
+	}
+
+	// date range is optional, aggregating the whole history by default
+	var from, to time.Time
+
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid from (expected YYYY-MM-DD): %s", v)
+		}
+	}
+
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid to (expected YYYY-MM-DD): %s", v)
+		}
+	}
+
+	result, err = e.manager.Aggregate(r.Context(), chi.URLParam(r, "id"), period, from, to)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrEmptyCurrencyID, currency.ErrUnsupportedPeriod, currency.ErrInvalidHistoryQuery:
+		return nil, http.StatusBadRequest, err
+	case currency.ErrCurrencyNotFound: // handling 404
+		return nil, http.StatusNotFound, err
+	case currency.ErrUnsupportedByStore:
+		return nil, http.StatusNotImplemented, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
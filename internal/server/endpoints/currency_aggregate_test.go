@@ -0,0 +1,58 @@
+package endpoints_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/server/endpoints"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointCurrencyAggregate(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/v1/currency/USD/aggregate?"+query, nil)
+		a.NoError(err)
+
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("id", "USD")
+
+		req = req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, chiCtx))
+		rr := httptest.NewRecorder()
+
+		endpoints.NewEndpoint(m, endpoints.CurrencyAggregate).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	resp := struct {
+		endpoints.Response
+		Payload []currency.Aggregate `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(get("period=week").Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Len(resp.Payload, 1)
+	a.Equal("2020-03-16", resp.Payload[0].PeriodStart.Time.Format("2006-01-02"))
+	a.Equal("1.0982", resp.Payload[0].Open.String())
+	a.Equal("1.0982", resp.Payload[0].High.String())
+	a.Equal("1.0801", resp.Payload[0].Low.String())
+	a.Equal("1.0801", resp.Payload[0].Close.String())
+	a.Equal("1.090567", resp.Payload[0].Mean.String())
+	a.Equal(3, resp.Payload[0].Count)
+
+	a.Equal(http.StatusBadRequest, get("period=fortnight").Code)
+	a.Equal(http.StatusBadRequest, get("from=x").Code)
+	a.Equal(http.StatusNotFound, get("to=2020-01-01").Code)
+}
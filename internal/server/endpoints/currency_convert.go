@@ -0,0 +1,45 @@
+package endpoints
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func CurrencyConvert(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	q := r.URL.Query()
+
+	// amount is optional, converting a single unit by default
+	amount := decimal.New(1, 0)
+
+	if v := q.Get("amount"); v != "" {
+		if amount, err = currency.ParseValue(v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid amount: %s", v)
+		}
+	}
+
+	// date is optional, using the latest rates by default
+	var date time.Time
+
+	if v := q.Get("date"); v != "" {
+		if date, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid date (expected YYYY-MM-DD): %s", v)
+		}
+	}
+
+	result, err = e.manager.Convert(r.Context(), q.Get("from"), q.Get("to"), amount, date)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrEmptyCurrencyID:
+		return nil, http.StatusBadRequest, err
+	case currency.ErrCurrencyNotFound: // handling 404
+		return nil, http.StatusNotFound, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
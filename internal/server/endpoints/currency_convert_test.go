@@ -0,0 +1,59 @@
+package endpoints_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/server/endpoints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointCurrencyConvert(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	req, err := http.NewRequest("GET", "/api/v1/convert?from=USD&to=GBP&amount=100&date=2020-03-18", nil)
+	a.NoError(err)
+
+	rr := httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.CurrencyConvert).ServeHTTP(rr, req)
+
+	resp := struct {
+		endpoints.Response
+		Payload currency.Conversion `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Empty(resp.Error)
+	a.Equal("0.850558", resp.Payload.Rate.String())
+	a.Equal("85.055789", resp.Payload.Result.String())
+	a.Equal(time.Date(2020, 3, 18, 0, 0, 0, 0, time.UTC), resp.Payload.PubDate.Time.UTC())
+
+	// bad requests and unknown currencies
+	for uri, code := range map[string]int{
+		"/api/v1/convert?from=USD&to=GBP&amount=abc":      http.StatusBadRequest,
+		"/api/v1/convert?from=USD&to=GBP&date=19.03.2020": http.StatusBadRequest,
+		"/api/v1/convert?to=GBP":                          http.StatusBadRequest,
+		"/api/v1/convert?from=USD&to=XXX":                 http.StatusNotFound,
+		"/api/v1/convert?from=USD&to=GBP&date=2020-01-01": http.StatusNotFound,
+		"/api/v1/convert?from=EUR&to=GBP&amount=2.5":      http.StatusOK,
+		"/api/v1/convert?from=USD&to=GBP&date=2020-03-21": http.StatusOK,
+	} {
+		req, err = http.NewRequest("GET", uri, nil)
+		a.NoError(err)
+
+		rr = httptest.NewRecorder()
+		endpoints.NewEndpoint(m, endpoints.CurrencyConvert).ServeHTTP(rr, req)
+		a.Equal(code, rr.Code, uri)
+	}
+}
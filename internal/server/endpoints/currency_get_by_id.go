@@ -2,17 +2,72 @@ package endpoints
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/agubarev/tetest/internal/currency"
 	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
 )
 
 func CurrencyGetByID(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
-	// obtaining currency history by ID
-	switch result, err = e.manager.GetAllByID(r.Context(), chi.URLParam(r, "id")); err {
+	q := r.URL.Query()
+
+	// obtaining a single value effective on a given date if it's requested,
+	// otherwise a page of currency history, relative to an optional base currency
+	if v := q.Get("as_of"); v != "" {
+		var date time.Time
+
+		if date, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid as_of (expected YYYY-MM-DD): %s", v)
+		}
+
+		result, err = e.manager.AsOf(r.Context(), chi.URLParam(r, "id"), q.Get("base"), date)
+	} else {
+		hq := currency.HistoryQuery{
+			Cursor: q.Get("cursor"),
+			Order:  currency.SortOrder(q.Get("order")),
+		}
+
+		if v := q.Get("from"); v != "" {
+			if hq.From, err = time.Parse("2006-01-02", v); err != nil {
+				return nil, http.StatusBadRequest, errors.Errorf("invalid from (expected YYYY-MM-DD): %s", v)
+			}
+		}
+
+		if v := q.Get("to"); v != "" {
+			if hq.To, err = time.Parse("2006-01-02", v); err != nil {
+				return nil, http.StatusBadRequest, errors.Errorf("invalid to (expected YYYY-MM-DD): %s", v)
+			}
+		}
+
+		if v := q.Get("limit"); v != "" {
+			if hq.Limit, err = strconv.Atoi(v); err != nil {
+				return nil, http.StatusBadRequest, errors.Errorf("invalid limit: %s", v)
+			}
+		}
+
+		var p currency.HistoryPage
+
+		if p, err = e.manager.HistoryByID(r.Context(), chi.URLParam(r, "id"), q.Get("base"), hq); err == nil {
+			result = page{
+				items: p.Currencies,
+				pagination: Pagination{
+					Limit:      p.Limit,
+					Order:      string(p.Order),
+					NextCursor: p.NextCursor,
+					HasMore:    p.HasMore(),
+				},
+			}
+		}
+	}
+
+	switch errors.Cause(err) {
 	case nil: // all good
 		return result, http.StatusOK, nil
-	case currency.ErrCurrencyNotFound: // handling 404
+	case currency.ErrEmptyCurrencyID, currency.ErrInvalidHistoryQuery:
+		return nil, http.StatusBadRequest, err
+	case currency.ErrCurrencyNotFound, currency.ErrStaleRate: // handling 404
 		return nil, http.StatusNotFound, err
 	default: // regular error
 		return nil, http.StatusInternalServerError, err
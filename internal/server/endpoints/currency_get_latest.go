@@ -2,14 +2,19 @@ package endpoints
 
 import (
 	"net/http"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
 )
 
 func CurrencyGetLatest(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
-	// obtaining latest currency values
-	result, err = e.manager.GetLatest(r.Context())
-	if err != nil {
+	// obtaining latest currency values, relative to an optional base currency
+	switch result, err = e.manager.GetLatest(r.Context(), r.URL.Query().Get("base")); errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrCurrencyNotFound: // unknown base currency
+		return nil, http.StatusNotFound, err
+	default: // regular error
 		return nil, http.StatusInternalServerError, err
 	}
-
-	return result, http.StatusOK, nil
 }
@@ -4,36 +4,57 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
+	"time"
 
 	"github.com/agubarev/tetest/internal/currency"
 	"github.com/agubarev/tetest/internal/server/endpoints"
 	"github.com/go-chi/chi"
+	"github.com/gocraft/dbr/v2"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
-// NOTE: since this is a simple test project, I'm not using
-// any abstraction for the feed source for this test, only a
-// simple in-memory store to fetch test values from
+// staticSource is a feed source which yields predefined snapshots,
+// so the endpoints can be tested without hitting the network
+type staticSource struct{}
 
-func init() {
-	os.Setenv("FEED_URL", "https://www.bank.lv/vk/ecb_rss.xml")
+func (s staticSource) Name() string {
+	return "static"
+}
+
+func (s staticSource) Fetch(ctx context.Context) ([]currency.Snapshot, error) {
+	ss := make([]currency.Snapshot, 0)
+
+	for i, v := range []string{"1.0801", "1.0934", "1.0982"} {
+		pubDate := time.Date(2020, 3, 19-i, 0, 0, 0, 0, time.UTC)
+
+		ss = append(ss, currency.Snapshot{
+			PubDate: pubDate,
+			Currencies: []currency.Currency{
+				{ID: "USD", Value: decimal.RequireFromString(v), PubDate: dbr.NewNullTime(pubDate)},
+				{ID: "GBP", Value: decimal.RequireFromString("0.93"), PubDate: dbr.NewNullTime(pubDate)},
+			},
+		})
+	}
+
+	return ss, nil
 }
 
 func TestEndpointGetLatest(t *testing.T) {
 	a := assert.New(t)
 
 	// initializing currency manager
-	m, err := currency.NewManager(currency.NewMemoryStore(), os.Getenv("FEED_URL"))
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
 	a.NoError(err)
 	a.NotNil(m)
 
-	// importing real values from a real external endpoint
-	a.NoError(m.Import(context.Background()))
+	// importing test values
+	_, err = m.Import(context.Background())
+	a.NoError(err)
 
 	req, err := http.NewRequest("GET", "/api/v1/currency", nil)
 	a.NoError(err)
@@ -56,12 +77,13 @@ func TestEndpointGetByID(t *testing.T) {
 	a := assert.New(t)
 
 	// initializing currency manager
-	m, err := currency.NewManager(currency.NewMemoryStore(), os.Getenv("FEED_URL"))
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
 	a.NoError(err)
 	a.NotNil(m)
 
-	// importing real values from a real external endpoint
-	a.NoError(m.Import(context.Background()))
+	// importing test values
+	_, err = m.Import(context.Background())
+	a.NoError(err)
 
 	req, err := http.NewRequest("GET", "/api/v1/currency/USD", nil)
 	a.NoError(err)
@@ -85,3 +107,197 @@ func TestEndpointGetByID(t *testing.T) {
 	a.Empty(resp.Error)
 	a.NotEmpty(resp.Payload)
 }
+
+func TestEndpointGetLatestRebased(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	req, err := http.NewRequest("GET", "/api/v1/currency?base=usd", nil)
+	a.NoError(err)
+
+	rr := httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.CurrencyGetLatest).ServeHTTP(rr, req)
+
+	resp := struct {
+		endpoints.Response
+		Payload []currency.Currency `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+
+	values := make(map[string]string)
+	for _, c := range resp.Payload {
+		values[c.ID] = c.Value.String()
+	}
+
+	a.Len(values, 3)
+	a.Equal("1", values["USD"])
+	a.Equal("0.92584", values["EUR"])
+	a.Equal("0.861031", values["GBP"])
+
+	// unknown base currency
+	req, err = http.NewRequest("GET", "/api/v1/currency?base=XXX", nil)
+	a.NoError(err)
+
+	rr = httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.CurrencyGetLatest).ServeHTTP(rr, req)
+	a.Equal(http.StatusNotFound, rr.Code)
+}
+
+func TestEndpointGetByIDRebased(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	req, err := http.NewRequest("GET", "/api/v1/currency/EUR?base=USD", nil)
+	a.NoError(err)
+
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("id", "EUR")
+
+	req = req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, chiCtx))
+	rr := httptest.NewRecorder()
+
+	endpoints.NewEndpoint(m, endpoints.CurrencyGetByID).ServeHTTP(rr, req)
+
+	resp := struct {
+		endpoints.Response
+		Payload []currency.Currency `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Len(resp.Payload, 3)
+
+	for _, c := range resp.Payload {
+		a.Equal("EUR", c.ID)
+		a.True(c.Value.LessThan(decimal.New(1, 0)))
+	}
+}
+
+func TestEndpointGetByIDAsOf(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/v1/currency/USD?"+query, nil)
+		a.NoError(err)
+
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("id", "USD")
+
+		req = req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, chiCtx))
+		rr := httptest.NewRecorder()
+
+		endpoints.NewEndpoint(m, endpoints.CurrencyGetByID).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	resp := struct {
+		endpoints.Response
+		Payload currency.AsOfRate `json:"payload"`
+	}{}
+
+	// sunday, falling back to thursday
+	rr := get("as_of=2020-03-22")
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Equal("USD", resp.Payload.ID)
+	a.Equal("1.0801", resp.Payload.Value.String())
+	a.Equal("2020-03-19", resp.Payload.PubDate.Time.Format("2006-01-02"))
+	a.Equal("2020-03-22", resp.Payload.AsOf.Time.Format("2006-01-02"))
+	a.Equal(3, resp.Payload.Staleness)
+	a.Equal(currency.DefaultMaxStaleness, resp.Payload.MaxStaleness)
+
+	// outside of the staleness window
+	a.Equal(http.StatusNotFound, get("as_of=2020-04-01").Code)
+
+	// before the first publication
+	a.Equal(http.StatusNotFound, get("as_of=2020-03-15").Code)
+
+	// invalid date
+	a.Equal(http.StatusBadRequest, get("as_of=15.03.2020").Code)
+}
+
+func TestEndpointGetByIDPaginated(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/v1/currency/USD?"+query, nil)
+		a.NoError(err)
+
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("id", "USD")
+
+		req = req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, chiCtx))
+		rr := httptest.NewRecorder()
+
+		endpoints.NewEndpoint(m, endpoints.CurrencyGetByID).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	resp := struct {
+		endpoints.Response
+		Payload []currency.Currency `json:"payload"`
+	}{}
+
+	// first page, oldest first
+	a.NoError(json.Unmarshal(get("order=asc&limit=2").Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Len(resp.Payload, 2)
+	a.Equal("1.0982", resp.Payload[0].Value.String())
+	a.NotNil(resp.Pagination)
+	a.Equal(2, resp.Pagination.Limit)
+	a.Equal("asc", resp.Pagination.Order)
+	a.True(resp.Pagination.HasMore)
+	a.NotEmpty(resp.Pagination.NextCursor)
+
+	// last page
+	cursor := resp.Pagination.NextCursor
+	resp.Pagination = nil
+
+	a.NoError(json.Unmarshal(get("order=asc&limit=2&cursor="+cursor).Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Len(resp.Payload, 1)
+	a.Equal("1.0801", resp.Payload[0].Value.String())
+	a.False(resp.Pagination.HasMore)
+	a.Empty(resp.Pagination.NextCursor)
+
+	// date range
+	resp.Pagination = nil
+
+	a.NoError(json.Unmarshal(get("from=2020-03-18&to=2020-03-18").Body.Bytes(), &resp))
+	a.Len(resp.Payload, 1)
+	a.Equal("1.0934", resp.Payload[0].Value.String())
+
+	// invalid parameters
+	for _, query := range []string{"from=18.03.2020", "to=x", "limit=x", "limit=-1", "order=x", "cursor=x", "from=2020-03-19&to=2020-03-18"} {
+		a.Equal(http.StatusBadRequest, get(query).Code, query)
+	}
+
+	// nothing within the range
+	a.Equal(http.StatusNotFound, get("to=2020-01-01").Code)
+}
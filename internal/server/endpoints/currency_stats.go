@@ -0,0 +1,58 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+func CurrencyStats(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	q := r.URL.Query()
+
+	// several currencies may be requested at once, i.e. /currency/USD,JPY/stats
+	ids := strings.Split(chi.URLParam(r, "id"), ",")
+
+	var sq currency.StatsQuery
+
+	if v := q.Get("window"); v != "" {
+		if sq.Window, err = strconv.Atoi(v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid window: %s", v)
+		}
+	}
+
+	// change periods in calendar days, i.e. ?changes=1,7,30
+	if v := q.Get("changes"); v != "" {
+		for _, d := range strings.Split(v, ",") {
+			days, err := strconv.Atoi(strings.TrimSpace(d))
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.Errorf("invalid change period: %s", d)
+			}
+
+			sq.ChangeDays = append(sq.ChangeDays, days)
+		}
+	}
+
+	if v := q.Get("to"); v != "" {
+		if sq.To, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid to (expected YYYY-MM-DD): %s", v)
+		}
+	}
+
+	result, err = e.manager.Stats(r.Context(), ids, sq)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrEmptyCurrencyID, currency.ErrInvalidStatsQuery:
+		return nil, http.StatusBadRequest, err
+	case currency.ErrCurrencyNotFound: // handling 404
+		return nil, http.StatusNotFound, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
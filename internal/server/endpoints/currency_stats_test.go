@@ -0,0 +1,70 @@
+package endpoints_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/server/endpoints"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointCurrencyStats(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	get := func(id string, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/v1/currency/"+id+"/stats?"+query, nil)
+		a.NoError(err)
+
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("id", id)
+
+		req = req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, chiCtx))
+		rr := httptest.NewRecorder()
+
+		endpoints.NewEndpoint(m, endpoints.CurrencyStats).ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	resp := struct {
+		endpoints.Response
+		Payload []currency.Stats `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(get("USD,GBP", "window=3&changes=1,2").Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Len(resp.Payload, 2)
+
+	s := resp.Payload[0]
+	a.Equal("USD", s.ID)
+	a.Equal(3, s.Window)
+	a.Equal(3, s.Observations)
+	a.Equal("1.0801", s.Min.String())
+	a.Equal("1.0982", s.Max.String())
+	a.Len(s.Changes, 2)
+	a.Equal("-0.0133", s.Changes[0].Absolute.String())
+	a.Equal("-0.0181", s.Changes[1].Absolute.String())
+	a.Len(s.VolatilitySeries, 3)
+	a.Equal(s.Volatility, s.VolatilitySeries[2].Volatility)
+
+	// constant values
+	a.Equal("GBP", resp.Payload[1].ID)
+	a.Zero(resp.Payload[1].Volatility)
+	a.Equal("0", resp.Payload[1].Changes[0].Percent.String())
+
+	a.Equal(http.StatusBadRequest, get("USD", "window=x").Code)
+	a.Equal(http.StatusBadRequest, get("USD", "window=1").Code)
+	a.Equal(http.StatusBadRequest, get("USD", "changes=1,x").Code)
+	a.Equal(http.StatusBadRequest, get("USD", "to=x").Code)
+	a.Equal(http.StatusNotFound, get("USD", "to=2020-01-01").Code)
+}
@@ -0,0 +1,34 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+)
+
+// defaultDiscrepanciesLimit is the number of discrepancies returned unless specified
+const defaultDiscrepanciesLimit = 50
+
+func DiscrepanciesGet(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	limit := defaultDiscrepanciesLimit
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid limit: %s", v)
+		}
+	}
+
+	// obtaining the most recent disagreements between sources
+	result, err = e.manager.Discrepancies(r.Context(), limit)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrUnsupportedByStore:
+		return nil, http.StatusNotImplemented, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
@@ -0,0 +1,78 @@
+package endpoints_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/server/endpoints"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// skewedSource yields the same snapshots as staticSource,
+// but with USD values off by one percent
+type skewedSource struct{}
+
+func (s skewedSource) Name() string {
+	return "skewed"
+}
+
+func (s skewedSource) Fetch(ctx context.Context) ([]currency.Snapshot, error) {
+	ss, err := staticSource{}.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ss {
+		for j := range ss[i].Currencies {
+			if c := &ss[i].Currencies[j]; c.ID == "USD" {
+				c.Value = c.Value.Mul(decimal.RequireFromString("1.01"))
+			}
+		}
+	}
+
+	return ss, nil
+}
+
+func TestEndpointDiscrepanciesGet(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSources(staticSource{}, skewedSource{}))
+	a.NoError(err)
+
+	_, err = m.Import(context.Background())
+	a.NoError(err)
+
+	req, err := http.NewRequest("GET", "/api/v1/discrepancies?limit=2", nil)
+	a.NoError(err)
+
+	rr := httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.DiscrepanciesGet).ServeHTTP(rr, req)
+
+	resp := struct {
+		endpoints.Response
+		Payload []currency.Discrepancy `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Empty(resp.Error)
+	a.Len(resp.Payload, 2)
+
+	for _, d := range resp.Payload {
+		a.Equal("USD", d.CurrencyID)
+		a.Equal("skewed", d.Source)
+		a.Equal("static", d.ChosenSource)
+	}
+
+	// invalid limit
+	req, err = http.NewRequest("GET", "/api/v1/discrepancies?limit=0", nil)
+	a.NoError(err)
+
+	rr = httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.DiscrepanciesGet).ServeHTTP(rr, req)
+	a.Equal(http.StatusBadRequest, rr.Code)
+}
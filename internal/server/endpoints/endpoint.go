@@ -26,9 +26,26 @@ type Response struct {
 	StatusCode int         `json:"status_code"`
 	Error      string      `json:"error,omitempty"`
 	ExecTime   float64     `json:"exec_time"`
+	Pagination *Pagination `json:"pagination,omitempty"`
 	Payload    interface{} `json:"payload,omitempty"`
 }
 
+// Pagination represents the position of a paginated payload, the next
+// page is requested by passing the next cursor as ?cursor
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	Order      string `json:"order,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// page is returned by handlers of paginated endpoints, so that
+// the pagination is moved into the response envelope
+type page struct {
+	items      interface{}
+	pagination Pagination
+}
+
 // NOTE: usually I like to use that approach over canonical
 // middleware function nesting, but either way is fine
 type Endpoint struct {
@@ -83,11 +100,18 @@ func (e Endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		errMsg = err.Error()
 	}
 
+	// unwrapping paginated result
+	var pagination *Pagination
+	if p, ok := result.(page); ok {
+		result, pagination = p.items, &p.pagination
+	}
+
 	// ... handle error or pass it by right into a response
 	response, err := json.Marshal(Response{
 		StatusCode: code,
 		Error:      errMsg,
 		ExecTime:   time.Since(start).Seconds(),
+		Pagination: pagination,
 		Payload:    result,
 	})
 
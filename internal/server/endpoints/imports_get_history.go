@@ -0,0 +1,34 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/pkg/errors"
+)
+
+// defaultImportHistoryLimit is the number of runs returned unless specified
+const defaultImportHistoryLimit = 20
+
+func ImportsGetHistory(e Endpoint, w http.ResponseWriter, r *http.Request) (result interface{}, code int, err error) {
+	limit := defaultImportHistoryLimit
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid limit: %s", v)
+		}
+	}
+
+	// obtaining the most recent import runs
+	result, err = e.manager.ImportHistory(r.Context(), limit)
+
+	switch errors.Cause(err) {
+	case nil: // all good
+		return result, http.StatusOK, nil
+	case currency.ErrUnsupportedByStore:
+		return nil, http.StatusNotImplemented, err
+	default: // regular error
+		return nil, http.StatusInternalServerError, err
+	}
+}
@@ -0,0 +1,50 @@
+package endpoints_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agubarev/tetest/internal/currency"
+	"github.com/agubarev/tetest/internal/server/endpoints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointImportsGetHistory(t *testing.T) {
+	a := assert.New(t)
+
+	m, err := currency.NewManager(currency.NewMemoryStore(), currency.WithFeedSource(staticSource{}))
+	a.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		_, err = m.Import(context.Background())
+		a.NoError(err)
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/imports?limit=2", nil)
+	a.NoError(err)
+
+	rr := httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.ImportsGetHistory).ServeHTTP(rr, req)
+
+	resp := struct {
+		endpoints.Response
+		Payload []currency.ImportReport `json:"payload"`
+	}{}
+
+	a.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Empty(resp.Error)
+	a.Len(resp.Payload, 2)
+	a.EqualValues(3, resp.Payload[0].ID)
+	a.Equal(6, resp.Payload[0].Unchanged)
+
+	// invalid limit
+	req, err = http.NewRequest("GET", "/api/v1/imports?limit=abc", nil)
+	a.NoError(err)
+
+	rr = httptest.NewRecorder()
+	endpoints.NewEndpoint(m, endpoints.ImportsGetHistory).ServeHTTP(rr, req)
+	a.Equal(http.StatusBadRequest, rr.Code)
+}
@@ -21,6 +21,21 @@ func Run(ctx context.Context, m *currency.Manager, addr string) (err error) {
 		r.Route("/currency", func(r chi.Router) {
 			r.Method("GET", "/", endpoints.NewEndpoint(m, endpoints.CurrencyGetLatest))
 			r.Method("GET", "/{id}", endpoints.NewEndpoint(m, endpoints.CurrencyGetByID))
+			r.Method("GET", "/{id}/aggregate", endpoints.NewEndpoint(m, endpoints.CurrencyAggregate))
+			r.Method("GET", "/{id}/stats", endpoints.NewEndpoint(m, endpoints.CurrencyStats))
+		})
+
+		r.Method("GET", "/currencies", endpoints.NewEndpoint(m, endpoints.CurrenciesGet))
+		r.Method("GET", "/convert", endpoints.NewEndpoint(m, endpoints.CurrencyConvert))
+		r.Method("GET", "/imports", endpoints.NewEndpoint(m, endpoints.ImportsGetHistory))
+		r.Method("GET", "/discrepancies", endpoints.NewEndpoint(m, endpoints.DiscrepanciesGet))
+
+		// NOTE: admin endpoints are meant to be protected by the gateway
+		r.Route("/admin", func(r chi.Router) {
+			r.Method("GET", "/gaps", endpoints.NewEndpoint(m, endpoints.AdminGapsGet))
+			r.Method("GET", "/alerts", endpoints.NewEndpoint(m, endpoints.AlertRulesGet))
+			r.Method("POST", "/alerts", endpoints.NewEndpoint(m, endpoints.AlertRuleCreate))
+			r.Method("DELETE", "/alerts/{id}", endpoints.NewEndpoint(m, endpoints.AlertRuleDelete))
 		})
 	})
 